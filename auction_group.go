@@ -0,0 +1,157 @@
+package auctioneer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+	"golang.org/x/sync/errgroup"
+)
+
+// AuctionGroupResult holds the results of the requests submitted by
+// RequestAuctionGroup.
+type AuctionGroupResult struct {
+	LRP  AuctionResult
+	Task AuctionResult
+}
+
+// AuctionGroupError is returned by RequestAuctionGroup when either request
+// of the group fails.
+type AuctionGroupError struct {
+	// Operation is the request that failed first: OperationLRP or
+	// OperationTask.
+	Operation string
+	Err       error
+	// OtherErr is the error of the other request, if it also failed. It
+	// usually matches context.Canceled, from the group being canceled.
+	OtherErr error
+}
+
+func (e *AuctionGroupError) Error() string {
+	return fmt.Sprintf("%s auctions failed: %s", e.Operation, e.Err)
+}
+
+func (e *AuctionGroupError) Unwrap() error {
+	return e.Err
+}
+
+// RequestAuctionGroup submits lrpStarts and tasks concurrently through
+// client as a single group, under a context derived from ctx. The first
+// request to fail cancels the other if it is still in flight; client has
+// already spent any retries it is configured with by then, so the failure is
+// final. An empty slice is not submitted.
+func RequestAuctionGroup(logger lager.Logger, ctx context.Context, client ExtendedClient, lrpStarts []*LRPStartRequest, tasks []*TaskStartRequest) (AuctionGroupResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		result   AuctionGroupResult
+		lock     sync.Mutex
+		groupErr *AuctionGroupError
+		wg       sync.WaitGroup
+	)
+
+	fail := func(operation string, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if groupErr == nil {
+			groupErr = &AuctionGroupError{Operation: operation, Err: err}
+			cancel()
+			return
+		}
+		groupErr.OtherErr = err
+	}
+
+	if len(lrpStarts) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lrpResult, err := client.RequestLRPAuctionsWithResult(logger, ctx, lrpStarts)
+			if err != nil {
+				fail(OperationLRP, err)
+				return
+			}
+			result.LRP = lrpResult
+		}()
+	}
+
+	if len(tasks) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			taskResult, err := client.RequestTaskAuctionsWithResult(logger, ctx, tasks)
+			if err != nil {
+				fail(OperationTask, err)
+				return
+			}
+			result.Task = taskResult
+		}()
+	}
+
+	wg.Wait()
+
+	if groupErr != nil {
+		return result, groupErr
+	}
+
+	return result, nil
+}
+
+// AuctionBatch is one of the batches submitted by RequestAuctionBatches.
+type AuctionBatch struct {
+	LRPs  []*LRPStartRequest
+	Tasks []*TaskStartRequest
+}
+
+// AuctionBatchError is returned by RequestAuctionBatches when a batch
+// fails. It unwraps to the batch's *AuctionGroupError.
+type AuctionBatchError struct {
+	// Index is the position in the batches of the batch that failed first.
+	Index int
+	Err   error
+}
+
+func (e *AuctionBatchError) Error() string {
+	return fmt.Sprintf("auction batch %d failed: %s", e.Index, e.Err)
+}
+
+func (e *AuctionBatchError) Unwrap() error {
+	return e.Err
+}
+
+// RequestAuctionBatches submits independent batches concurrently through
+// client, each as a group with RequestAuctionGroup, at most concurrency at a
+// time, or all at once if concurrency is not positive. The first batch to
+// fail cancels the others that are in flight, keeps the rest from starting
+// and is returned as an *AuctionBatchError; canceling ctx stops the batches
+// the same way. The results are in the order of batches, empty for a batch
+// that failed or never started.
+func RequestAuctionBatches(logger lager.Logger, ctx context.Context, client ExtendedClient, concurrency int, batches []AuctionBatch) ([]AuctionGroupResult, error) {
+	group, ctx := errgroup.WithContext(ctx)
+	if concurrency > 0 {
+		group.SetLimit(concurrency)
+	}
+
+	results := make([]AuctionGroupResult, len(batches))
+	for i, batch := range batches {
+		i, batch := i, batch
+		group.Go(func() error {
+			err := ctx.Err()
+			if err != nil {
+				return err
+			}
+
+			result, err := RequestAuctionGroup(logger, ctx, client, batch.LRPs, batch.Tasks)
+			if err != nil {
+				return &AuctionBatchError{Index: i, Err: err}
+			}
+			results[i] = result
+			return nil
+		})
+	}
+
+	err := group.Wait()
+	return results, err
+}
@@ -0,0 +1,188 @@
+package auctioneer_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/auctioneer/auctioneerfakes"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequestAuctionGroup", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeClient *auctioneerfakes.FakeExtendedClient
+		lrpStarts  []*LRPStartRequest
+		tasks      []*TaskStartRequest
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeClient = &auctioneerfakes.FakeExtendedClient{}
+		lrpStarts = []*LRPStartRequest{{ProcessGuid: "guid"}}
+		tasks = []*TaskStartRequest{{}}
+	})
+
+	It("submits both requests and returns their results", func() {
+		fakeClient.RequestLRPAuctionsWithResultReturns(AuctionResult{Location: "lrp-location"}, nil)
+		fakeClient.RequestTaskAuctionsWithResultReturns(AuctionResult{Location: "task-location"}, nil)
+
+		result, err := RequestAuctionGroup(logger, context.Background(), fakeClient, lrpStarts, tasks)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.LRP.Location).To(Equal("lrp-location"))
+		Expect(result.Task.Location).To(Equal("task-location"))
+
+		_, _, sentLRPs := fakeClient.RequestLRPAuctionsWithResultArgsForCall(0)
+		Expect(sentLRPs).To(Equal(lrpStarts))
+		_, _, sentTasks := fakeClient.RequestTaskAuctionsWithResultArgsForCall(0)
+		Expect(sentTasks).To(Equal(tasks))
+	})
+
+	It("does not submit an empty slice", func() {
+		_, err := RequestAuctionGroup(logger, context.Background(), fakeClient, lrpStarts, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeClient.RequestLRPAuctionsWithResultCallCount()).To(Equal(1))
+		Expect(fakeClient.RequestTaskAuctionsWithResultCallCount()).To(Equal(0))
+	})
+
+	Context("when one request fails", func() {
+		var failure error
+
+		BeforeEach(func() {
+			failure = errors.New("boom")
+			fakeClient.RequestLRPAuctionsWithResultReturns(AuctionResult{}, failure)
+			fakeClient.RequestTaskAuctionsWithResultStub = func(_ lager.Logger, ctx context.Context, _ []*TaskStartRequest) (AuctionResult, error) {
+				<-ctx.Done()
+				return AuctionResult{}, ctx.Err()
+			}
+		})
+
+		It("cancels the other and identifies the operation that failed first", func() {
+			_, err := RequestAuctionGroup(logger, context.Background(), fakeClient, lrpStarts, tasks)
+			Expect(err).To(MatchError(ContainSubstring("lrp auctions failed: boom")))
+			Expect(errors.Is(err, failure)).To(BeTrue())
+
+			var groupErr *AuctionGroupError
+			Expect(errors.As(err, &groupErr)).To(BeTrue())
+			Expect(groupErr.Operation).To(Equal(OperationLRP))
+			Expect(groupErr.OtherErr).To(Equal(context.Canceled))
+		})
+	})
+
+	Context("when the parent context is canceled", func() {
+		It("cancels the requests in flight", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			fakeClient.RequestLRPAuctionsWithResultStub = func(_ lager.Logger, ctx context.Context, _ []*LRPStartRequest) (AuctionResult, error) {
+				return AuctionResult{}, ctx.Err()
+			}
+
+			_, err := RequestAuctionGroup(logger, ctx, fakeClient, lrpStarts, nil)
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("RequestAuctionBatches", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeClient *auctioneerfakes.FakeExtendedClient
+		batches    []AuctionBatch
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeClient = &auctioneerfakes.FakeExtendedClient{}
+		fakeClient.RequestLRPAuctionsWithResultStub = func(_ lager.Logger, _ context.Context, lrpStarts []*LRPStartRequest) (AuctionResult, error) {
+			return AuctionResult{Location: lrpStarts[0].ProcessGuid}, nil
+		}
+		batches = []AuctionBatch{
+			{LRPs: []*LRPStartRequest{{ProcessGuid: "guid-0"}}},
+			{LRPs: []*LRPStartRequest{{ProcessGuid: "guid-1"}}, Tasks: []*TaskStartRequest{{}}},
+			{LRPs: []*LRPStartRequest{{ProcessGuid: "guid-2"}}},
+		}
+	})
+
+	It("submits every batch and returns their results in order", func() {
+		results, err := RequestAuctionBatches(logger, context.Background(), fakeClient, 0, batches)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(3))
+		for i, result := range results {
+			Expect(result.LRP.Location).To(Equal(batches[i].LRPs[0].ProcessGuid))
+		}
+		Expect(fakeClient.RequestLRPAuctionsWithResultCallCount()).To(Equal(3))
+		Expect(fakeClient.RequestTaskAuctionsWithResultCallCount()).To(Equal(1))
+	})
+
+	It("runs at most concurrency batches at a time", func() {
+		var (
+			lock           sync.Mutex
+			inFlight, peak int
+		)
+		fakeClient.RequestLRPAuctionsWithResultStub = func(_ lager.Logger, _ context.Context, _ []*LRPStartRequest) (AuctionResult, error) {
+			lock.Lock()
+			inFlight++
+			if inFlight > peak {
+				peak = inFlight
+			}
+			lock.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			lock.Lock()
+			inFlight--
+			lock.Unlock()
+			return AuctionResult{}, nil
+		}
+
+		_, err := RequestAuctionBatches(logger, context.Background(), fakeClient, 2, append(batches, batches...))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(peak).To(Equal(2))
+	})
+
+	Context("when a batch fails", func() {
+		var failure error
+
+		BeforeEach(func() {
+			failure = errors.New("boom")
+			fakeClient.RequestLRPAuctionsWithResultStub = func(_ lager.Logger, ctx context.Context, lrpStarts []*LRPStartRequest) (AuctionResult, error) {
+				if lrpStarts[0].ProcessGuid == "guid-0" {
+					return AuctionResult{}, failure
+				}
+				<-ctx.Done()
+				return AuctionResult{}, ctx.Err()
+			}
+		})
+
+		It("cancels the other batches and returns the failed batch's error", func() {
+			_, err := RequestAuctionBatches(logger, context.Background(), fakeClient, 0, batches)
+
+			var batchErr *AuctionBatchError
+			Expect(errors.As(err, &batchErr)).To(BeTrue())
+			Expect(batchErr.Index).To(Equal(0))
+			Expect(errors.Is(err, failure)).To(BeTrue())
+		})
+
+		It("does not start the batches that were waiting", func() {
+			_, err := RequestAuctionBatches(logger, context.Background(), fakeClient, 1, batches)
+			Expect(errors.Is(err, failure)).To(BeTrue())
+			Expect(fakeClient.RequestLRPAuctionsWithResultCallCount()).To(Equal(1))
+		})
+	})
+
+	It("stops when the context is canceled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := RequestAuctionBatches(logger, ctx, fakeClient, 1, batches)
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		Expect(fakeClient.RequestLRPAuctionsWithResultCallCount()).To(BeZero())
+	})
+})
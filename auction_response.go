@@ -0,0 +1,82 @@
+package auctioneer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// RequestLRPAuctionsResponse submits an LRP auction batch like
+// RequestLRPAuctionsWithResult, but returns the auctioneer's final response
+// itself, for callers that need headers or a status the typed result and
+// errors do not expose. The response is returned along with the error when
+// the auctioneer rejects the batch, and is nil when none was received. Its
+// body has already been read into memory, so the connection is free, but
+// the caller must still close it.
+//
+// A client configured with WithSharding makes one request per shard, so it
+// returns a nil response.
+func (c *auctioneerClient) RequestLRPAuctionsResponse(logger lager.Logger, ctx context.Context, lrpStarts []*LRPStartRequest) (*http.Response, error) {
+	ctx = requestContext(ctx)
+	logger = c.requestLogger(logger, ctx).Session("request-lrp-auctions")
+	lrpStarts = c.transformLRPs(lrpStarts)
+	if len(c.shardBackends) > 0 {
+		_, err := c.requestShardedLRPAuctions(logger, ctx, lrpStarts)
+		return nil, err
+	}
+
+	response := &capturedResponse{}
+	_, err := c.requestAuctions(logger, ctx, auctionRequest{
+		operation: OperationLRP,
+		route:     CreateLRPAuctionsRoute,
+		auctions:  lrpStarts,
+		count:     len(lrpStarts),
+		response:  response,
+	})
+	return response.resp, err
+}
+
+// RequestTaskAuctionsResponse submits a task auction batch like
+// RequestTaskAuctionsWithResult, returning the auctioneer's final response
+// as RequestLRPAuctionsResponse does.
+func (c *auctioneerClient) RequestTaskAuctionsResponse(logger lager.Logger, ctx context.Context, tasks []*TaskStartRequest) (*http.Response, error) {
+	ctx = requestContext(ctx)
+	logger = c.requestLogger(logger, ctx).Session("request-task-auctions")
+	tasks = c.transformTasks(tasks)
+
+	response := &capturedResponse{}
+	_, err := c.requestAuctions(logger, ctx, auctionRequest{
+		operation: OperationTask,
+		route:     CreateTaskAuctionsRoute,
+		auctions:  tasks,
+		count:     len(tasks),
+		response:  response,
+	})
+	return response.resp, err
+}
+
+// capturedResponse holds the last response to a batch, with its body
+// buffered so that it can be read both by the client and by its caller.
+type capturedResponse struct {
+	resp *http.Response
+}
+
+// capture buffers the body of resp, keeping a copy of resp for the caller,
+// and returns resp with a body over the buffer.
+func (r *capturedResponse) capture(resp *http.Response) (*http.Response, error) {
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+
+	kept := *resp
+	kept.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.resp = &kept
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
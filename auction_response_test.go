@@ -0,0 +1,85 @@
+package auctioneer_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("RequestLRPAuctionsResponse", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		client     ExtendedClient
+		lrpStarts  []*LRPStartRequest
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		client = NewClient(fakeServer.URL())
+		lrpStarts = []*LRPStartRequest{{ProcessGuid: "some-guid", Domain: "some-domain", Indices: []int{0}}}
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("returns the response to an accepted batch with its body readable", func() {
+		fakeServer.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("POST", "/v1/lrps"),
+			ghttp.VerifyJSONRepresenting(lrpStarts),
+			ghttp.RespondWith(http.StatusAccepted, `{"accepted":1}`, http.Header{"X-Auctioneer-Zone": []string{"z1"}}),
+		))
+
+		resp, err := client.RequestLRPAuctionsResponse(logger, context.Background(), lrpStarts)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+
+		Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+		Expect(resp.Header.Get("X-Auctioneer-Zone")).To(Equal("z1"))
+		Expect(ioutil.ReadAll(resp.Body)).To(MatchJSON(`{"accepted":1}`))
+	})
+
+	It("returns the response along with the error for a rejected batch", func() {
+		fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusBadRequest, "invalid domain"))
+
+		resp, err := client.RequestLRPAuctionsResponse(logger, context.Background(), lrpStarts)
+		var statusErr *StatusError
+		Expect(errors.As(err, &statusErr)).To(BeTrue())
+		Expect(statusErr.StatusCode).To(Equal(http.StatusBadRequest))
+
+		Expect(resp).NotTo(BeNil())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(ioutil.ReadAll(resp.Body)).To(BeEquivalentTo("invalid domain"))
+	})
+
+	It("returns a nil response when none was received", func() {
+		fakeServer.Close()
+
+		resp, err := client.RequestLRPAuctionsResponse(logger, context.Background(), lrpStarts)
+		Expect(err).To(HaveOccurred())
+		Expect(resp).To(BeNil())
+	})
+
+	It("returns the response to a task batch", func() {
+		fakeServer.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("POST", "/v1/tasks"),
+			ghttp.RespondWith(http.StatusAccepted, ""),
+		))
+
+		resp, err := client.RequestTaskAuctionsResponse(logger, context.Background(), []*TaskStartRequest{{}})
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+	})
+})
@@ -0,0 +1,99 @@
+package auctioneer
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// AuctionResult describes an auction batch that the auctioneer accepted for
+// asynchronous processing.
+type AuctionResult struct {
+	// Location is the absolute URL of the status resource for the submitted
+	// batch, taken from the Location header of the response. It is empty
+	// when the auctioneer does not provide one.
+	Location string
+
+	// ShardLocations is set instead of Location for a batch split by
+	// WithSharding: the status location of each shard, by backend.
+	ShardLocations map[string]string
+
+	// Retries is the number of times the batch was sent again after a
+	// failed attempt; see WithRetries. UsedFallback reports whether any
+	// attempt fell back to plain HTTP. For a sharded batch they cover all
+	// the shards.
+	Retries      int
+	UsedFallback bool
+
+	// RemoteAddr is the address, such as "10.0.16.4:9016", of the
+	// connection the batch was accepted on, identifying the instance that
+	// served it behind a load balancer. It is empty for a sharded batch;
+	// the request summary logged for each shard carries it instead.
+	RemoteAddr string
+
+	// ConnReused reports whether that connection was reused from the
+	// client's pool of idle connections rather than newly dialed. A drop in
+	// reuse across batches points at connections being closed between
+	// them, adding a dial, and a TLS handshake, to each.
+	ConnReused bool
+
+	// Accepted is the number of entries the auctioneer accepted and
+	// Rejected the entries it rejected, by their index in the batch, when it
+	// reports them in the body of its response. Auctioneers that answer
+	// with an empty body, or one without a result, are assumed to have
+	// accepted the whole batch: Accepted is then the number of entries
+	// submitted, or 0 for a batch submitted with RequestLRPAuctionsRaw. For
+	// a sharded batch they cover all the shards, indexed in the whole batch.
+	Accepted int
+	Rejected []DryRunRejection
+}
+
+// auctionResultBody is the result reported by auctioneers that return one.
+type auctionResultBody struct {
+	Accepted *int              `json:"accepted"`
+	Rejected []DryRunRejection `json:"rejected"`
+}
+
+func newAuctionResult(logger lager.Logger, resp *http.Response, submitted int) AuctionResult {
+	result := AuctionResult{
+		Location: resolveLocation(resp),
+		Accepted: submitted,
+	}
+
+	if resp.ContentLength == 0 {
+		return result
+	}
+
+	body := auctionResultBody{}
+	err := json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		if err != io.EOF {
+			// the batch was accepted regardless
+			logger.Debug("ignoring-invalid-result-body", lager.Data{"error": err.Error()})
+		}
+		return result
+	}
+
+	if body.Accepted != nil {
+		result.Accepted = *body.Accepted
+		result.Rejected = body.Rejected
+	}
+	return result
+}
+
+func resolveLocation(resp *http.Response) string {
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return ""
+	}
+
+	locationURL, err := url.Parse(location)
+	if err != nil || resp.Request == nil || resp.Request.URL == nil {
+		return location
+	}
+
+	return resp.Request.URL.ResolveReference(locationURL).String()
+}
@@ -1,6 +1,9 @@
 package auctioneer_test
 
 import (
+	"io/ioutil"
+	"os"
+
 	"code.cloudfoundry.org/consuladapter/consulrunner"
 
 	. "github.com/onsi/ginkgo"
@@ -10,7 +13,15 @@ import (
 	"testing"
 )
 
-var consulRunner *consulrunner.ClusterRunner
+var (
+	consulRunner *consulrunner.ClusterRunner
+
+	// blueCerts and greenCerts are unrelated sets of TLS material generated
+	// for the suite, so that a certificate from one does not match a key
+	// from the other.
+	blueCerts, greenCerts tlsFixtures
+	suiteCertDirs         []string
+)
 
 func TestAuctioneer(t *testing.T) {
 	RegisterFailHandler(Fail)
@@ -28,12 +39,26 @@ var _ = BeforeSuite(func() {
 
 	consulRunner.Start()
 	consulRunner.WaitUntilReady()
+
+	blueCerts = newTLSFixtures(suiteCertDir("blue-certs"), nil)
+	greenCerts = newTLSFixtures(suiteCertDir("green-certs"), nil)
 })
 
 var _ = AfterSuite(func() {
 	consulRunner.Stop()
+
+	for _, dir := range suiteCertDirs {
+		os.RemoveAll(dir)
+	}
 })
 
+func suiteCertDir(name string) string {
+	dir, err := ioutil.TempDir("", name)
+	Expect(err).NotTo(HaveOccurred())
+	suiteCertDirs = append(suiteCertDirs, dir)
+	return dir
+}
+
 var _ = BeforeEach(func() {
 	consulRunner.Reset()
 })
@@ -0,0 +1,966 @@
+// This file was generated by counterfeiter
+package auctioneerfakes
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager"
+)
+
+type FakeExtendedClient struct {
+	RequestLRPAuctionsStub        func(logger lager.Logger, lrpStart []*auctioneer.LRPStartRequest) error
+	requestLRPAuctionsMutex       sync.RWMutex
+	requestLRPAuctionsArgsForCall []struct {
+		logger   lager.Logger
+		lrpStart []*auctioneer.LRPStartRequest
+	}
+	requestLRPAuctionsReturns struct {
+		result1 error
+	}
+	RequestTaskAuctionsStub        func(logger lager.Logger, tasks []*auctioneer.TaskStartRequest) error
+	requestTaskAuctionsMutex       sync.RWMutex
+	requestTaskAuctionsArgsForCall []struct {
+		logger lager.Logger
+		tasks  []*auctioneer.TaskStartRequest
+	}
+	requestTaskAuctionsReturns struct {
+		result1 error
+	}
+	RequestLRPAuctionsWithResultStub        func(logger lager.Logger, ctx context.Context, lrpStart []*auctioneer.LRPStartRequest) (auctioneer.AuctionResult, error)
+	requestLRPAuctionsWithResultMutex       sync.RWMutex
+	requestLRPAuctionsWithResultArgsForCall []struct {
+		logger   lager.Logger
+		ctx      context.Context
+		lrpStart []*auctioneer.LRPStartRequest
+	}
+	requestLRPAuctionsWithResultReturns struct {
+		result1 auctioneer.AuctionResult
+		result2 error
+	}
+	RequestTaskAuctionsWithResultStub        func(logger lager.Logger, ctx context.Context, tasks []*auctioneer.TaskStartRequest) (auctioneer.AuctionResult, error)
+	requestTaskAuctionsWithResultMutex       sync.RWMutex
+	requestTaskAuctionsWithResultArgsForCall []struct {
+		logger lager.Logger
+		ctx    context.Context
+		tasks  []*auctioneer.TaskStartRequest
+	}
+	requestTaskAuctionsWithResultReturns struct {
+		result1 auctioneer.AuctionResult
+		result2 error
+	}
+	RequestLRPAuctionsResponseStub        func(logger lager.Logger, ctx context.Context, lrpStart []*auctioneer.LRPStartRequest) (*http.Response, error)
+	requestLRPAuctionsResponseMutex       sync.RWMutex
+	requestLRPAuctionsResponseArgsForCall []struct {
+		logger   lager.Logger
+		ctx      context.Context
+		lrpStart []*auctioneer.LRPStartRequest
+	}
+	requestLRPAuctionsResponseReturns struct {
+		result1 *http.Response
+		result2 error
+	}
+	RequestTaskAuctionsResponseStub        func(logger lager.Logger, ctx context.Context, tasks []*auctioneer.TaskStartRequest) (*http.Response, error)
+	requestTaskAuctionsResponseMutex       sync.RWMutex
+	requestTaskAuctionsResponseArgsForCall []struct {
+		logger lager.Logger
+		ctx    context.Context
+		tasks  []*auctioneer.TaskStartRequest
+	}
+	requestTaskAuctionsResponseReturns struct {
+		result1 *http.Response
+		result2 error
+	}
+	RequestLRPAuctionsRawStub        func(logger lager.Logger, ctx context.Context, body io.Reader) error
+	requestLRPAuctionsRawMutex       sync.RWMutex
+	requestLRPAuctionsRawArgsForCall []struct {
+		logger lager.Logger
+		ctx    context.Context
+		body   io.Reader
+	}
+	requestLRPAuctionsRawReturns struct {
+		result1 error
+	}
+	WaitForBatchStub        func(logger lager.Logger, ctx context.Context, location string) (auctioneer.BatchStatus, error)
+	waitForBatchMutex       sync.RWMutex
+	waitForBatchArgsForCall []struct {
+		logger   lager.Logger
+		ctx      context.Context
+		location string
+	}
+	waitForBatchReturns struct {
+		result1 auctioneer.BatchStatus
+		result2 error
+	}
+	DryRunLRPAuctionsStub        func(logger lager.Logger, ctx context.Context, lrpStart []*auctioneer.LRPStartRequest) (auctioneer.DryRunResult, error)
+	dryRunLRPAuctionsMutex       sync.RWMutex
+	dryRunLRPAuctionsArgsForCall []struct {
+		logger   lager.Logger
+		ctx      context.Context
+		lrpStart []*auctioneer.LRPStartRequest
+	}
+	dryRunLRPAuctionsReturns struct {
+		result1 auctioneer.DryRunResult
+		result2 error
+	}
+	DryRunTaskAuctionsStub        func(logger lager.Logger, ctx context.Context, tasks []*auctioneer.TaskStartRequest) (auctioneer.DryRunResult, error)
+	dryRunTaskAuctionsMutex       sync.RWMutex
+	dryRunTaskAuctionsArgsForCall []struct {
+		logger lager.Logger
+		ctx    context.Context
+		tasks  []*auctioneer.TaskStartRequest
+	}
+	dryRunTaskAuctionsReturns struct {
+		result1 auctioneer.DryRunResult
+		result2 error
+	}
+	PingStub        func(logger lager.Logger, ctx context.Context) (auctioneer.PingResult, error)
+	pingMutex       sync.RWMutex
+	pingArgsForCall []struct {
+		logger lager.Logger
+		ctx    context.Context
+	}
+	pingReturns struct {
+		result1 auctioneer.PingResult
+		result2 error
+	}
+	WarmupStub        func(ctx context.Context) error
+	warmupMutex       sync.RWMutex
+	warmupArgsForCall []struct {
+		ctx context.Context
+	}
+	warmupReturns struct {
+		result1 error
+	}
+	CapabilitiesStub        func(logger lager.Logger, ctx context.Context) (auctioneer.Capabilities, error)
+	capabilitiesMutex       sync.RWMutex
+	capabilitiesArgsForCall []struct {
+		logger lager.Logger
+		ctx    context.Context
+	}
+	capabilitiesReturns struct {
+		result1 auctioneer.Capabilities
+		result2 error
+	}
+	StatsStub        func() auctioneer.ClientStats
+	statsMutex       sync.RWMutex
+	statsArgsForCall []struct{}
+	statsReturns     struct {
+		result1 auctioneer.ClientStats
+	}
+	StatsAndResetStub        func() auctioneer.ClientStats
+	statsAndResetMutex       sync.RWMutex
+	statsAndResetArgsForCall []struct{}
+	statsAndResetReturns     struct {
+		result1 auctioneer.ClientStats
+	}
+	ServerVersionStub        func() string
+	serverVersionMutex       sync.RWMutex
+	serverVersionArgsForCall []struct{}
+	serverVersionReturns     struct {
+		result1 string
+	}
+	SetURLStub        func(auctioneerURL string)
+	setURLMutex       sync.RWMutex
+	setURLArgsForCall []struct {
+		auctioneerURL string
+	}
+	ReloadTLSStub        func() error
+	reloadTLSMutex       sync.RWMutex
+	reloadTLSArgsForCall []struct{}
+	reloadTLSReturns     struct {
+		result1 error
+	}
+	InjectMetadataStub        func(ctx context.Context, carrier auctioneer.MetadataCarrier)
+	injectMetadataMutex       sync.RWMutex
+	injectMetadataArgsForCall []struct {
+		ctx     context.Context
+		carrier auctioneer.MetadataCarrier
+	}
+	ShutdownStub        func(ctx context.Context) error
+	shutdownMutex       sync.RWMutex
+	shutdownArgsForCall []struct {
+		ctx context.Context
+	}
+	shutdownReturns struct {
+		result1 error
+	}
+	CloseStub        func() error
+	closeMutex       sync.RWMutex
+	closeArgsForCall []struct{}
+	closeReturns     struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeExtendedClient) RequestLRPAuctions(logger lager.Logger, lrpStart []*auctioneer.LRPStartRequest) error {
+	var lrpStartCopy []*auctioneer.LRPStartRequest
+	if lrpStart != nil {
+		lrpStartCopy = make([]*auctioneer.LRPStartRequest, len(lrpStart))
+		copy(lrpStartCopy, lrpStart)
+	}
+	fake.requestLRPAuctionsMutex.Lock()
+	fake.requestLRPAuctionsArgsForCall = append(fake.requestLRPAuctionsArgsForCall, struct {
+		logger   lager.Logger
+		lrpStart []*auctioneer.LRPStartRequest
+	}{logger, lrpStartCopy})
+	fake.recordInvocation("RequestLRPAuctions", []interface{}{logger, lrpStartCopy})
+	fake.requestLRPAuctionsMutex.Unlock()
+	if fake.RequestLRPAuctionsStub != nil {
+		return fake.RequestLRPAuctionsStub(logger, lrpStart)
+	} else {
+		return fake.requestLRPAuctionsReturns.result1
+	}
+}
+
+func (fake *FakeExtendedClient) RequestLRPAuctionsCallCount() int {
+	fake.requestLRPAuctionsMutex.RLock()
+	defer fake.requestLRPAuctionsMutex.RUnlock()
+	return len(fake.requestLRPAuctionsArgsForCall)
+}
+
+func (fake *FakeExtendedClient) RequestLRPAuctionsArgsForCall(i int) (lager.Logger, []*auctioneer.LRPStartRequest) {
+	fake.requestLRPAuctionsMutex.RLock()
+	defer fake.requestLRPAuctionsMutex.RUnlock()
+	return fake.requestLRPAuctionsArgsForCall[i].logger, fake.requestLRPAuctionsArgsForCall[i].lrpStart
+}
+
+func (fake *FakeExtendedClient) RequestLRPAuctionsReturns(result1 error) {
+	fake.RequestLRPAuctionsStub = nil
+	fake.requestLRPAuctionsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeExtendedClient) RequestTaskAuctions(logger lager.Logger, tasks []*auctioneer.TaskStartRequest) error {
+	var tasksCopy []*auctioneer.TaskStartRequest
+	if tasks != nil {
+		tasksCopy = make([]*auctioneer.TaskStartRequest, len(tasks))
+		copy(tasksCopy, tasks)
+	}
+	fake.requestTaskAuctionsMutex.Lock()
+	fake.requestTaskAuctionsArgsForCall = append(fake.requestTaskAuctionsArgsForCall, struct {
+		logger lager.Logger
+		tasks  []*auctioneer.TaskStartRequest
+	}{logger, tasksCopy})
+	fake.recordInvocation("RequestTaskAuctions", []interface{}{logger, tasksCopy})
+	fake.requestTaskAuctionsMutex.Unlock()
+	if fake.RequestTaskAuctionsStub != nil {
+		return fake.RequestTaskAuctionsStub(logger, tasks)
+	} else {
+		return fake.requestTaskAuctionsReturns.result1
+	}
+}
+
+func (fake *FakeExtendedClient) RequestTaskAuctionsCallCount() int {
+	fake.requestTaskAuctionsMutex.RLock()
+	defer fake.requestTaskAuctionsMutex.RUnlock()
+	return len(fake.requestTaskAuctionsArgsForCall)
+}
+
+func (fake *FakeExtendedClient) RequestTaskAuctionsArgsForCall(i int) (lager.Logger, []*auctioneer.TaskStartRequest) {
+	fake.requestTaskAuctionsMutex.RLock()
+	defer fake.requestTaskAuctionsMutex.RUnlock()
+	return fake.requestTaskAuctionsArgsForCall[i].logger, fake.requestTaskAuctionsArgsForCall[i].tasks
+}
+
+func (fake *FakeExtendedClient) RequestTaskAuctionsReturns(result1 error) {
+	fake.RequestTaskAuctionsStub = nil
+	fake.requestTaskAuctionsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeExtendedClient) RequestLRPAuctionsWithResult(logger lager.Logger, ctx context.Context, lrpStart []*auctioneer.LRPStartRequest) (auctioneer.AuctionResult, error) {
+	var lrpStartCopy []*auctioneer.LRPStartRequest
+	if lrpStart != nil {
+		lrpStartCopy = make([]*auctioneer.LRPStartRequest, len(lrpStart))
+		copy(lrpStartCopy, lrpStart)
+	}
+	fake.requestLRPAuctionsWithResultMutex.Lock()
+	fake.requestLRPAuctionsWithResultArgsForCall = append(fake.requestLRPAuctionsWithResultArgsForCall, struct {
+		logger   lager.Logger
+		ctx      context.Context
+		lrpStart []*auctioneer.LRPStartRequest
+	}{logger, ctx, lrpStartCopy})
+	fake.recordInvocation("RequestLRPAuctionsWithResult", []interface{}{logger, ctx, lrpStartCopy})
+	fake.requestLRPAuctionsWithResultMutex.Unlock()
+	if fake.RequestLRPAuctionsWithResultStub != nil {
+		return fake.RequestLRPAuctionsWithResultStub(logger, ctx, lrpStart)
+	} else {
+		return fake.requestLRPAuctionsWithResultReturns.result1, fake.requestLRPAuctionsWithResultReturns.result2
+	}
+}
+
+func (fake *FakeExtendedClient) RequestLRPAuctionsWithResultCallCount() int {
+	fake.requestLRPAuctionsWithResultMutex.RLock()
+	defer fake.requestLRPAuctionsWithResultMutex.RUnlock()
+	return len(fake.requestLRPAuctionsWithResultArgsForCall)
+}
+
+func (fake *FakeExtendedClient) RequestLRPAuctionsWithResultArgsForCall(i int) (lager.Logger, context.Context, []*auctioneer.LRPStartRequest) {
+	fake.requestLRPAuctionsWithResultMutex.RLock()
+	defer fake.requestLRPAuctionsWithResultMutex.RUnlock()
+	return fake.requestLRPAuctionsWithResultArgsForCall[i].logger, fake.requestLRPAuctionsWithResultArgsForCall[i].ctx, fake.requestLRPAuctionsWithResultArgsForCall[i].lrpStart
+}
+
+func (fake *FakeExtendedClient) RequestLRPAuctionsWithResultReturns(result1 auctioneer.AuctionResult, result2 error) {
+	fake.RequestLRPAuctionsWithResultStub = nil
+	fake.requestLRPAuctionsWithResultReturns = struct {
+		result1 auctioneer.AuctionResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeExtendedClient) RequestTaskAuctionsWithResult(logger lager.Logger, ctx context.Context, tasks []*auctioneer.TaskStartRequest) (auctioneer.AuctionResult, error) {
+	var tasksCopy []*auctioneer.TaskStartRequest
+	if tasks != nil {
+		tasksCopy = make([]*auctioneer.TaskStartRequest, len(tasks))
+		copy(tasksCopy, tasks)
+	}
+	fake.requestTaskAuctionsWithResultMutex.Lock()
+	fake.requestTaskAuctionsWithResultArgsForCall = append(fake.requestTaskAuctionsWithResultArgsForCall, struct {
+		logger lager.Logger
+		ctx    context.Context
+		tasks  []*auctioneer.TaskStartRequest
+	}{logger, ctx, tasksCopy})
+	fake.recordInvocation("RequestTaskAuctionsWithResult", []interface{}{logger, ctx, tasksCopy})
+	fake.requestTaskAuctionsWithResultMutex.Unlock()
+	if fake.RequestTaskAuctionsWithResultStub != nil {
+		return fake.RequestTaskAuctionsWithResultStub(logger, ctx, tasks)
+	} else {
+		return fake.requestTaskAuctionsWithResultReturns.result1, fake.requestTaskAuctionsWithResultReturns.result2
+	}
+}
+
+func (fake *FakeExtendedClient) RequestTaskAuctionsWithResultCallCount() int {
+	fake.requestTaskAuctionsWithResultMutex.RLock()
+	defer fake.requestTaskAuctionsWithResultMutex.RUnlock()
+	return len(fake.requestTaskAuctionsWithResultArgsForCall)
+}
+
+func (fake *FakeExtendedClient) RequestTaskAuctionsWithResultArgsForCall(i int) (lager.Logger, context.Context, []*auctioneer.TaskStartRequest) {
+	fake.requestTaskAuctionsWithResultMutex.RLock()
+	defer fake.requestTaskAuctionsWithResultMutex.RUnlock()
+	return fake.requestTaskAuctionsWithResultArgsForCall[i].logger, fake.requestTaskAuctionsWithResultArgsForCall[i].ctx, fake.requestTaskAuctionsWithResultArgsForCall[i].tasks
+}
+
+func (fake *FakeExtendedClient) RequestTaskAuctionsWithResultReturns(result1 auctioneer.AuctionResult, result2 error) {
+	fake.RequestTaskAuctionsWithResultStub = nil
+	fake.requestTaskAuctionsWithResultReturns = struct {
+		result1 auctioneer.AuctionResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeExtendedClient) RequestLRPAuctionsResponse(logger lager.Logger, ctx context.Context, lrpStart []*auctioneer.LRPStartRequest) (*http.Response, error) {
+	var lrpStartCopy []*auctioneer.LRPStartRequest
+	if lrpStart != nil {
+		lrpStartCopy = make([]*auctioneer.LRPStartRequest, len(lrpStart))
+		copy(lrpStartCopy, lrpStart)
+	}
+	fake.requestLRPAuctionsResponseMutex.Lock()
+	fake.requestLRPAuctionsResponseArgsForCall = append(fake.requestLRPAuctionsResponseArgsForCall, struct {
+		logger   lager.Logger
+		ctx      context.Context
+		lrpStart []*auctioneer.LRPStartRequest
+	}{logger, ctx, lrpStartCopy})
+	fake.recordInvocation("RequestLRPAuctionsResponse", []interface{}{logger, ctx, lrpStartCopy})
+	fake.requestLRPAuctionsResponseMutex.Unlock()
+	if fake.RequestLRPAuctionsResponseStub != nil {
+		return fake.RequestLRPAuctionsResponseStub(logger, ctx, lrpStart)
+	} else {
+		return fake.requestLRPAuctionsResponseReturns.result1, fake.requestLRPAuctionsResponseReturns.result2
+	}
+}
+
+func (fake *FakeExtendedClient) RequestLRPAuctionsResponseCallCount() int {
+	fake.requestLRPAuctionsResponseMutex.RLock()
+	defer fake.requestLRPAuctionsResponseMutex.RUnlock()
+	return len(fake.requestLRPAuctionsResponseArgsForCall)
+}
+
+func (fake *FakeExtendedClient) RequestLRPAuctionsResponseArgsForCall(i int) (lager.Logger, context.Context, []*auctioneer.LRPStartRequest) {
+	fake.requestLRPAuctionsResponseMutex.RLock()
+	defer fake.requestLRPAuctionsResponseMutex.RUnlock()
+	return fake.requestLRPAuctionsResponseArgsForCall[i].logger, fake.requestLRPAuctionsResponseArgsForCall[i].ctx, fake.requestLRPAuctionsResponseArgsForCall[i].lrpStart
+}
+
+func (fake *FakeExtendedClient) RequestLRPAuctionsResponseReturns(result1 *http.Response, result2 error) {
+	fake.RequestLRPAuctionsResponseStub = nil
+	fake.requestLRPAuctionsResponseReturns = struct {
+		result1 *http.Response
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeExtendedClient) RequestTaskAuctionsResponse(logger lager.Logger, ctx context.Context, tasks []*auctioneer.TaskStartRequest) (*http.Response, error) {
+	var tasksCopy []*auctioneer.TaskStartRequest
+	if tasks != nil {
+		tasksCopy = make([]*auctioneer.TaskStartRequest, len(tasks))
+		copy(tasksCopy, tasks)
+	}
+	fake.requestTaskAuctionsResponseMutex.Lock()
+	fake.requestTaskAuctionsResponseArgsForCall = append(fake.requestTaskAuctionsResponseArgsForCall, struct {
+		logger lager.Logger
+		ctx    context.Context
+		tasks  []*auctioneer.TaskStartRequest
+	}{logger, ctx, tasksCopy})
+	fake.recordInvocation("RequestTaskAuctionsResponse", []interface{}{logger, ctx, tasksCopy})
+	fake.requestTaskAuctionsResponseMutex.Unlock()
+	if fake.RequestTaskAuctionsResponseStub != nil {
+		return fake.RequestTaskAuctionsResponseStub(logger, ctx, tasks)
+	} else {
+		return fake.requestTaskAuctionsResponseReturns.result1, fake.requestTaskAuctionsResponseReturns.result2
+	}
+}
+
+func (fake *FakeExtendedClient) RequestTaskAuctionsResponseCallCount() int {
+	fake.requestTaskAuctionsResponseMutex.RLock()
+	defer fake.requestTaskAuctionsResponseMutex.RUnlock()
+	return len(fake.requestTaskAuctionsResponseArgsForCall)
+}
+
+func (fake *FakeExtendedClient) RequestTaskAuctionsResponseArgsForCall(i int) (lager.Logger, context.Context, []*auctioneer.TaskStartRequest) {
+	fake.requestTaskAuctionsResponseMutex.RLock()
+	defer fake.requestTaskAuctionsResponseMutex.RUnlock()
+	return fake.requestTaskAuctionsResponseArgsForCall[i].logger, fake.requestTaskAuctionsResponseArgsForCall[i].ctx, fake.requestTaskAuctionsResponseArgsForCall[i].tasks
+}
+
+func (fake *FakeExtendedClient) RequestTaskAuctionsResponseReturns(result1 *http.Response, result2 error) {
+	fake.RequestTaskAuctionsResponseStub = nil
+	fake.requestTaskAuctionsResponseReturns = struct {
+		result1 *http.Response
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeExtendedClient) RequestLRPAuctionsRaw(logger lager.Logger, ctx context.Context, body io.Reader) error {
+	fake.requestLRPAuctionsRawMutex.Lock()
+	fake.requestLRPAuctionsRawArgsForCall = append(fake.requestLRPAuctionsRawArgsForCall, struct {
+		logger lager.Logger
+		ctx    context.Context
+		body   io.Reader
+	}{logger, ctx, body})
+	fake.recordInvocation("RequestLRPAuctionsRaw", []interface{}{logger, ctx, body})
+	fake.requestLRPAuctionsRawMutex.Unlock()
+	if fake.RequestLRPAuctionsRawStub != nil {
+		return fake.RequestLRPAuctionsRawStub(logger, ctx, body)
+	} else {
+		return fake.requestLRPAuctionsRawReturns.result1
+	}
+}
+
+func (fake *FakeExtendedClient) RequestLRPAuctionsRawCallCount() int {
+	fake.requestLRPAuctionsRawMutex.RLock()
+	defer fake.requestLRPAuctionsRawMutex.RUnlock()
+	return len(fake.requestLRPAuctionsRawArgsForCall)
+}
+
+func (fake *FakeExtendedClient) RequestLRPAuctionsRawArgsForCall(i int) (lager.Logger, context.Context, io.Reader) {
+	fake.requestLRPAuctionsRawMutex.RLock()
+	defer fake.requestLRPAuctionsRawMutex.RUnlock()
+	return fake.requestLRPAuctionsRawArgsForCall[i].logger, fake.requestLRPAuctionsRawArgsForCall[i].ctx, fake.requestLRPAuctionsRawArgsForCall[i].body
+}
+
+func (fake *FakeExtendedClient) RequestLRPAuctionsRawReturns(result1 error) {
+	fake.RequestLRPAuctionsRawStub = nil
+	fake.requestLRPAuctionsRawReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeExtendedClient) WaitForBatch(logger lager.Logger, ctx context.Context, location string) (auctioneer.BatchStatus, error) {
+	fake.waitForBatchMutex.Lock()
+	fake.waitForBatchArgsForCall = append(fake.waitForBatchArgsForCall, struct {
+		logger   lager.Logger
+		ctx      context.Context
+		location string
+	}{logger, ctx, location})
+	fake.recordInvocation("WaitForBatch", []interface{}{logger, ctx, location})
+	fake.waitForBatchMutex.Unlock()
+	if fake.WaitForBatchStub != nil {
+		return fake.WaitForBatchStub(logger, ctx, location)
+	} else {
+		return fake.waitForBatchReturns.result1, fake.waitForBatchReturns.result2
+	}
+}
+
+func (fake *FakeExtendedClient) WaitForBatchCallCount() int {
+	fake.waitForBatchMutex.RLock()
+	defer fake.waitForBatchMutex.RUnlock()
+	return len(fake.waitForBatchArgsForCall)
+}
+
+func (fake *FakeExtendedClient) WaitForBatchArgsForCall(i int) (lager.Logger, context.Context, string) {
+	fake.waitForBatchMutex.RLock()
+	defer fake.waitForBatchMutex.RUnlock()
+	return fake.waitForBatchArgsForCall[i].logger, fake.waitForBatchArgsForCall[i].ctx, fake.waitForBatchArgsForCall[i].location
+}
+
+func (fake *FakeExtendedClient) WaitForBatchReturns(result1 auctioneer.BatchStatus, result2 error) {
+	fake.WaitForBatchStub = nil
+	fake.waitForBatchReturns = struct {
+		result1 auctioneer.BatchStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeExtendedClient) DryRunLRPAuctions(logger lager.Logger, ctx context.Context, lrpStart []*auctioneer.LRPStartRequest) (auctioneer.DryRunResult, error) {
+	var lrpStartCopy []*auctioneer.LRPStartRequest
+	if lrpStart != nil {
+		lrpStartCopy = make([]*auctioneer.LRPStartRequest, len(lrpStart))
+		copy(lrpStartCopy, lrpStart)
+	}
+	fake.dryRunLRPAuctionsMutex.Lock()
+	fake.dryRunLRPAuctionsArgsForCall = append(fake.dryRunLRPAuctionsArgsForCall, struct {
+		logger   lager.Logger
+		ctx      context.Context
+		lrpStart []*auctioneer.LRPStartRequest
+	}{logger, ctx, lrpStartCopy})
+	fake.recordInvocation("DryRunLRPAuctions", []interface{}{logger, ctx, lrpStartCopy})
+	fake.dryRunLRPAuctionsMutex.Unlock()
+	if fake.DryRunLRPAuctionsStub != nil {
+		return fake.DryRunLRPAuctionsStub(logger, ctx, lrpStart)
+	} else {
+		return fake.dryRunLRPAuctionsReturns.result1, fake.dryRunLRPAuctionsReturns.result2
+	}
+}
+
+func (fake *FakeExtendedClient) DryRunLRPAuctionsCallCount() int {
+	fake.dryRunLRPAuctionsMutex.RLock()
+	defer fake.dryRunLRPAuctionsMutex.RUnlock()
+	return len(fake.dryRunLRPAuctionsArgsForCall)
+}
+
+func (fake *FakeExtendedClient) DryRunLRPAuctionsArgsForCall(i int) (lager.Logger, context.Context, []*auctioneer.LRPStartRequest) {
+	fake.dryRunLRPAuctionsMutex.RLock()
+	defer fake.dryRunLRPAuctionsMutex.RUnlock()
+	return fake.dryRunLRPAuctionsArgsForCall[i].logger, fake.dryRunLRPAuctionsArgsForCall[i].ctx, fake.dryRunLRPAuctionsArgsForCall[i].lrpStart
+}
+
+func (fake *FakeExtendedClient) DryRunLRPAuctionsReturns(result1 auctioneer.DryRunResult, result2 error) {
+	fake.DryRunLRPAuctionsStub = nil
+	fake.dryRunLRPAuctionsReturns = struct {
+		result1 auctioneer.DryRunResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeExtendedClient) DryRunTaskAuctions(logger lager.Logger, ctx context.Context, tasks []*auctioneer.TaskStartRequest) (auctioneer.DryRunResult, error) {
+	var tasksCopy []*auctioneer.TaskStartRequest
+	if tasks != nil {
+		tasksCopy = make([]*auctioneer.TaskStartRequest, len(tasks))
+		copy(tasksCopy, tasks)
+	}
+	fake.dryRunTaskAuctionsMutex.Lock()
+	fake.dryRunTaskAuctionsArgsForCall = append(fake.dryRunTaskAuctionsArgsForCall, struct {
+		logger lager.Logger
+		ctx    context.Context
+		tasks  []*auctioneer.TaskStartRequest
+	}{logger, ctx, tasksCopy})
+	fake.recordInvocation("DryRunTaskAuctions", []interface{}{logger, ctx, tasksCopy})
+	fake.dryRunTaskAuctionsMutex.Unlock()
+	if fake.DryRunTaskAuctionsStub != nil {
+		return fake.DryRunTaskAuctionsStub(logger, ctx, tasks)
+	} else {
+		return fake.dryRunTaskAuctionsReturns.result1, fake.dryRunTaskAuctionsReturns.result2
+	}
+}
+
+func (fake *FakeExtendedClient) DryRunTaskAuctionsCallCount() int {
+	fake.dryRunTaskAuctionsMutex.RLock()
+	defer fake.dryRunTaskAuctionsMutex.RUnlock()
+	return len(fake.dryRunTaskAuctionsArgsForCall)
+}
+
+func (fake *FakeExtendedClient) DryRunTaskAuctionsArgsForCall(i int) (lager.Logger, context.Context, []*auctioneer.TaskStartRequest) {
+	fake.dryRunTaskAuctionsMutex.RLock()
+	defer fake.dryRunTaskAuctionsMutex.RUnlock()
+	return fake.dryRunTaskAuctionsArgsForCall[i].logger, fake.dryRunTaskAuctionsArgsForCall[i].ctx, fake.dryRunTaskAuctionsArgsForCall[i].tasks
+}
+
+func (fake *FakeExtendedClient) DryRunTaskAuctionsReturns(result1 auctioneer.DryRunResult, result2 error) {
+	fake.DryRunTaskAuctionsStub = nil
+	fake.dryRunTaskAuctionsReturns = struct {
+		result1 auctioneer.DryRunResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeExtendedClient) Ping(logger lager.Logger, ctx context.Context) (auctioneer.PingResult, error) {
+	fake.pingMutex.Lock()
+	fake.pingArgsForCall = append(fake.pingArgsForCall, struct {
+		logger lager.Logger
+		ctx    context.Context
+	}{logger, ctx})
+	fake.recordInvocation("Ping", []interface{}{logger, ctx})
+	fake.pingMutex.Unlock()
+	if fake.PingStub != nil {
+		return fake.PingStub(logger, ctx)
+	} else {
+		return fake.pingReturns.result1, fake.pingReturns.result2
+	}
+}
+
+func (fake *FakeExtendedClient) PingCallCount() int {
+	fake.pingMutex.RLock()
+	defer fake.pingMutex.RUnlock()
+	return len(fake.pingArgsForCall)
+}
+
+func (fake *FakeExtendedClient) PingArgsForCall(i int) (lager.Logger, context.Context) {
+	fake.pingMutex.RLock()
+	defer fake.pingMutex.RUnlock()
+	return fake.pingArgsForCall[i].logger, fake.pingArgsForCall[i].ctx
+}
+
+func (fake *FakeExtendedClient) PingReturns(result1 auctioneer.PingResult, result2 error) {
+	fake.PingStub = nil
+	fake.pingReturns = struct {
+		result1 auctioneer.PingResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeExtendedClient) Warmup(ctx context.Context) error {
+	fake.warmupMutex.Lock()
+	fake.warmupArgsForCall = append(fake.warmupArgsForCall, struct {
+		ctx context.Context
+	}{ctx})
+	fake.recordInvocation("Warmup", []interface{}{ctx})
+	fake.warmupMutex.Unlock()
+	if fake.WarmupStub != nil {
+		return fake.WarmupStub(ctx)
+	} else {
+		return fake.warmupReturns.result1
+	}
+}
+
+func (fake *FakeExtendedClient) WarmupCallCount() int {
+	fake.warmupMutex.RLock()
+	defer fake.warmupMutex.RUnlock()
+	return len(fake.warmupArgsForCall)
+}
+
+func (fake *FakeExtendedClient) WarmupArgsForCall(i int) context.Context {
+	fake.warmupMutex.RLock()
+	defer fake.warmupMutex.RUnlock()
+	return fake.warmupArgsForCall[i].ctx
+}
+
+func (fake *FakeExtendedClient) WarmupReturns(result1 error) {
+	fake.WarmupStub = nil
+	fake.warmupReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeExtendedClient) Capabilities(logger lager.Logger, ctx context.Context) (auctioneer.Capabilities, error) {
+	fake.capabilitiesMutex.Lock()
+	fake.capabilitiesArgsForCall = append(fake.capabilitiesArgsForCall, struct {
+		logger lager.Logger
+		ctx    context.Context
+	}{logger, ctx})
+	fake.recordInvocation("Capabilities", []interface{}{logger, ctx})
+	fake.capabilitiesMutex.Unlock()
+	if fake.CapabilitiesStub != nil {
+		return fake.CapabilitiesStub(logger, ctx)
+	} else {
+		return fake.capabilitiesReturns.result1, fake.capabilitiesReturns.result2
+	}
+}
+
+func (fake *FakeExtendedClient) CapabilitiesCallCount() int {
+	fake.capabilitiesMutex.RLock()
+	defer fake.capabilitiesMutex.RUnlock()
+	return len(fake.capabilitiesArgsForCall)
+}
+
+func (fake *FakeExtendedClient) CapabilitiesArgsForCall(i int) (lager.Logger, context.Context) {
+	fake.capabilitiesMutex.RLock()
+	defer fake.capabilitiesMutex.RUnlock()
+	return fake.capabilitiesArgsForCall[i].logger, fake.capabilitiesArgsForCall[i].ctx
+}
+
+func (fake *FakeExtendedClient) CapabilitiesReturns(result1 auctioneer.Capabilities, result2 error) {
+	fake.CapabilitiesStub = nil
+	fake.capabilitiesReturns = struct {
+		result1 auctioneer.Capabilities
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeExtendedClient) Stats() auctioneer.ClientStats {
+	fake.statsMutex.Lock()
+	fake.statsArgsForCall = append(fake.statsArgsForCall, struct{}{})
+	fake.recordInvocation("Stats", []interface{}{})
+	fake.statsMutex.Unlock()
+	if fake.StatsStub != nil {
+		return fake.StatsStub()
+	} else {
+		return fake.statsReturns.result1
+	}
+}
+
+func (fake *FakeExtendedClient) StatsCallCount() int {
+	fake.statsMutex.RLock()
+	defer fake.statsMutex.RUnlock()
+	return len(fake.statsArgsForCall)
+}
+
+func (fake *FakeExtendedClient) StatsReturns(result1 auctioneer.ClientStats) {
+	fake.StatsStub = nil
+	fake.statsReturns = struct {
+		result1 auctioneer.ClientStats
+	}{result1}
+}
+
+func (fake *FakeExtendedClient) StatsAndReset() auctioneer.ClientStats {
+	fake.statsAndResetMutex.Lock()
+	fake.statsAndResetArgsForCall = append(fake.statsAndResetArgsForCall, struct{}{})
+	fake.recordInvocation("StatsAndReset", []interface{}{})
+	fake.statsAndResetMutex.Unlock()
+	if fake.StatsAndResetStub != nil {
+		return fake.StatsAndResetStub()
+	} else {
+		return fake.statsAndResetReturns.result1
+	}
+}
+
+func (fake *FakeExtendedClient) StatsAndResetCallCount() int {
+	fake.statsAndResetMutex.RLock()
+	defer fake.statsAndResetMutex.RUnlock()
+	return len(fake.statsAndResetArgsForCall)
+}
+
+func (fake *FakeExtendedClient) StatsAndResetReturns(result1 auctioneer.ClientStats) {
+	fake.StatsAndResetStub = nil
+	fake.statsAndResetReturns = struct {
+		result1 auctioneer.ClientStats
+	}{result1}
+}
+
+func (fake *FakeExtendedClient) ServerVersion() string {
+	fake.serverVersionMutex.Lock()
+	fake.serverVersionArgsForCall = append(fake.serverVersionArgsForCall, struct{}{})
+	fake.recordInvocation("ServerVersion", []interface{}{})
+	fake.serverVersionMutex.Unlock()
+	if fake.ServerVersionStub != nil {
+		return fake.ServerVersionStub()
+	} else {
+		return fake.serverVersionReturns.result1
+	}
+}
+
+func (fake *FakeExtendedClient) ServerVersionCallCount() int {
+	fake.serverVersionMutex.RLock()
+	defer fake.serverVersionMutex.RUnlock()
+	return len(fake.serverVersionArgsForCall)
+}
+
+func (fake *FakeExtendedClient) ServerVersionReturns(result1 string) {
+	fake.ServerVersionStub = nil
+	fake.serverVersionReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeExtendedClient) SetURL(auctioneerURL string) {
+	fake.setURLMutex.Lock()
+	fake.setURLArgsForCall = append(fake.setURLArgsForCall, struct {
+		auctioneerURL string
+	}{auctioneerURL})
+	fake.recordInvocation("SetURL", []interface{}{auctioneerURL})
+	fake.setURLMutex.Unlock()
+	if fake.SetURLStub != nil {
+		fake.SetURLStub(auctioneerURL)
+	}
+}
+
+func (fake *FakeExtendedClient) SetURLCallCount() int {
+	fake.setURLMutex.RLock()
+	defer fake.setURLMutex.RUnlock()
+	return len(fake.setURLArgsForCall)
+}
+
+func (fake *FakeExtendedClient) SetURLArgsForCall(i int) string {
+	fake.setURLMutex.RLock()
+	defer fake.setURLMutex.RUnlock()
+	return fake.setURLArgsForCall[i].auctioneerURL
+}
+
+func (fake *FakeExtendedClient) ReloadTLS() error {
+	fake.reloadTLSMutex.Lock()
+	fake.reloadTLSArgsForCall = append(fake.reloadTLSArgsForCall, struct{}{})
+	fake.recordInvocation("ReloadTLS", []interface{}{})
+	fake.reloadTLSMutex.Unlock()
+	if fake.ReloadTLSStub != nil {
+		return fake.ReloadTLSStub()
+	} else {
+		return fake.reloadTLSReturns.result1
+	}
+}
+
+func (fake *FakeExtendedClient) ReloadTLSCallCount() int {
+	fake.reloadTLSMutex.RLock()
+	defer fake.reloadTLSMutex.RUnlock()
+	return len(fake.reloadTLSArgsForCall)
+}
+
+func (fake *FakeExtendedClient) ReloadTLSReturns(result1 error) {
+	fake.ReloadTLSStub = nil
+	fake.reloadTLSReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeExtendedClient) InjectMetadata(ctx context.Context, carrier auctioneer.MetadataCarrier) {
+	fake.injectMetadataMutex.Lock()
+	fake.injectMetadataArgsForCall = append(fake.injectMetadataArgsForCall, struct {
+		ctx     context.Context
+		carrier auctioneer.MetadataCarrier
+	}{ctx, carrier})
+	fake.recordInvocation("InjectMetadata", []interface{}{ctx, carrier})
+	fake.injectMetadataMutex.Unlock()
+	if fake.InjectMetadataStub != nil {
+		fake.InjectMetadataStub(ctx, carrier)
+	}
+}
+
+func (fake *FakeExtendedClient) InjectMetadataCallCount() int {
+	fake.injectMetadataMutex.RLock()
+	defer fake.injectMetadataMutex.RUnlock()
+	return len(fake.injectMetadataArgsForCall)
+}
+
+func (fake *FakeExtendedClient) InjectMetadataArgsForCall(i int) (context.Context, auctioneer.MetadataCarrier) {
+	fake.injectMetadataMutex.RLock()
+	defer fake.injectMetadataMutex.RUnlock()
+	return fake.injectMetadataArgsForCall[i].ctx, fake.injectMetadataArgsForCall[i].carrier
+}
+
+func (fake *FakeExtendedClient) Shutdown(ctx context.Context) error {
+	fake.shutdownMutex.Lock()
+	fake.shutdownArgsForCall = append(fake.shutdownArgsForCall, struct {
+		ctx context.Context
+	}{ctx})
+	fake.recordInvocation("Shutdown", []interface{}{ctx})
+	fake.shutdownMutex.Unlock()
+	if fake.ShutdownStub != nil {
+		return fake.ShutdownStub(ctx)
+	} else {
+		return fake.shutdownReturns.result1
+	}
+}
+
+func (fake *FakeExtendedClient) ShutdownCallCount() int {
+	fake.shutdownMutex.RLock()
+	defer fake.shutdownMutex.RUnlock()
+	return len(fake.shutdownArgsForCall)
+}
+
+func (fake *FakeExtendedClient) ShutdownArgsForCall(i int) context.Context {
+	fake.shutdownMutex.RLock()
+	defer fake.shutdownMutex.RUnlock()
+	return fake.shutdownArgsForCall[i].ctx
+}
+
+func (fake *FakeExtendedClient) ShutdownReturns(result1 error) {
+	fake.ShutdownStub = nil
+	fake.shutdownReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeExtendedClient) Close() error {
+	fake.closeMutex.Lock()
+	fake.closeArgsForCall = append(fake.closeArgsForCall, struct{}{})
+	fake.recordInvocation("Close", []interface{}{})
+	fake.closeMutex.Unlock()
+	if fake.CloseStub != nil {
+		return fake.CloseStub()
+	} else {
+		return fake.closeReturns.result1
+	}
+}
+
+func (fake *FakeExtendedClient) CloseCallCount() int {
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	return len(fake.closeArgsForCall)
+}
+
+func (fake *FakeExtendedClient) CloseReturns(result1 error) {
+	fake.CloseStub = nil
+	fake.closeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeExtendedClient) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.requestLRPAuctionsMutex.RLock()
+	defer fake.requestLRPAuctionsMutex.RUnlock()
+	fake.requestTaskAuctionsMutex.RLock()
+	defer fake.requestTaskAuctionsMutex.RUnlock()
+	fake.requestLRPAuctionsWithResultMutex.RLock()
+	defer fake.requestLRPAuctionsWithResultMutex.RUnlock()
+	fake.requestTaskAuctionsWithResultMutex.RLock()
+	defer fake.requestTaskAuctionsWithResultMutex.RUnlock()
+	fake.requestLRPAuctionsResponseMutex.RLock()
+	defer fake.requestLRPAuctionsResponseMutex.RUnlock()
+	fake.requestTaskAuctionsResponseMutex.RLock()
+	defer fake.requestTaskAuctionsResponseMutex.RUnlock()
+	fake.requestLRPAuctionsRawMutex.RLock()
+	defer fake.requestLRPAuctionsRawMutex.RUnlock()
+	fake.waitForBatchMutex.RLock()
+	defer fake.waitForBatchMutex.RUnlock()
+	fake.dryRunLRPAuctionsMutex.RLock()
+	defer fake.dryRunLRPAuctionsMutex.RUnlock()
+	fake.dryRunTaskAuctionsMutex.RLock()
+	defer fake.dryRunTaskAuctionsMutex.RUnlock()
+	fake.pingMutex.RLock()
+	defer fake.pingMutex.RUnlock()
+	fake.warmupMutex.RLock()
+	defer fake.warmupMutex.RUnlock()
+	fake.capabilitiesMutex.RLock()
+	defer fake.capabilitiesMutex.RUnlock()
+	fake.statsMutex.RLock()
+	defer fake.statsMutex.RUnlock()
+	fake.statsAndResetMutex.RLock()
+	defer fake.statsAndResetMutex.RUnlock()
+	fake.serverVersionMutex.RLock()
+	defer fake.serverVersionMutex.RUnlock()
+	fake.setURLMutex.RLock()
+	defer fake.setURLMutex.RUnlock()
+	fake.reloadTLSMutex.RLock()
+	defer fake.reloadTLSMutex.RUnlock()
+	fake.injectMetadataMutex.RLock()
+	defer fake.injectMetadataMutex.RUnlock()
+	fake.shutdownMutex.RLock()
+	defer fake.shutdownMutex.RUnlock()
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeExtendedClient) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ auctioneer.ExtendedClient = new(FakeExtendedClient)
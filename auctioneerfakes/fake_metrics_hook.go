@@ -0,0 +1,138 @@
+// This file was generated by counterfeiter
+package auctioneerfakes
+
+import (
+	"sync"
+
+	"time"
+
+	"code.cloudfoundry.org/auctioneer"
+)
+
+type FakeMetricsHook struct {
+	IncrementCounterStub        func(name string, labels map[string]string)
+	incrementCounterMutex       sync.RWMutex
+	incrementCounterArgsForCall []struct {
+		name   string
+		labels map[string]string
+	}
+	ObserveDurationStub        func(name string, duration time.Duration, labels map[string]string)
+	observeDurationMutex       sync.RWMutex
+	observeDurationArgsForCall []struct {
+		name     string
+		duration time.Duration
+		labels   map[string]string
+	}
+	ObserveValueStub        func(name string, value float64, labels map[string]string)
+	observeValueMutex       sync.RWMutex
+	observeValueArgsForCall []struct {
+		name   string
+		value  float64
+		labels map[string]string
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeMetricsHook) IncrementCounter(name string, labels map[string]string) {
+	fake.incrementCounterMutex.Lock()
+	fake.incrementCounterArgsForCall = append(fake.incrementCounterArgsForCall, struct {
+		name   string
+		labels map[string]string
+	}{name, labels})
+	fake.recordInvocation("IncrementCounter", []interface{}{name, labels})
+	fake.incrementCounterMutex.Unlock()
+	if fake.IncrementCounterStub != nil {
+		fake.IncrementCounterStub(name, labels)
+	}
+}
+
+func (fake *FakeMetricsHook) IncrementCounterCallCount() int {
+	fake.incrementCounterMutex.RLock()
+	defer fake.incrementCounterMutex.RUnlock()
+	return len(fake.incrementCounterArgsForCall)
+}
+
+func (fake *FakeMetricsHook) IncrementCounterArgsForCall(i int) (string, map[string]string) {
+	fake.incrementCounterMutex.RLock()
+	defer fake.incrementCounterMutex.RUnlock()
+	return fake.incrementCounterArgsForCall[i].name, fake.incrementCounterArgsForCall[i].labels
+}
+
+func (fake *FakeMetricsHook) ObserveDuration(name string, duration time.Duration, labels map[string]string) {
+	fake.observeDurationMutex.Lock()
+	fake.observeDurationArgsForCall = append(fake.observeDurationArgsForCall, struct {
+		name     string
+		duration time.Duration
+		labels   map[string]string
+	}{name, duration, labels})
+	fake.recordInvocation("ObserveDuration", []interface{}{name, duration, labels})
+	fake.observeDurationMutex.Unlock()
+	if fake.ObserveDurationStub != nil {
+		fake.ObserveDurationStub(name, duration, labels)
+	}
+}
+
+func (fake *FakeMetricsHook) ObserveDurationCallCount() int {
+	fake.observeDurationMutex.RLock()
+	defer fake.observeDurationMutex.RUnlock()
+	return len(fake.observeDurationArgsForCall)
+}
+
+func (fake *FakeMetricsHook) ObserveDurationArgsForCall(i int) (string, time.Duration, map[string]string) {
+	fake.observeDurationMutex.RLock()
+	defer fake.observeDurationMutex.RUnlock()
+	return fake.observeDurationArgsForCall[i].name, fake.observeDurationArgsForCall[i].duration, fake.observeDurationArgsForCall[i].labels
+}
+
+func (fake *FakeMetricsHook) ObserveValue(name string, value float64, labels map[string]string) {
+	fake.observeValueMutex.Lock()
+	fake.observeValueArgsForCall = append(fake.observeValueArgsForCall, struct {
+		name   string
+		value  float64
+		labels map[string]string
+	}{name, value, labels})
+	fake.recordInvocation("ObserveValue", []interface{}{name, value, labels})
+	fake.observeValueMutex.Unlock()
+	if fake.ObserveValueStub != nil {
+		fake.ObserveValueStub(name, value, labels)
+	}
+}
+
+func (fake *FakeMetricsHook) ObserveValueCallCount() int {
+	fake.observeValueMutex.RLock()
+	defer fake.observeValueMutex.RUnlock()
+	return len(fake.observeValueArgsForCall)
+}
+
+func (fake *FakeMetricsHook) ObserveValueArgsForCall(i int) (string, float64, map[string]string) {
+	fake.observeValueMutex.RLock()
+	defer fake.observeValueMutex.RUnlock()
+	return fake.observeValueArgsForCall[i].name, fake.observeValueArgsForCall[i].value, fake.observeValueArgsForCall[i].labels
+}
+
+func (fake *FakeMetricsHook) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.incrementCounterMutex.RLock()
+	defer fake.incrementCounterMutex.RUnlock()
+	fake.observeDurationMutex.RLock()
+	defer fake.observeDurationMutex.RUnlock()
+	fake.observeValueMutex.RLock()
+	defer fake.observeValueMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeMetricsHook) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ auctioneer.MetricsHook = new(FakeMetricsHook)
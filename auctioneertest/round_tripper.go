@@ -0,0 +1,102 @@
+package auctioneertest
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrUnexpectedRequest is returned by a RoundTripper for a request beyond
+// the responses it was given.
+var ErrUnexpectedRequest = errors.New("auctioneertest: no response for request")
+
+// Response is a canned response of a RoundTripper. A non-nil Err is
+// returned in place of a response, as a transport failure.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+	Err        error
+}
+
+// Request is a request a RoundTripper received, with its body read.
+type Request struct {
+	*http.Request
+	Body []byte
+}
+
+// RoundTripper is an http.RoundTripper that records the requests it is
+// given and answers them with its responses, in order. Pass it to a client
+// with auctioneer.WithRoundTripper to test code that uses the client
+// without a server. It is safe for concurrent use.
+type RoundTripper struct {
+	lock      sync.Mutex
+	responses []Response
+	requests  []Request
+}
+
+// NewRoundTripper returns a RoundTripper that answers with responses.
+func NewRoundTripper(responses ...Response) *RoundTripper {
+	return &RoundTripper{responses: responses}
+}
+
+// Respond adds responses for the requests after those already answered.
+func (rt *RoundTripper) Respond(responses ...Response) {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	rt.responses = append(rt.responses, responses...)
+}
+
+// Requests returns the requests received so far.
+func (rt *RoundTripper) Requests() []Request {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	return append([]Request(nil), rt.requests...)
+}
+
+func (rt *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rt.lock.Lock()
+	n := len(rt.requests)
+	rt.requests = append(rt.requests, Request{Request: req, Body: body})
+	if n >= len(rt.responses) {
+		rt.lock.Unlock()
+		return nil, ErrUnexpectedRequest
+	}
+	canned := rt.responses[n]
+	rt.lock.Unlock()
+
+	if canned.Err != nil {
+		return nil, canned.Err
+	}
+
+	header := canned.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", canned.StatusCode, http.StatusText(canned.StatusCode)),
+		StatusCode:    canned.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(canned.Body)),
+		ContentLength: int64(len(canned.Body)),
+		Request:       req,
+	}, nil
+}
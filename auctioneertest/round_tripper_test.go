@@ -0,0 +1,79 @@
+package auctioneertest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/auctioneer/auctioneertest"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RoundTripper", func() {
+	var (
+		logger       *lagertest.TestLogger
+		roundTripper *auctioneertest.RoundTripper
+		client       auctioneer.ExtendedClient
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		roundTripper = auctioneertest.NewRoundTripper()
+		client = auctioneer.NewClient("http://auctioneer.example.com", auctioneer.WithRoundTripper(roundTripper))
+	})
+
+	It("records the requests it receives", func() {
+		roundTripper.Respond(auctioneertest.Response{StatusCode: http.StatusAccepted})
+
+		lrpStart := auctioneer.LRPStartRequest{ProcessGuid: "some-guid", Domain: "some-domain", Indices: []int{0}}
+		Expect(client.RequestLRPAuctions(logger, []*auctioneer.LRPStartRequest{&lrpStart})).To(Succeed())
+
+		requests := roundTripper.Requests()
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Method).To(Equal("POST"))
+		Expect(requests[0].URL.String()).To(Equal("http://auctioneer.example.com/v1/lrps"))
+
+		sent := []auctioneer.LRPStartRequest{}
+		Expect(json.Unmarshal(requests[0].Body, &sent)).To(Succeed())
+		Expect(sent).To(HaveLen(1))
+		Expect(sent[0].ProcessGuid).To(Equal("some-guid"))
+	})
+
+	It("answers with its responses in order", func() {
+		roundTripper.Respond(
+			auctioneertest.Response{
+				StatusCode: http.StatusAccepted,
+				Header:     http.Header{"Location": []string{"/v1/batches/some-batch"}},
+			},
+			auctioneertest.Response{StatusCode: http.StatusServiceUnavailable},
+		)
+
+		result, err := client.RequestTaskAuctionsWithResult(logger, context.Background(), []*auctioneer.TaskStartRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Location).To(Equal("http://auctioneer.example.com/v1/batches/some-batch"))
+
+		var statusErr *auctioneer.StatusError
+		err = client.RequestTaskAuctions(logger, []*auctioneer.TaskStartRequest{})
+		Expect(errors.As(err, &statusErr)).To(BeTrue())
+		Expect(statusErr.StatusCode).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("fails requests with a response's error", func() {
+		failure := errors.New("connection refused")
+		roundTripper.Respond(auctioneertest.Response{Err: failure})
+
+		err := client.RequestLRPAuctions(logger, []*auctioneer.LRPStartRequest{})
+		Expect(errors.Is(err, failure)).To(BeTrue())
+	})
+
+	It("fails requests beyond its responses", func() {
+		err := client.RequestLRPAuctions(logger, []*auctioneer.LRPStartRequest{})
+		Expect(errors.Is(err, auctioneertest.ErrUnexpectedRequest)).To(BeTrue())
+		Expect(roundTripper.Requests()).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,136 @@
+package auctioneer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// WithBackendMaxConns caps the connections to each backend in limits, keyed
+// by its base URL as given to WithFailover or WithSharding, so that a large
+// fan-out cannot overwhelm a smaller auctioneer. A connection is counted
+// from when a request is sent until its response body is closed; a request
+// to a backend at its cap waits for one of them to finish, or for its
+// context to be done. Limits of 0 or less, backends not in limits and the
+// client's URL are limited only by the transport.
+func WithBackendMaxConns(limits map[string]int) ClientOption {
+	return func(c *auctioneerClient) {
+		if c.backendConns == nil {
+			c.backendConns = map[string]chan struct{}{}
+		}
+		for backend, limit := range limits {
+			u, err := url.Parse(backend)
+			if err != nil || u.Host == "" || limit <= 0 {
+				continue
+			}
+			c.backendConns[u.Host] = make(chan struct{}, limit)
+		}
+	}
+}
+
+// WithBackendTimeouts bounds each attempt to send a request to a backend in
+// timeouts, keyed by its base URL as given to WithFailover or WithSharding,
+// so that a nearby backend can be given up on quickly while a remote one is
+// allowed longer. The timeout covers sending the request and reading the
+// response, and applies within the request context's own deadline, which
+// still bounds the whole call. An attempt that runs out of time fails with
+// a *BackendTimeoutError, which IsRetryable reports as retryable, so that
+// WithRetries and WithFailover try again as they would after a network
+// timeout. Timeouts of 0 or less, backends not in timeouts and the client's
+// URL are bounded only by the request context.
+func WithBackendTimeouts(timeouts map[string]time.Duration) ClientOption {
+	return func(c *auctioneerClient) {
+		if c.backendTimeouts == nil {
+			c.backendTimeouts = map[string]time.Duration{}
+		}
+		for backend, timeout := range timeouts {
+			u, err := url.Parse(backend)
+			if err != nil || u.Host == "" || timeout <= 0 {
+				continue
+			}
+			c.backendTimeouts[u.Host] = timeout
+		}
+	}
+}
+
+// BackendTimeoutError is returned when an attempt to send a request to a
+// backend takes longer than WithBackendTimeouts allows it.
+type BackendTimeoutError struct {
+	// Backend is the host the request was sent to.
+	Backend string
+	Timeout time.Duration
+}
+
+func (e *BackendTimeoutError) Error() string {
+	return fmt.Sprintf("request to auctioneer backend %s timed out after %s", e.Backend, e.Timeout)
+}
+
+// withBackendTimeout returns req bounded by the timeout of its backend, if
+// it has one, and the function that cancels it, or nil if it does not.
+func (c *auctioneerClient) withBackendTimeout(req *http.Request) (*http.Request, context.CancelFunc) {
+	timeout, ok := c.backendTimeouts[req.URL.Host]
+	if !ok {
+		return req, nil
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	req = req.WithContext(ctx)
+	// tell the auctioneer about the shorter deadline
+	setRequestTimeoutHeader(req)
+	return req, cancel
+}
+
+// backendTimeoutError returns err as a *BackendTimeoutError when req, made
+// with a backend timeout, ran out of time before parent did.
+func (c *auctioneerClient) backendTimeoutError(parent context.Context, req *http.Request, err error) error {
+	if req.Context().Err() != context.DeadlineExceeded || parent.Err() != nil {
+		return err
+	}
+	return &BackendTimeoutError{Backend: req.URL.Host, Timeout: c.backendTimeouts[req.URL.Host]}
+}
+
+func cancelAndRelease(cancel context.CancelFunc, release func()) func() {
+	return func() {
+		cancel()
+		if release != nil {
+			release()
+		}
+	}
+}
+
+// acquireBackendConn waits for a connection to the backend req is for to be
+// free, if its connections are capped, and returns the function that frees
+// it again, or nil if they are not.
+func (c *auctioneerClient) acquireBackendConn(req *http.Request) (func(), error) {
+	conns, ok := c.backendConns[req.URL.Host]
+	if !ok {
+		return nil, nil
+	}
+
+	err := c.acquireSlot(req.Context(), req.URL.Host, conns)
+	if err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-conns })
+	}, nil
+}
+
+// releasingBody frees a backend connection when the response body it
+// belongs to is closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
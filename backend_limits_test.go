@@ -0,0 +1,189 @@
+package auctioneer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("WithBackendMaxConns", func() {
+	var (
+		logger  *lagertest.TestLogger
+		small   *ghttp.Server
+		large   *ghttp.Server
+		client  ExtendedClient
+		unblock chan struct{}
+	)
+
+	// blockingHandler accepts auctions once unblock is closed.
+	blockingHandler := func() http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			<-unblock
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		unblock = make(chan struct{})
+
+		small = ghttp.NewServer()
+		small.RouteToHandler("POST", "/v1/lrps", blockingHandler())
+		large = ghttp.NewServer()
+		large.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+
+		client = NewClient(large.URL(), WithBackendMaxConns(map[string]int{small.URL(): 1}))
+	})
+
+	AfterEach(func() {
+		small.Close()
+		large.Close()
+	})
+
+	It("holds requests to a backend at its cap until a connection is free", func() {
+		ctx := ContextWithTargetURL(context.Background(), small.URL())
+		errs := make(chan error, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+				errs <- err
+			}()
+		}
+
+		Eventually(small.ReceivedRequests).Should(HaveLen(1))
+		Consistently(small.ReceivedRequests, 100*time.Millisecond).Should(HaveLen(1))
+
+		close(unblock)
+		Eventually(errs).Should(Receive(BeNil()))
+		Eventually(errs).Should(Receive(BeNil()))
+		Expect(small.ReceivedRequests()).To(HaveLen(2))
+	})
+
+	It("does not limit other backends", func() {
+		ctx := ContextWithTargetURL(context.Background(), small.URL())
+		go client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+		Eventually(small.ReceivedRequests).Should(HaveLen(1))
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		close(unblock)
+	})
+
+	It("stops waiting when the context is done", func() {
+		ctx := ContextWithTargetURL(context.Background(), small.URL())
+		go client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+		Eventually(small.ReceivedRequests).Should(HaveLen(1))
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		Expect(small.ReceivedRequests()).To(HaveLen(1))
+		close(unblock)
+	})
+})
+
+var _ = Describe("WithBackendTimeouts", func() {
+	var (
+		logger  *lagertest.TestLogger
+		near    *ghttp.Server
+		far     *ghttp.Server
+		client  ExtendedClient
+		unblock chan struct{}
+	)
+
+	slowHandler := func(delay time.Duration) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(delay):
+			case <-unblock:
+			}
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		unblock = make(chan struct{})
+
+		near = ghttp.NewServer()
+		far = ghttp.NewServer()
+		client = NewClient(near.URL(), WithBackendTimeouts(map[string]time.Duration{
+			near.URL(): 50 * time.Millisecond,
+			far.URL():  time.Second,
+		}))
+	})
+
+	AfterEach(func() {
+		close(unblock)
+		near.Close()
+		far.Close()
+	})
+
+	It("gives up on a backend that takes longer than its timeout", func() {
+		near.RouteToHandler("POST", "/v1/lrps", slowHandler(time.Second))
+
+		_, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), []*LRPStartRequest{})
+
+		var timeoutErr *BackendTimeoutError
+		Expect(errors.As(err, &timeoutErr)).To(BeTrue())
+		Expect(timeoutErr.Timeout).To(Equal(50 * time.Millisecond))
+		Expect(timeoutErr.Backend).To(Equal(near.Addr()))
+		Expect(IsRetryable(err)).To(BeTrue())
+	})
+
+	It("waits longer for a backend with a longer timeout", func() {
+		far.RouteToHandler("POST", "/v1/lrps", slowHandler(200*time.Millisecond))
+
+		_, err := client.RequestLRPAuctionsWithResult(logger, ContextWithTargetURL(context.Background(), far.URL()), []*LRPStartRequest{})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("tells the backend about its timeout", func() {
+		near.RouteToHandler("POST", "/v1/lrps", ghttp.CombineHandlers(
+			func(w http.ResponseWriter, r *http.Request) {
+				timeout, err := strconv.Atoi(r.Header.Get(RequestTimeoutHeader))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(timeout).To(BeNumerically("<=", 50))
+			},
+			ghttp.RespondWith(http.StatusAccepted, "{}"),
+		))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("keeps the request context's own deadline error", func() {
+		near.RouteToHandler("POST", "/v1/lrps", slowHandler(time.Second))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+	})
+
+	It("fails over to the next backend after a timeout with WithRetryAfterSend", func() {
+		near.RouteToHandler("POST", "/v1/lrps", slowHandler(time.Second))
+		far.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+
+		client = NewClient("http://unused.example.com",
+			WithFailover([]string{near.URL(), far.URL()}),
+			WithRetryAfterSend(),
+			WithBackendTimeouts(map[string]time.Duration{near.URL(): 50 * time.Millisecond}),
+		)
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(far.ReceivedRequests()).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,188 @@
+package auctioneer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync/atomic"
+)
+
+// Backend is a backend of a client configured with WithFailover, as offered
+// to a BackendSelector.
+type Backend struct {
+	// URL is the backend's base URL as given to WithFailover.
+	URL string
+	// Healthy is false for a backend marked unhealthy after failing a batch,
+	// until it accepts one again or WithHealthProbe finds it reachable.
+	Healthy bool
+	// SuccessRate is the backend's recent rate of accepting batches, from 0
+	// to 1, weighing each batch less as more follow it. Backends without a
+	// recorded batch have a rate of 1.
+	SuccessRate float64
+}
+
+// BackendSelector chooses which backend a client configured with
+// WithFailover and WithBackendSelector sends a batch to. Select is called
+// with the backends not yet tried for the batch, in configured order, and
+// with the request context, so that it can, for example, prefer backends in
+// the caller's availability zone. It is called again, with the backend it
+// chose left out, each time the batch fails over. Implementations must be
+// safe for concurrent use.
+type BackendSelector interface {
+	Select(ctx context.Context, backends []Backend) (Backend, error)
+}
+
+// WithBackendSelector makes a client configured with WithFailover choose the
+// backend for each batch, and each one it fails over to, with selector,
+// instead of trying the healthy backends in configured order. An error from
+// selector fails the batch with it. WithFailFast still leaves unhealthy
+// backends out of those offered, and WithWeightedFailover has no effect.
+func WithBackendSelector(selector BackendSelector) ClientOption {
+	return func(c *auctioneerClient) {
+		c.backendSelector = selector
+	}
+}
+
+// NewOrderedSelector returns a BackendSelector that chooses the first healthy
+// backend, or the first backend when none is healthy: a client's order
+// without WithBackendSelector.
+func NewOrderedSelector() BackendSelector {
+	return orderedSelector{}
+}
+
+type orderedSelector struct{}
+
+func (orderedSelector) Select(_ context.Context, backends []Backend) (Backend, error) {
+	candidates, err := preferHealthy(backends)
+	if err != nil {
+		return Backend{}, err
+	}
+	return candidates[0], nil
+}
+
+// NewRoundRobinSelector returns a BackendSelector that spreads batches evenly
+// across the healthy backends, or across all of them when none is healthy,
+// by choosing each in turn.
+func NewRoundRobinSelector() BackendSelector {
+	return &roundRobinSelector{}
+}
+
+type roundRobinSelector struct {
+	next uint64
+}
+
+func (s *roundRobinSelector) Select(_ context.Context, backends []Backend) (Backend, error) {
+	candidates, err := preferHealthy(backends)
+	if err != nil {
+		return Backend{}, err
+	}
+	n := atomic.AddUint64(&s.next, 1) - 1
+	return candidates[n%uint64(len(candidates))], nil
+}
+
+// NewWeightedSelector returns a BackendSelector that chooses among the
+// healthy backends, or among all of them when none is healthy, at random,
+// with probability proportional to their recent success rate.
+func NewWeightedSelector() BackendSelector {
+	return weightedSelector{}
+}
+
+type weightedSelector struct{}
+
+func (weightedSelector) Select(_ context.Context, backends []Backend) (Backend, error) {
+	candidates, err := preferHealthy(backends)
+	if err != nil {
+		return Backend{}, err
+	}
+
+	total := 0.0
+	for _, backend := range candidates {
+		total += math.Max(backend.SuccessRate, minFailoverWeight)
+	}
+
+	r := rand.Float64() * total
+	for _, backend := range candidates {
+		r -= math.Max(backend.SuccessRate, minFailoverWeight)
+		if r < 0 {
+			return backend, nil
+		}
+	}
+	return candidates[len(candidates)-1], nil
+}
+
+// preferHealthy returns the healthy backends, or all of them when none is
+// healthy.
+func preferHealthy(backends []Backend) ([]Backend, error) {
+	if len(backends) == 0 {
+		return nil, ErrNoHealthyBackend
+	}
+
+	var healthy []Backend
+	for _, backend := range backends {
+		if backend.Healthy {
+			healthy = append(healthy, backend)
+		}
+	}
+	if len(healthy) == 0 {
+		return backends, nil
+	}
+	return healthy, nil
+}
+
+// backendSequence returns the function that yields, each time it is called,
+// the next backend to try for a batch, or "" once none is left.
+func (c *auctioneerClient) backendSequence(ctx context.Context) func() (string, error) {
+	if c.backendSelector == nil {
+		backends := c.failover.order(c.failoverFailFast, c.failoverWeighted)
+		return func() (string, error) {
+			if len(backends) == 0 {
+				return "", nil
+			}
+			backend := backends[0]
+			backends = backends[1:]
+			return backend, nil
+		}
+	}
+
+	tried := map[string]bool{}
+	return func() (string, error) {
+		candidates := c.failover.candidates(tried, c.failoverFailFast)
+		if len(candidates) == 0 {
+			return "", nil
+		}
+
+		backend, err := c.backendSelector.Select(ctx, candidates)
+		if err != nil {
+			return "", err
+		}
+		for _, candidate := range candidates {
+			if candidate.URL == backend.URL {
+				tried[backend.URL] = true
+				return backend.URL, nil
+			}
+		}
+		return "", fmt.Errorf("backend selector chose %q, which was not offered", backend.URL)
+	}
+}
+
+// candidates returns the backends not in tried, leaving out unhealthy ones
+// when failFast is set.
+func (f *failover) candidates(tried map[string]bool, failFast bool) []Backend {
+	f.health.lock.Lock()
+	defer f.health.lock.Unlock()
+
+	candidates := make([]Backend, 0, len(f.backends))
+	for _, backend := range f.backends {
+		healthy := !f.health.unhealthy[backend]
+		if tried[backend] || (failFast && !healthy) {
+			continue
+		}
+		candidates = append(candidates, Backend{
+			URL:         backend,
+			Healthy:     healthy,
+			SuccessRate: 1 - f.health.failureRate[backend],
+		})
+	}
+	return candidates
+}
@@ -0,0 +1,225 @@
+package auctioneer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+type selectorFunc func(ctx context.Context, backends []Backend) (Backend, error)
+
+func (f selectorFunc) Select(ctx context.Context, backends []Backend) (Backend, error) {
+	return f(ctx, backends)
+}
+
+type zoneKey struct{}
+
+var _ = Describe("WithBackendSelector", func() {
+	var (
+		logger  *lagertest.TestLogger
+		primary *ghttp.Server
+		standby *ghttp.Server
+		offered [][]Backend
+	)
+
+	newSelectorClient := func(selector BackendSelector, opts ...ClientOption) ExtendedClient {
+		opts = append([]ClientOption{
+			WithFailover([]string{primary.URL(), standby.URL()}),
+			WithBackendSelector(selector),
+		}, opts...)
+		return NewClient("http://unused.example.com", opts...)
+	}
+
+	// last chooses the last backend offered, recording what it was offered.
+	last := selectorFunc(func(ctx context.Context, backends []Backend) (Backend, error) {
+		offered = append(offered, backends)
+		return backends[len(backends)-1], nil
+	})
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		offered = nil
+		primary = ghttp.NewServer()
+		primary.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+		standby = ghttp.NewServer()
+		standby.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+	})
+
+	AfterEach(func() {
+		primary.Close()
+		standby.Close()
+	})
+
+	It("submits to the backend the selector chooses", func() {
+		client := newSelectorClient(last)
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(primary.ReceivedRequests()).To(BeEmpty())
+		Expect(standby.ReceivedRequests()).To(HaveLen(1))
+		Expect(offered).To(Equal([][]Backend{{
+			{URL: primary.URL(), Healthy: true, SuccessRate: 1},
+			{URL: standby.URL(), Healthy: true, SuccessRate: 1},
+		}}))
+	})
+
+	It("passes the request context to the selector", func() {
+		client := newSelectorClient(selectorFunc(func(ctx context.Context, backends []Backend) (Backend, error) {
+			if ctx.Value(zoneKey{}) == "z2" {
+				return backends[1], nil
+			}
+			return backends[0], nil
+		}))
+
+		ctx := context.WithValue(context.Background(), zoneKey{}, "z2")
+		_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(standby.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("offers the backends not yet tried when failing over", func() {
+		standby.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusServiceUnavailable, "{}"))
+		client := newSelectorClient(last)
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(primary.ReceivedRequests()).To(HaveLen(1))
+		Expect(offered).To(HaveLen(2))
+		Expect(offered[1]).To(Equal([]Backend{{URL: primary.URL(), Healthy: true, SuccessRate: 1}}))
+	})
+
+	It("reports the health and success rate of each backend", func() {
+		standby.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusServiceUnavailable, "{}"))
+		client := newSelectorClient(last)
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(offered[2][1].URL).To(Equal(standby.URL()))
+		Expect(offered[2][1].Healthy).To(BeFalse())
+		Expect(offered[2][1].SuccessRate).To(BeNumerically("~", 0.8))
+	})
+
+	It("fails the batch with the selector's error", func() {
+		selectorErr := errors.New("no backend in zone")
+		client := newSelectorClient(selectorFunc(func(context.Context, []Backend) (Backend, error) {
+			return Backend{}, selectorErr
+		}))
+
+		err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+		Expect(err).To(Equal(selectorErr))
+		Expect(primary.ReceivedRequests()).To(BeEmpty())
+		Expect(standby.ReceivedRequests()).To(BeEmpty())
+	})
+
+	It("fails when the selector chooses a backend that was not offered", func() {
+		client := newSelectorClient(selectorFunc(func(context.Context, []Backend) (Backend, error) {
+			return Backend{URL: "http://elsewhere.example.com"}, nil
+		}))
+
+		err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+		Expect(err).To(MatchError(ContainSubstring("not offered")))
+	})
+
+	Context("with WithFailFast", func() {
+		It("offers only the healthy backends", func() {
+			primary.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusServiceUnavailable, "{}"))
+			standby.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusServiceUnavailable, "{}"))
+			client := newSelectorClient(last, WithFailFast())
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+			err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+			Expect(err).To(Equal(ErrNoHealthyBackend))
+			Expect(offered).To(HaveLen(2))
+		})
+	})
+
+	Context("with NewRoundRobinSelector", func() {
+		It("takes turns between the backends", func() {
+			client := newSelectorClient(NewRoundRobinSelector())
+
+			for i := 0; i < 4; i++ {
+				Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			}
+			Expect(primary.ReceivedRequests()).To(HaveLen(2))
+			Expect(standby.ReceivedRequests()).To(HaveLen(2))
+		})
+	})
+})
+
+var _ = Describe("BackendSelectors", func() {
+	var (
+		a, b, c Backend
+		ctx     context.Context
+	)
+
+	BeforeEach(func() {
+		a = Backend{URL: "http://a.example.com", Healthy: true, SuccessRate: 1}
+		b = Backend{URL: "http://b.example.com", Healthy: true, SuccessRate: 1}
+		c = Backend{URL: "http://c.example.com", Healthy: true, SuccessRate: 1}
+		ctx = context.Background()
+	})
+
+	Describe("NewOrderedSelector", func() {
+		It("chooses the first healthy backend", func() {
+			a.Healthy = false
+			Expect(NewOrderedSelector().Select(ctx, []Backend{a, b, c})).To(Equal(b))
+		})
+
+		It("chooses the first backend when none is healthy", func() {
+			a.Healthy, b.Healthy = false, false
+			Expect(NewOrderedSelector().Select(ctx, []Backend{a, b})).To(Equal(a))
+		})
+
+		It("fails without backends", func() {
+			_, err := NewOrderedSelector().Select(ctx, nil)
+			Expect(err).To(Equal(ErrNoHealthyBackend))
+		})
+	})
+
+	Describe("NewRoundRobinSelector", func() {
+		It("chooses each healthy backend in turn", func() {
+			b.Healthy = false
+			selector := NewRoundRobinSelector()
+
+			var chosen []string
+			for i := 0; i < 4; i++ {
+				backend, err := selector.Select(ctx, []Backend{a, b, c})
+				Expect(err).NotTo(HaveOccurred())
+				chosen = append(chosen, backend.URL)
+			}
+			Expect(chosen).To(Equal([]string{a.URL, c.URL, a.URL, c.URL}))
+		})
+	})
+
+	Describe("NewWeightedSelector", func() {
+		It("favors backends with a higher success rate", func() {
+			b.SuccessRate = 0
+			selector := NewWeightedSelector()
+
+			chosenA := 0
+			for i := 0; i < 1000; i++ {
+				backend, err := selector.Select(ctx, []Backend{a, b})
+				Expect(err).NotTo(HaveOccurred())
+				if backend == a {
+					chosenA++
+				}
+			}
+			Expect(chosenA).To(BeNumerically(">", 850))
+			Expect(chosenA).To(BeNumerically("<", 1000))
+		})
+
+		It("chooses only healthy backends while there are any", func() {
+			a.Healthy = false
+			selector := NewWeightedSelector()
+
+			for i := 0; i < 100; i++ {
+				Expect(selector.Select(ctx, []Backend{a, b})).To(Equal(b))
+			}
+		})
+	})
+})
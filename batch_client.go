@@ -0,0 +1,201 @@
+package auctioneer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager"
+)
+
+var ErrBatchClientClosed = errors.New("batch client is closed")
+
+// BatchClient accumulates individual LRP and task auction requests and
+// submits them through a Client as batches, every flush interval or as soon
+// as maxBatchSize requests of a kind are pending, whichever comes first.
+// It is safe for concurrent use.
+type BatchClient struct {
+	logger       lager.Logger
+	client       ExtendedClient
+	maxBatchSize int
+
+	lock          sync.Mutex
+	lrps          []*LRPStartRequest
+	lrpCallbacks  []func(error)
+	tasks         []*TaskStartRequest
+	taskCallbacks []func(error)
+	closed        bool
+
+	// runCtx bounds the periodic flushes; Close cancels it when its own
+	// context is done before an in-flight flush finishes.
+	runCtx    context.Context
+	cancelRun context.CancelFunc
+	flushNow  chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+// NewBatchClient returns a BatchClient that submits through client. A
+// maxBatchSize of zero or less leaves batches bounded only by the flush
+// interval. Close must be called to stop the BatchClient.
+func NewBatchClient(logger lager.Logger, client ExtendedClient, clock clock.Clock, flushInterval time.Duration, maxBatchSize int) *BatchClient {
+	runCtx, cancelRun := context.WithCancel(context.Background())
+	b := &BatchClient{
+		logger:       logger.Session("batch-client"),
+		client:       client,
+		maxBatchSize: maxBatchSize,
+		runCtx:       runCtx,
+		cancelRun:    cancelRun,
+		flushNow:     make(chan struct{}, 1),
+		done:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+
+	go b.run(clock.NewTicker(flushInterval))
+
+	return b
+}
+
+// AddLRP queues lrpStart for the next batch. callback, which may be nil, is
+// called from the flushing goroutine with the outcome of the batch that
+// carried lrpStart.
+func (b *BatchClient) AddLRP(lrpStart *LRPStartRequest, callback func(error)) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		return ErrBatchClientClosed
+	}
+
+	b.lrps = append(b.lrps, lrpStart)
+	b.lrpCallbacks = append(b.lrpCallbacks, callback)
+	if b.maxBatchSize > 0 && len(b.lrps) >= b.maxBatchSize {
+		b.signalFlush()
+	}
+
+	return nil
+}
+
+// AddTask queues task for the next batch. callback, which may be nil, is
+// called from the flushing goroutine with the outcome of the batch that
+// carried task.
+func (b *BatchClient) AddTask(task *TaskStartRequest, callback func(error)) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		return ErrBatchClientClosed
+	}
+
+	b.tasks = append(b.tasks, task)
+	b.taskCallbacks = append(b.taskCallbacks, callback)
+	if b.maxBatchSize > 0 && len(b.tasks) >= b.maxBatchSize {
+		b.signalFlush()
+	}
+
+	return nil
+}
+
+// Flush submits everything pending now, under ctx, and returns the first
+// error any of its batches failed with. Every submitter's callback is still
+// called.
+func (b *BatchClient) Flush(ctx context.Context) error {
+	b.lock.Lock()
+	lrps, lrpCallbacks := b.lrps, b.lrpCallbacks
+	tasks, taskCallbacks := b.tasks, b.taskCallbacks
+	b.lrps, b.lrpCallbacks = nil, nil
+	b.tasks, b.taskCallbacks = nil, nil
+	b.lock.Unlock()
+
+	var firstErr error
+
+	for start := 0; start < len(lrps); {
+		end := b.batchEnd(start, len(lrps))
+		_, err := b.client.RequestLRPAuctionsWithResult(b.logger, ctx, lrps[start:end])
+		notifySubmitters(lrpCallbacks[start:end], err)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		start = end
+	}
+
+	for start := 0; start < len(tasks); {
+		end := b.batchEnd(start, len(tasks))
+		_, err := b.client.RequestTaskAuctionsWithResult(b.logger, ctx, tasks[start:end])
+		notifySubmitters(taskCallbacks[start:end], err)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		start = end
+	}
+
+	return firstErr
+}
+
+// Close stops the periodic flush and flushes what is pending, under ctx.
+// A periodic flush still in flight is waited for until ctx is done, and then
+// canceled. Requests added after Close are rejected with
+// ErrBatchClientClosed.
+func (b *BatchClient) Close(ctx context.Context) error {
+	b.lock.Lock()
+	if b.closed {
+		b.lock.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.lock.Unlock()
+
+	close(b.done)
+	select {
+	case <-b.stopped:
+	case <-ctx.Done():
+		b.cancelRun()
+		<-b.stopped
+	}
+	b.cancelRun()
+
+	return b.Flush(ctx)
+}
+
+func (b *BatchClient) run(ticker clock.Ticker) {
+	defer close(b.stopped)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C():
+		case <-b.flushNow:
+		}
+
+		err := b.Flush(b.runCtx)
+		if err != nil {
+			b.logger.Error("failed-to-flush", err)
+		}
+	}
+}
+
+func (b *BatchClient) signalFlush() {
+	select {
+	case b.flushNow <- struct{}{}:
+	default:
+	}
+}
+
+func (b *BatchClient) batchEnd(start, n int) int {
+	if b.maxBatchSize > 0 && n-start > b.maxBatchSize {
+		return start + b.maxBatchSize
+	}
+	return n
+}
+
+func notifySubmitters(callbacks []func(error), err error) {
+	for _, callback := range callbacks {
+		if callback != nil {
+			callback(err)
+		}
+	}
+}
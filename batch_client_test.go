@@ -0,0 +1,156 @@
+package auctioneer_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/auctioneer/auctioneerfakes"
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BatchClient", func() {
+	const flushInterval = time.Second
+
+	var (
+		logger      *lagertest.TestLogger
+		fakeClient  *auctioneerfakes.FakeExtendedClient
+		fakeClock   *fakeclock.FakeClock
+		batchClient *BatchClient
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeClient = &auctioneerfakes.FakeExtendedClient{}
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		batchClient = NewBatchClient(logger, fakeClient, fakeClock, flushInterval, 3)
+	})
+
+	AfterEach(func() {
+		batchClient.Close(context.Background())
+	})
+
+	It("submits the accumulated requests every flush interval", func() {
+		Expect(batchClient.AddLRP(&LRPStartRequest{ProcessGuid: "guid-1"}, nil)).To(Succeed())
+		Expect(batchClient.AddLRP(&LRPStartRequest{ProcessGuid: "guid-2"}, nil)).To(Succeed())
+		Expect(batchClient.AddTask(&TaskStartRequest{}, nil)).To(Succeed())
+		Expect(fakeClient.RequestLRPAuctionsWithResultCallCount()).To(Equal(0))
+
+		fakeClock.WaitForWatcherAndIncrement(flushInterval)
+
+		Eventually(fakeClient.RequestLRPAuctionsWithResultCallCount).Should(Equal(1))
+		_, _, lrpStarts := fakeClient.RequestLRPAuctionsWithResultArgsForCall(0)
+		Expect(lrpStarts).To(HaveLen(2))
+
+		Eventually(fakeClient.RequestTaskAuctionsWithResultCallCount).Should(Equal(1))
+		_, _, tasks := fakeClient.RequestTaskAuctionsWithResultArgsForCall(0)
+		Expect(tasks).To(HaveLen(1))
+	})
+
+	It("submits as soon as the max batch size is reached", func() {
+		for i := 0; i < 3; i++ {
+			Expect(batchClient.AddLRP(&LRPStartRequest{}, nil)).To(Succeed())
+		}
+
+		Eventually(fakeClient.RequestLRPAuctionsWithResultCallCount).Should(Equal(1))
+		_, _, lrpStarts := fakeClient.RequestLRPAuctionsWithResultArgsForCall(0)
+		Expect(lrpStarts).To(HaveLen(3))
+	})
+
+	It("never submits more than the max batch size at once", func() {
+		for i := 0; i < 7; i++ {
+			Expect(batchClient.AddTask(&TaskStartRequest{}, nil)).To(Succeed())
+		}
+		Expect(batchClient.Close(context.Background())).To(Succeed())
+
+		total := 0
+		for i := 0; i < fakeClient.RequestTaskAuctionsWithResultCallCount(); i++ {
+			_, _, tasks := fakeClient.RequestTaskAuctionsWithResultArgsForCall(i)
+			Expect(len(tasks)).To(BeNumerically("<=", 3))
+			total += len(tasks)
+		}
+		Expect(total).To(Equal(7))
+	})
+
+	Context("when a batch fails", func() {
+		var submitErr error
+
+		BeforeEach(func() {
+			submitErr = errors.New("boom")
+			fakeClient.RequestTaskAuctionsWithResultReturns(AuctionResult{}, submitErr)
+		})
+
+		It("reports the error to each submitter and from Flush", func() {
+			var (
+				lock    sync.Mutex
+				results []error
+			)
+			callback := func(err error) {
+				lock.Lock()
+				defer lock.Unlock()
+				results = append(results, err)
+			}
+
+			Expect(batchClient.AddLRP(&LRPStartRequest{}, callback)).To(Succeed())
+			Expect(batchClient.AddTask(&TaskStartRequest{}, callback)).To(Succeed())
+
+			Expect(batchClient.Flush(context.Background())).To(MatchError(submitErr))
+			Expect(results).To(ConsistOf(nil, submitErr))
+		})
+	})
+
+	Describe("Close", func() {
+		It("flushes what is pending", func() {
+			Expect(batchClient.AddLRP(&LRPStartRequest{}, nil)).To(Succeed())
+
+			Expect(batchClient.Close(context.Background())).To(Succeed())
+			Expect(fakeClient.RequestLRPAuctionsWithResultCallCount()).To(Equal(1))
+		})
+
+		Context("when a periodic flush is stalled", func() {
+			BeforeEach(func() {
+				fakeClient.RequestLRPAuctionsWithResultStub = func(_ lager.Logger, ctx context.Context, _ []*LRPStartRequest) (AuctionResult, error) {
+					<-ctx.Done()
+					return AuctionResult{}, ctx.Err()
+				}
+			})
+
+			It("cancels the flush once its context is done", func() {
+				var (
+					lock     sync.Mutex
+					flushErr error
+				)
+				Expect(batchClient.AddLRP(&LRPStartRequest{}, func(err error) {
+					lock.Lock()
+					defer lock.Unlock()
+					flushErr = err
+				})).To(Succeed())
+
+				fakeClock.WaitForWatcherAndIncrement(flushInterval)
+				Eventually(fakeClient.RequestLRPAuctionsWithResultCallCount).Should(Equal(1))
+
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+				Expect(batchClient.Close(ctx)).To(Succeed())
+
+				lock.Lock()
+				defer lock.Unlock()
+				Expect(errors.Is(flushErr, context.Canceled)).To(BeTrue())
+			})
+		})
+
+		It("rejects later requests", func() {
+			Expect(batchClient.Close(context.Background())).To(Succeed())
+
+			Expect(batchClient.AddLRP(&LRPStartRequest{}, nil)).To(MatchError(ErrBatchClientClosed))
+			Expect(batchClient.AddTask(&TaskStartRequest{}, nil)).To(MatchError(ErrBatchClientClosed))
+		})
+	})
+})
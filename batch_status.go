@@ -0,0 +1,143 @@
+package auctioneer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+)
+
+const (
+	batchStatusInitialPollInterval = 100 * time.Millisecond
+	batchStatusMaxPollInterval     = 5 * time.Second
+)
+
+var ErrEmptyBatchLocation = errors.New("batch location is empty")
+
+type BatchState string
+
+const (
+	BatchStatePending  BatchState = "pending"
+	BatchStateComplete BatchState = "complete"
+	BatchStateFailed   BatchState = "failed"
+)
+
+// BatchStatus is the representation of the status resource the auctioneer
+// returns in the Location header of an accepted auction batch. Reason is
+// set, when the auctioneer provides one, for a failed batch.
+type BatchStatus struct {
+	State  BatchState      `json:"state"`
+	Error  string          `json:"error,omitempty"`
+	Reason RejectionReason `json:"reason,omitempty"`
+}
+
+// Done reports whether the auctioneer has finished processing the batch,
+// successfully or not.
+func (s BatchStatus) Done() bool {
+	return s.State == BatchStateComplete || s.State == BatchStateFailed
+}
+
+// WaitForBatch polls the status resource at location, backing off between
+// attempts, until the batch is done or the context expires. Polls that fail
+// with a retryable error (see IsRetryable) or with a 5xx or 429 status are
+// tried again; other failures end the wait. When the context expires after
+// a failed poll, the returned error wraps both the context error and the
+// poll's.
+func (c *auctioneerClient) WaitForBatch(logger lager.Logger, ctx context.Context, location string) (BatchStatus, error) {
+	ctx = requestContext(ctx)
+	logger = c.requestLogger(logger, ctx).Session("wait-for-batch", lager.Data{"location": location})
+
+	if location == "" {
+		return BatchStatus{}, ErrEmptyBatchLocation
+	}
+
+	interval := batchStatusInitialPollInterval
+	var pollErr error
+	for {
+		status, err := c.fetchBatchStatus(logger, ctx, location)
+		if err != nil {
+			if ctx.Err() != nil {
+				return BatchStatus{}, withPollError(err, pollErr)
+			}
+			if !isRetryablePollError(err) {
+				return BatchStatus{}, err
+			}
+			logger.Debug("retrying-batch-status", lager.Data{"error": err.Error()})
+		}
+		pollErr = err
+
+		if status.Done() {
+			logger.Debug("batch-done", lager.Data{"state": status.State})
+			return status, nil
+		}
+
+		timer := c.clock.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return status, withPollError(ctx.Err(), pollErr)
+		case <-timer.C():
+		}
+
+		interval *= 2
+		if interval > batchStatusMaxPollInterval {
+			interval = batchStatusMaxPollInterval
+		}
+	}
+}
+
+func (c *auctioneerClient) fetchBatchStatus(logger lager.Logger, ctx context.Context, location string) (BatchStatus, error) {
+	req, err := http.NewRequest("GET", location, nil)
+	if err != nil {
+		return BatchStatus{}, err
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := c.doRequest(logger, req, "WaitForBatch")
+	if err != nil {
+		return BatchStatus{}, err
+	}
+	defer resp.Body.Close()
+
+	err = c.checkResponse(OperationBatchStatus, resp, isStatusOK)
+	if err != nil {
+		return BatchStatus{}, err
+	}
+
+	status := BatchStatus{}
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	if err != nil {
+		return BatchStatus{}, err
+	}
+
+	return status, nil
+}
+
+func isStatusOK(code int) bool {
+	return code == http.StatusOK
+}
+
+func isRetryablePollError(err error) bool {
+	if retryable, ok := asClassified(err); ok {
+		return retryable
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	return IsRetryable(err)
+}
+
+// withPollError adds the failure of the last poll, if there was one, to the
+// error that ended the wait.
+func withPollError(err, pollErr error) error {
+	if pollErr == nil {
+		return err
+	}
+	return fmt.Errorf("%w: last poll failed: %w", err, pollErr)
+}
@@ -0,0 +1,164 @@
+package auctioneer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("WaitForBatch", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		client     ExtendedClient
+		location   string
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		client = NewClient(fakeServer.URL())
+		location = fakeServer.URL() + "/v1/batches/some-batch"
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	Context("when the batch completes after a few polls", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/batches/some-batch"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, BatchStatus{State: BatchStatePending}),
+				),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, BatchStatus{State: BatchStatePending}),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, BatchStatus{State: BatchStateComplete}),
+			)
+		})
+
+		It("returns the final status", func() {
+			status, err := client.WaitForBatch(logger, context.Background(), location)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.State).To(Equal(BatchStateComplete))
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(3))
+		})
+
+		It("doubles the interval between polls", func() {
+			fakeClock := fakeclock.NewFakeClock(time.Now())
+			client = NewClient(fakeServer.URL(), WithClock(fakeClock))
+
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				_, err := client.WaitForBatch(logger, context.Background(), location)
+				Expect(err).NotTo(HaveOccurred())
+			}()
+
+			fakeClock.WaitForWatcherAndIncrement(100 * time.Millisecond)
+			fakeClock.WaitForWatcherAndIncrement(100 * time.Millisecond)
+			Consistently(done).ShouldNot(BeClosed())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(2))
+
+			fakeClock.Increment(100 * time.Millisecond)
+			Eventually(done).Should(BeClosed())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(3))
+		})
+	})
+
+	Context("when the batch fails", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(
+				ghttp.RespondWithJSONEncoded(http.StatusOK, BatchStatus{State: BatchStateFailed, Error: "boom", Reason: RejectionReasonInsufficientResources}),
+			)
+		})
+
+		It("returns the failed status", func() {
+			status, err := client.WaitForBatch(logger, context.Background(), location)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.Done()).To(BeTrue())
+			Expect(status.Error).To(Equal("boom"))
+			Expect(status.Reason).To(Equal(RejectionReasonInsufficientResources))
+		})
+	})
+
+	Context("when the context expires before the batch completes", func() {
+		BeforeEach(func() {
+			fakeServer.RouteToHandler("GET", "/v1/batches/some-batch",
+				ghttp.RespondWithJSONEncoded(http.StatusOK, BatchStatus{State: BatchStatePending}),
+			)
+		})
+
+		It("returns the context error", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+			defer cancel()
+
+			_, err := client.WaitForBatch(logger, ctx, location)
+			Expect(err).To(Equal(context.DeadlineExceeded))
+		})
+	})
+
+	Context("when the status resource returns an error", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, ""))
+		})
+
+		It("returns an error", func() {
+			_, err := client.WaitForBatch(logger, context.Background(), location)
+			Expect(err).To(MatchError("http error: status code 404 (Not Found)"))
+		})
+	})
+
+	Context("when a poll fails with a retryable status", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(
+				ghttp.RespondWithJSONEncoded(http.StatusOK, BatchStatus{State: BatchStatePending}),
+				ghttp.RespondWith(http.StatusServiceUnavailable, ""),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, BatchStatus{State: BatchStateComplete}),
+			)
+		})
+
+		It("keeps polling until the batch completes", func() {
+			status, err := client.WaitForBatch(logger, context.Background(), location)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.State).To(Equal(BatchStateComplete))
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(3))
+		})
+	})
+
+	Context("when the context expires while polls are failing", func() {
+		BeforeEach(func() {
+			fakeServer.RouteToHandler("GET", "/v1/batches/some-batch", ghttp.RespondWith(http.StatusTooManyRequests, ""))
+		})
+
+		It("returns both the context error and the last poll failure", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+			defer cancel()
+
+			_, err := client.WaitForBatch(logger, ctx, location)
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+
+			var statusErr *StatusError
+			Expect(errors.As(err, &statusErr)).To(BeTrue())
+			Expect(statusErr.StatusCode).To(Equal(http.StatusTooManyRequests))
+			Expect(len(fakeServer.ReceivedRequests())).To(BeNumerically(">", 1))
+		})
+	})
+
+	Context("when the location is empty", func() {
+		It("returns ErrEmptyBatchLocation", func() {
+			_, err := client.WaitForBatch(logger, context.Background(), "")
+			Expect(err).To(Equal(ErrEmptyBatchLocation))
+		})
+	})
+})
@@ -0,0 +1,38 @@
+package auctioneer
+
+// WithBatchTransform passes every LRP auction batch through transform before
+// it is marshaled, so that a policy such as a default domain can be applied
+// in one place rather than at every call site. transform may modify the
+// batch in place or return a different one; what it returns is submitted,
+// and the indexes of Rejected entries refer to it. It applies to
+// RequestLRPAuctions, RequestLRPAuctionsWithResult,
+// RequestLRPAuctionsResponse and DryRunLRPAuctions, before the batch is split
+// by WithSharding, but not to batches that RequestLRPAuctionsRaw receives
+// already marshaled.
+func WithBatchTransform(transform func([]*LRPStartRequest) []*LRPStartRequest) ClientOption {
+	return func(c *auctioneerClient) {
+		c.lrpTransform = transform
+	}
+}
+
+// WithTaskBatchTransform passes every task auction batch through transform
+// before it is marshaled, as WithBatchTransform does for LRPs.
+func WithTaskBatchTransform(transform func([]*TaskStartRequest) []*TaskStartRequest) ClientOption {
+	return func(c *auctioneerClient) {
+		c.taskTransform = transform
+	}
+}
+
+func (c *auctioneerClient) transformLRPs(lrpStarts []*LRPStartRequest) []*LRPStartRequest {
+	if c.lrpTransform == nil {
+		return lrpStarts
+	}
+	return c.lrpTransform(lrpStarts)
+}
+
+func (c *auctioneerClient) transformTasks(tasks []*TaskStartRequest) []*TaskStartRequest {
+	if c.taskTransform == nil {
+		return tasks
+	}
+	return c.taskTransform(tasks)
+}
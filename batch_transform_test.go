@@ -0,0 +1,112 @@
+package auctioneer_test
+
+import (
+	"context"
+	"net/http"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Batch transforms", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+	)
+
+	defaultDomain := func(lrpStarts []*LRPStartRequest) []*LRPStartRequest {
+		for _, lrpStart := range lrpStarts {
+			if lrpStart.Domain == "" {
+				lrpStart.Domain = "default-domain"
+			}
+		}
+		return lrpStarts
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	Describe("WithBatchTransform", func() {
+		It("submits the transformed LRP batch", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyJSONRepresenting([]*LRPStartRequest{
+					{ProcessGuid: "some-guid", Domain: "default-domain"},
+					{ProcessGuid: "other-guid", Domain: "other-domain"},
+				}),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+
+			client := NewClient(fakeServer.URL(), WithBatchTransform(defaultDomain))
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{
+				{ProcessGuid: "some-guid"},
+				{ProcessGuid: "other-guid", Domain: "other-domain"},
+			})).To(Succeed())
+		})
+
+		It("counts the entries of the batch it returns", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyJSONRepresenting([]*LRPStartRequest{{ProcessGuid: "some-guid"}}),
+				ghttp.RespondWith(http.StatusAccepted, ""),
+			))
+
+			client := NewClient(fakeServer.URL(), WithBatchTransform(func(lrpStarts []*LRPStartRequest) []*LRPStartRequest {
+				return lrpStarts[:1]
+			}))
+			result, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), []*LRPStartRequest{
+				{ProcessGuid: "some-guid"},
+				{ProcessGuid: "other-guid"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Accepted).To(Equal(1))
+		})
+
+		It("transforms dry runs too", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV(DryRunHeader, "true"),
+				ghttp.VerifyJSONRepresenting([]*LRPStartRequest{{ProcessGuid: "some-guid", Domain: "default-domain"}}),
+				ghttp.RespondWith(http.StatusOK, "{}"),
+			))
+
+			client := NewClient(fakeServer.URL(), WithBatchTransform(defaultDomain))
+			_, err := client.DryRunLRPAuctions(logger, context.Background(), []*LRPStartRequest{{ProcessGuid: "some-guid"}})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("leaves task batches alone", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyJSONRepresenting([]*TaskStartRequest{{}}),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+
+			client := NewClient(fakeServer.URL(), WithBatchTransform(func([]*LRPStartRequest) []*LRPStartRequest {
+				Fail("transformed a task batch")
+				return nil
+			}))
+			Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{{}})).To(Succeed())
+		})
+	})
+
+	Describe("WithTaskBatchTransform", func() {
+		It("submits the transformed task batch", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyJSONRepresenting([]*TaskStartRequest{}),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+
+			client := NewClient(fakeServer.URL(), WithTaskBatchTransform(func([]*TaskStartRequest) []*TaskStartRequest {
+				return []*TaskStartRequest{}
+			}))
+			Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{{}, {}})).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,192 @@
+package auctioneer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// DefaultCapabilitiesTTL is how long Capabilities reuses the auctioneer's
+// answer unless WithCapabilitiesTTL says otherwise.
+const DefaultCapabilitiesTTL = 5 * time.Minute
+
+// Modes an auctioneer may advertise in Capabilities.
+const (
+	ModeDryRun = "dry-run"
+)
+
+// ErrCapabilitiesNotSupported is returned by Capabilities when the
+// auctioneer predates capability detection. Such an auctioneer supports
+// JSON request bodies only, without encodings or optional modes.
+var ErrCapabilitiesNotSupported = errors.New("auctioneer does not advertise its capabilities")
+
+// Capabilities describes the optional request features an auctioneer
+// supports. The auctioneer returns it as the JSON body of a 200 response to
+// an OPTIONS request for its base URL.
+type Capabilities struct {
+	// ContentTypes are the media types accepted for auction request bodies.
+	ContentTypes []string `json:"content_types"`
+	// Encodings are the Content-Encoding values accepted for request
+	// bodies, such as "gzip".
+	Encodings []string `json:"encodings"`
+	// Modes are the optional request modes supported, such as ModeDryRun.
+	Modes []string `json:"modes"`
+	// MaxPayloadBytes is the largest marshaled auction batch accepted, 0
+	// when the auctioneer does not advertise a limit.
+	MaxPayloadBytes int `json:"max_payload_bytes,omitempty"`
+}
+
+func (c Capabilities) SupportsContentType(contentType string) bool {
+	return containsString(c.ContentTypes, contentType)
+}
+
+func (c Capabilities) SupportsEncoding(encoding string) bool {
+	return containsString(c.Encodings, encoding)
+}
+
+func (c Capabilities) SupportsMode(mode string) bool {
+	return containsString(c.Modes, mode)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// WithCapabilitiesTTL makes Capabilities reuse the auctioneer's answer for
+// ttl before asking again. A non-positive ttl asks on every call.
+func WithCapabilitiesTTL(ttl time.Duration) ClientOption {
+	return func(c *auctioneerClient) {
+		c.capabilities.ttl = ttl
+	}
+}
+
+// capabilitiesCache holds the last answer, including
+// ErrCapabilitiesNotSupported, of each auctioneer URL.
+type capabilitiesCache struct {
+	ttl time.Duration
+
+	lock    sync.Mutex
+	entries map[string]capabilitiesEntry
+}
+
+type capabilitiesEntry struct {
+	capabilities Capabilities
+	err          error
+	expires      time.Time
+}
+
+func (cc *capabilitiesCache) get(url string, now time.Time) (capabilitiesEntry, bool) {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+
+	entry, ok := cc.entries[url]
+	if !ok || !now.Before(entry.expires) {
+		return capabilitiesEntry{}, false
+	}
+	return entry, true
+}
+
+func (cc *capabilitiesCache) set(url string, entry capabilitiesEntry, now time.Time) {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+
+	if cc.entries == nil {
+		cc.entries = map[string]capabilitiesEntry{}
+	}
+	for u, e := range cc.entries {
+		if !now.Before(e.expires) {
+			delete(cc.entries, u)
+		}
+	}
+
+	entry.expires = now.Add(cc.ttl)
+	cc.entries[url] = entry
+}
+
+// Capabilities asks the auctioneer which content types, encodings and modes
+// it supports. Each auctioneer URL's answer is cached for the capabilities
+// TTL. An auctioneer that predates capability detection yields
+// ErrCapabilitiesNotSupported, which is cached too; other failures are not.
+func (c *auctioneerClient) Capabilities(logger lager.Logger, ctx context.Context) (Capabilities, error) {
+	ctx = requestContext(ctx)
+	url, err := c.targetURL(ctx)
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	if entry, ok := c.capabilities.get(url, c.clock.Now()); ok {
+		return entry.capabilities, entry.err
+	}
+
+	logger = c.requestLogger(logger, ctx).Session("capabilities")
+
+	capabilities, err := c.fetchCapabilities(logger, ctx, url)
+	if err != nil && err != ErrCapabilitiesNotSupported {
+		return Capabilities{}, err
+	}
+
+	c.capabilities.set(url, capabilitiesEntry{capabilities: capabilities, err: err}, c.clock.Now())
+	return capabilities, err
+}
+
+// batchCapabilities returns Capabilities for the batch being built with
+// ctx. By then ctx carries the batch's client traces, which record its
+// connection and whether it was sent, so the capabilities are fetched
+// without them.
+func (c *auctioneerClient) batchCapabilities(logger lager.Logger, ctx context.Context) (Capabilities, error) {
+	return c.Capabilities(logger, untracedContext{ctx})
+}
+
+// untracedContext is its Context without an httptrace.ClientTrace.
+type untracedContext struct {
+	context.Context
+}
+
+func (ctx untracedContext) Value(key interface{}) interface{} {
+	value := ctx.Context.Value(key)
+	if _, ok := value.(*httptrace.ClientTrace); ok {
+		return nil
+	}
+	return value
+}
+
+func (c *auctioneerClient) fetchCapabilities(logger lager.Logger, ctx context.Context, url string) (Capabilities, error) {
+	req, err := http.NewRequest("OPTIONS", url, nil)
+	if err != nil {
+		return Capabilities{}, err
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := c.doRequest(logger, req, "Capabilities")
+	if err != nil {
+		return Capabilities{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return Capabilities{}, ErrCapabilitiesNotSupported
+	default:
+		return Capabilities{}, newStatusError(OperationCapabilities, resp)
+	}
+
+	capabilities := Capabilities{}
+	err = json.NewDecoder(resp.Body).Decode(&capabilities)
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	return capabilities, nil
+}
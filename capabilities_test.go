@@ -0,0 +1,116 @@
+package auctioneer_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Capabilities", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		client     ExtendedClient
+		advertised Capabilities
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		client = NewClient(fakeServer.URL())
+		advertised = Capabilities{
+			ContentTypes: []string{"application/json"},
+			Encodings:    []string{"gzip"},
+			Modes:        []string{ModeDryRun},
+		}
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	Context("when the auctioneer advertises its capabilities", func() {
+		BeforeEach(func() {
+			fakeServer.RouteToHandler("OPTIONS", "/", ghttp.RespondWithJSONEncoded(http.StatusOK, advertised))
+		})
+
+		It("returns them", func() {
+			capabilities, err := client.Capabilities(logger, context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(capabilities).To(Equal(advertised))
+			Expect(capabilities.SupportsEncoding("gzip")).To(BeTrue())
+			Expect(capabilities.SupportsMode(ModeDryRun)).To(BeTrue())
+			Expect(capabilities.SupportsContentType("application/x-protobuf")).To(BeFalse())
+		})
+
+		It("caches them for the TTL", func() {
+			_, err := client.Capabilities(logger, context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			_, err = client.Capabilities(logger, context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("asks again once the TTL has passed", func() {
+			fakeClock := fakeclock.NewFakeClock(time.Now())
+			client = NewClient(fakeServer.URL(), WithCapabilitiesTTL(time.Minute), WithClock(fakeClock))
+
+			_, err := client.Capabilities(logger, context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			fakeClock.Increment(time.Minute)
+			_, err = client.Capabilities(logger, context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("asks again after the URL changes", func() {
+			otherServer := ghttp.NewServer()
+			defer otherServer.Close()
+			otherServer.RouteToHandler("OPTIONS", "/", ghttp.RespondWithJSONEncoded(http.StatusOK, Capabilities{}))
+
+			_, err := client.Capabilities(logger, context.Background())
+			Expect(err).NotTo(HaveOccurred())
+
+			client.SetURL(otherServer.URL())
+			capabilities, err := client.Capabilities(logger, context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(capabilities.SupportsEncoding("gzip")).To(BeFalse())
+		})
+	})
+
+	Context("when the auctioneer predates capability detection", func() {
+		BeforeEach(func() {
+			fakeServer.RouteToHandler("OPTIONS", "/", ghttp.RespondWith(http.StatusMethodNotAllowed, ""))
+		})
+
+		It("returns and caches ErrCapabilitiesNotSupported", func() {
+			_, err := client.Capabilities(logger, context.Background())
+			Expect(err).To(Equal(ErrCapabilitiesNotSupported))
+			_, err = client.Capabilities(logger, context.Background())
+			Expect(err).To(Equal(ErrCapabilitiesNotSupported))
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+
+	Context("when the request fails", func() {
+		BeforeEach(func() {
+			fakeServer.RouteToHandler("OPTIONS", "/", ghttp.RespondWith(http.StatusInternalServerError, ""))
+		})
+
+		It("does not cache the failure", func() {
+			_, err := client.Capabilities(logger, context.Background())
+			Expect(err).To(MatchError(ContainSubstring("status code 500")))
+			_, err = client.Capabilities(logger, context.Background())
+			Expect(err).To(HaveOccurred())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(2))
+		})
+	})
+})
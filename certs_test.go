@@ -0,0 +1,103 @@
+package auctioneer_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+type tlsFixtures struct {
+	CAFile         string
+	ServerCertFile string
+	ServerKeyFile  string
+	ClientCertFile string
+	ClientKeyFile  string
+
+	CACert *x509.Certificate
+	CAKey  *ecdsa.PrivateKey
+}
+
+// newTLSFixtures writes a CA and a server and client certificate signed by
+// it into dir. The server certificate is valid for serverNames and, when
+// serverIPs is non-empty, for those IPs.
+func newTLSFixtures(dir string, serverNames []string, serverIPs ...net.IP) tlsFixtures {
+	caKey := newKey()
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "auctioneer test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	Expect(err).NotTo(HaveOccurred())
+	caCert, err := x509.ParseCertificate(caDER)
+	Expect(err).NotTo(HaveOccurred())
+
+	fixtures := tlsFixtures{
+		CAFile:         filepath.Join(dir, "ca.crt"),
+		ServerCertFile: filepath.Join(dir, "server.crt"),
+		ServerKeyFile:  filepath.Join(dir, "server.key"),
+		ClientCertFile: filepath.Join(dir, "client.crt"),
+		ClientKeyFile:  filepath.Join(dir, "client.key"),
+		CACert:         caCert,
+		CAKey:          caKey,
+	}
+	writePEM(fixtures.CAFile, "CERTIFICATE", caDER)
+
+	writeSignedCert(fixtures, 2, fixtures.ServerCertFile, fixtures.ServerKeyFile, serverNames, serverIPs)
+	writeSignedCert(fixtures, 3, fixtures.ClientCertFile, fixtures.ClientKeyFile, nil, nil)
+
+	return fixtures
+}
+
+func (f tlsFixtures) ServerTLSConfig() *tls.Config {
+	cert, err := tls.LoadX509KeyPair(f.ServerCertFile, f.ServerKeyFile)
+	Expect(err).NotTo(HaveOccurred())
+	return &tls.Config{Certificates: []tls.Certificate{cert}}
+}
+
+func writeSignedCert(f tlsFixtures, serial int64, certFile, keyFile string, names []string, ips []net.IP) {
+	key := newKey()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "auctioneer test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     names,
+		IPAddresses:  ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, f.CACert, &key.PublicKey, f.CAKey)
+	Expect(err).NotTo(HaveOccurred())
+	writePEM(certFile, "CERTIFICATE", der)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+	writePEM(keyFile, "EC PRIVATE KEY", keyDER)
+}
+
+func newKey() *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	return key
+}
+
+func writePEM(path, blockType string, der []byte) {
+	err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600)
+	Expect(err).NotTo(HaveOccurred())
+}
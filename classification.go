@@ -0,0 +1,101 @@
+package auctioneer
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Classification is how a FailureClassifier interprets the outcome of a
+// request.
+type Classification int
+
+const (
+	ClassificationSuccess Classification = iota
+	ClassificationRetryableError
+	ClassificationPermanentError
+)
+
+// FailureClassifier classifies the outcome of a request: either the
+// response, or the error when no response was received.
+type FailureClassifier func(resp *http.Response, err error) Classification
+
+// WithFailureClassifier makes classifier decide how auction submissions and
+// batch status polls are interpreted, in place of the standard rules: only
+// a response classified as ClassificationSuccess succeeds, and WithRetries
+// and WaitForBatch try again only after a ClassificationRetryableError,
+// which IsRetryable then reports for the returned error too. Auction
+// submissions that reached the auctioneer are still only retried with
+// WithRetryAfterSend.
+//
+// Without a classifier, transport errors are retryable as IsRetryable
+// reports and auction responses succeed with the statuses set by
+// WithSuccessStatusCodes.
+func WithFailureClassifier(classifier FailureClassifier) ClientOption {
+	return func(c *auctioneerClient) {
+		c.failureClassifier = classifier
+	}
+}
+
+// classifiedError is an error whose retryability a FailureClassifier
+// decided.
+type classifiedError struct {
+	err       error
+	retryable bool
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() error {
+	return e.err
+}
+
+// asClassified reports the retryability a FailureClassifier decided for
+// err, if it did.
+func asClassified(err error) (retryable bool, ok bool) {
+	var classifiedErr *classifiedError
+	if errors.As(err, &classifiedErr) {
+		return classifiedErr.retryable, true
+	}
+	return false, false
+}
+
+// isRetryableAttempt reports whether a request attempt that ended with resp
+// or err is worth trying again.
+func (c *auctioneerClient) isRetryableAttempt(resp *http.Response, err error) bool {
+	if c.failureClassifier == nil {
+		return err != nil && IsRetryable(err)
+	}
+	return c.failureClassifier(resp, err) == ClassificationRetryableError
+}
+
+// classifyError records the classifier's verdict on a request that failed
+// with err.
+func (c *auctioneerClient) classifyError(err error) error {
+	if c.failureClassifier == nil {
+		return err
+	}
+	return &classifiedError{err: err, retryable: c.failureClassifier(nil, err) == ClassificationRetryableError}
+}
+
+// checkResponse returns nil if resp is a success, whose status is
+// successStatus unless a classifier decides, or else the error for
+// operation.
+func (c *auctioneerClient) checkResponse(operation string, resp *http.Response, successStatus func(int) bool) error {
+	if c.failureClassifier == nil {
+		if successStatus(resp.StatusCode) {
+			return nil
+		}
+		return newStatusError(operation, resp)
+	}
+
+	switch c.failureClassifier(resp, nil) {
+	case ClassificationSuccess:
+		return nil
+	case ClassificationRetryableError:
+		return &classifiedError{err: newStatusError(operation, resp), retryable: true}
+	default:
+		return &classifiedError{err: newStatusError(operation, resp)}
+	}
+}
@@ -0,0 +1,126 @@
+package auctioneer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("WithFailureClassifier", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		classifier FailureClassifier
+	)
+
+	// a proxy that answers 299 for accepted batches and 520 while the
+	// auctioneer restarts
+	const (
+		statusProxyAccepted    = 299
+		statusProxyUnavailable = 520
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		classifier = func(resp *http.Response, err error) Classification {
+			switch {
+			case err != nil:
+				return ClassificationPermanentError
+			case resp.StatusCode == statusProxyAccepted || resp.StatusCode == http.StatusOK:
+				return ClassificationSuccess
+			case resp.StatusCode == statusProxyUnavailable:
+				return ClassificationRetryableError
+			default:
+				return ClassificationPermanentError
+			}
+		}
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("succeeds on the responses the classifier accepts", func() {
+		fakeServer.AppendHandlers(ghttp.RespondWith(statusProxyAccepted, "{}"))
+
+		client := NewClient(fakeServer.URL(), WithFailureClassifier(classifier))
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+	})
+
+	It("fails on the responses the classifier rejects", func() {
+		fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+
+		client := NewClient(fakeServer.URL(), WithFailureClassifier(classifier))
+		err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+
+		var statusErr *StatusError
+		Expect(errors.As(err, &statusErr)).To(BeTrue())
+		Expect(statusErr.StatusCode).To(Equal(http.StatusAccepted))
+		Expect(IsRetryable(err)).To(BeFalse())
+	})
+
+	It("reports retryable responses through IsRetryable", func() {
+		fakeServer.AppendHandlers(ghttp.RespondWith(statusProxyUnavailable, "{}"))
+
+		client := NewClient(fakeServer.URL(), WithFailureClassifier(classifier))
+		Expect(IsRetryable(client.RequestLRPAuctions(logger, []*LRPStartRequest{}))).To(BeTrue())
+	})
+
+	It("overrides IsRetryable for transport errors", func() {
+		client := NewClient(fakeServer.URL(), WithFailureClassifier(classifier))
+		fakeServer.Close()
+		Expect(IsRetryable(client.RequestLRPAuctions(logger, []*LRPStartRequest{}))).To(BeFalse())
+	})
+
+	It("retries responses the classifier marks retryable", func() {
+		fakeServer.AppendHandlers(
+			ghttp.RespondWith(statusProxyUnavailable, "{}"),
+			ghttp.RespondWith(statusProxyAccepted, "{}"),
+		)
+
+		client := NewClient(fakeServer.URL(), WithFailureClassifier(classifier), WithRetries(2), WithRetryAfterSend())
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(fakeServer.ReceivedRequests()).To(HaveLen(2))
+	})
+
+	It("does not retry auction submissions that reached the auctioneer without WithRetryAfterSend", func() {
+		fakeServer.AppendHandlers(ghttp.RespondWith(statusProxyUnavailable, "{}"))
+
+		client := NewClient(fakeServer.URL(), WithFailureClassifier(classifier), WithRetries(2))
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+		Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("keeps polling a batch while the classifier marks polls retryable", func() {
+		fakeServer.AppendHandlers(
+			ghttp.RespondWith(statusProxyUnavailable, "{}"),
+			ghttp.RespondWithJSONEncoded(http.StatusOK, BatchStatus{State: BatchStateComplete}),
+		)
+
+		client := NewClient(fakeServer.URL(), WithFailureClassifier(classifier))
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		status, err := client.WaitForBatch(logger, ctx, fakeServer.URL()+"/v1/batches/some-batch")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.State).To(Equal(BatchStateComplete))
+	})
+
+	It("stops polling a batch when the classifier marks a poll permanent", func() {
+		fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusServiceUnavailable, "{}"))
+
+		client := NewClient(fakeServer.URL(), WithFailureClassifier(classifier))
+		_, err := client.WaitForBatch(logger, context.Background(), fakeServer.URL()+"/v1/batches/some-batch")
+		Expect(err).To(HaveOccurred())
+		Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+	})
+})
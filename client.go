@@ -1,39 +1,342 @@
 package auctioneer
 
 import (
-	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"code.cloudfoundry.org/cfhttp"
+	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagerctx"
+	"github.com/fsnotify/fsnotify"
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/tedsuo/rata"
 )
 
+var ErrTLSNotConfigured = errors.New("client was not configured with TLS")
+
+// Header values shared by every request; the transport does not modify them.
+var (
+	jsonContentType   = []string{"application/json"}
+	dryRunHeaderValue = []string{"true"}
+)
+
+// lagerctx returns an inert logger of this type when a context carries no
+// logger.
+var discardLoggerType = reflect.TypeOf(lagerctx.FromContext(context.Background()))
+
+// Client submits auction batches to the auctioneer.
+//
 //go:generate counterfeiter -o auctioneerfakes/fake_client.go . Client
 type Client interface {
 	RequestLRPAuctions(logger lager.Logger, lrpStart []*LRPStartRequest) error
 	RequestTaskAuctions(logger lager.Logger, tasks []*TaskStartRequest) error
 }
 
+// ExtendedClient is the Client returned by NewClient, NewSecureClient and
+// NewClientFromConfig. It is kept apart from Client so that existing
+// implementations of Client keep satisfying it as methods are added here.
+//
+// Clients returned by the constructors are safe for concurrent use by
+// multiple goroutines, including concurrent calls to SetURL and ReloadTLS
+// while requests are in flight. A request observes the URL and TLS
+// configuration that were current when it started.
+//
+// Methods given a nil context use context.Background() instead.
+//
+//go:generate counterfeiter -o auctioneerfakes/fake_extended_client.go . ExtendedClient
+type ExtendedClient interface {
+	Client
+	RequestLRPAuctionsWithResult(logger lager.Logger, ctx context.Context, lrpStart []*LRPStartRequest) (AuctionResult, error)
+	RequestTaskAuctionsWithResult(logger lager.Logger, ctx context.Context, tasks []*TaskStartRequest) (AuctionResult, error)
+	RequestLRPAuctionsResponse(logger lager.Logger, ctx context.Context, lrpStart []*LRPStartRequest) (*http.Response, error)
+	RequestTaskAuctionsResponse(logger lager.Logger, ctx context.Context, tasks []*TaskStartRequest) (*http.Response, error)
+	RequestLRPAuctionsRaw(logger lager.Logger, ctx context.Context, body io.Reader) error
+	WaitForBatch(logger lager.Logger, ctx context.Context, location string) (BatchStatus, error)
+	DryRunLRPAuctions(logger lager.Logger, ctx context.Context, lrpStart []*LRPStartRequest) (DryRunResult, error)
+	DryRunTaskAuctions(logger lager.Logger, ctx context.Context, tasks []*TaskStartRequest) (DryRunResult, error)
+	Ping(logger lager.Logger, ctx context.Context) (PingResult, error)
+	Warmup(ctx context.Context) error
+	Capabilities(logger lager.Logger, ctx context.Context) (Capabilities, error)
+	Stats() ClientStats
+	StatsAndReset() ClientStats
+	ServerVersion() string
+	SetURL(auctioneerURL string)
+	ReloadTLS() error
+	InjectMetadata(ctx context.Context, carrier MetadataCarrier)
+	Shutdown(ctx context.Context) error
+	Close() error
+}
+
 type auctioneerClient struct {
+	// lock guards the fields below that SetURL and ReloadTLS mutate.
+	lock               sync.RWMutex
 	httpClient         *http.Client
 	insecureHTTPClient *http.Client
 	url                string
-	requireTLS         bool
+	reqGen             *rata.RequestGenerator
+
+	requireTLS bool
+	caFile     string
+	certFile   string
+	keyFile    string
+
+	useContextLogger         bool
+	metrics                  MetricsHook
+	responseHeaderTimeout    time.Duration
+	tracer                   opentracing.Tracer
+	tracerFunc               func(ctx context.Context) opentracing.Tracer
+	spanNamer                func(op string) string
+	serverNames              []string
+	ocspStapling             bool
+	crl                      *crlChecker
+	transportConfigs         []func(*http.Transport)
+	roundTripper             http.RoundTripper
+	ndjsonStreaming          bool
+	ndjsonThreshold          int
+	contextHeaders           map[interface{}]string
+	constantTags             map[string]string
+	replicaReads             bool
+	bufferPool               BufferPool
+	maxRetries               int
+	retryAfterSend           bool
+	resolver                 *net.Resolver
+	dialFallbackDelay        time.Duration
+	dialFallbackDelaySet     bool
+	dialControl              func(network, address string, conn syscall.RawConn) error
+	insecureFallbackLogger   lager.Logger
+	contentType              []string
+	maxResponseHeaderBytes   int64
+	batchTrailers            bool
+	digestAlgorithm          DigestAlgorithm
+	wireLogger               func(dir string, b []byte)
+	routes                   rata.Routes
+	logThrottle              *logThrottle
+	stats                    *clientStats
+	successStatusCodes       []int
+	strictStatus             bool
+	rateLimitQPS             float64
+	rateLimitBurst           int
+	operationWeights         map[string]float64
+	rateLimiter              *rateLimiter
+	tlsSessionCache          tls.ClientSessionCache
+	tlsSessionCacheSet       bool
+	tlsSessionCacheSize      int
+	serverVersion            atomic.Value
+	capabilities             *capabilitiesCache
+	serverPayloadLimit       bool
+	autoCompression          bool
+	autoCompressionThreshold int
+	shardBackends            []string
+	shardFunc                ShardFunc
+	failureClassifier        FailureClassifier
+	maxPayloadBytes          int
+	marshalTimeout           time.Duration
+	postSuccessHook          PostSuccessHook
+	disableCompression       bool
+	lrpTransform             func([]*LRPStartRequest) []*LRPStartRequest
+	taskTransform            func([]*TaskStartRequest) []*TaskStartRequest
+	isolationSegmentHeader   string
+	clock                    clock.Clock
+	failover                 *failover
+	failoverFailFast         bool
+	failoverWeighted         bool
+	backendSelector          BackendSelector
+	hedgeDelay               time.Duration
+	maxHedges                int
+	healthProbeInterval      time.Duration
+	healthProbeJitter        time.Duration
+	// transportOwner, when not nil, is the client whose HTTP clients this
+	// one sends its requests through; see NewClientSharingTransport.
+	transportOwner *auctioneerClient
+	// backendConns holds a slot for each connection in use to a backend
+	// capped by WithBackendMaxConns, by host.
+	backendConns map[string]chan struct{}
+	// backendTimeouts bounds each attempt to a backend set by
+	// WithBackendTimeouts, by host.
+	backendTimeouts map[string]time.Duration
+	// connAcquireTimeout bounds each wait for a connection; see
+	// WithConnectionAcquireTimeout.
+	connAcquireTimeout time.Duration
+	// requests tracks the requests in flight for Shutdown and Close.
+	requests requestTracker
+	// tlsWatcher, when not nil, watches the TLS files for
+	// WithTLSFileWatch until Shutdown or Close.
+	tlsWatcher     *fsnotify.Watcher
+	tlsWatchLogger lager.Logger
+}
+
+// NewClient returns a client for auctioneerURL without TLS material. It
+// cannot fail, so an https URL that needs client certificates is only
+// rejected by the first request; NewClientFromConfig rejects it up front
+// with ErrTLSRequiredButNotConfigured.
+func NewClient(auctioneerURL string, opts ...ClientOption) ExtendedClient {
+	auctioneerURL = normalizeURL(auctioneerURL)
+	client := newDefaultClient(auctioneerURL)
+	client.httpClient = cfhttp.NewClient()
+	client.applyOptions(opts)
+	client.reqGen = rata.NewRequestGenerator(auctioneerURL, client.routes)
+	client.configureTransport(client.httpClient)
+
+	return client
+}
+
+func NewSecureClient(auctioneerURL, caFile, certFile, keyFile string, requireTLS bool, opts ...ClientOption) (ExtendedClient, error) {
+	if requireTLS && caFile == "" && certFile == "" && keyFile == "" {
+		return nil, ErrTLSRequiredButNotConfigured
+	}
+
+	// the TLS material is loaded once the options are applied, so that it
+	// is validated against the client's clock
+	client := newSecureClient(auctioneerURL, cfhttp.NewClient(), requireTLS, opts)
+	client.caFile = caFile
+	client.certFile = certFile
+	client.keyFile = keyFile
+
+	if err := client.loadTLS(); err != nil {
+		return nil, err
+	}
+	if err := client.watchTLSFiles(); err != nil {
+		return nil, err
+	}
+
+	return client, nil
 }
 
-func NewClient(auctioneerURL string) Client {
+// NewSecureClientWithTLSConfig is like NewSecureClient, but uses tlsConfig,
+// built elsewhere, instead of loading TLS files through cfhttp. tlsConfig is
+// cloned, so later changes to it do not affect the client. When requireTLS
+// is set, tlsConfig must provide a client certificate through Certificates
+// or GetClientCertificate. ReloadTLS returns ErrTLSNotConfigured for such a
+// client; rotate credentials with GetClientCertificate instead. A
+// ClientSessionCache set on tlsConfig takes precedence over
+// WithTLSSessionCacheSize and WithTLSSessionCache.
+func NewSecureClientWithTLSConfig(auctioneerURL string, tlsConfig *tls.Config, requireTLS bool, opts ...ClientOption) (ExtendedClient, error) {
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	if requireTLS && len(tlsConfig.Certificates) == 0 && tlsConfig.GetClientCertificate == nil {
+		return nil, ErrTLSConfigMissingCertificate
+	}
+
+	httpClient := cfhttp.NewClient()
+	tr, ok := httpClient.Transport.(*http.Transport)
+	if !ok {
+		return nil, errors.New("Invalid transport")
+	}
+	tr.TLSClientConfig = tlsConfig.Clone()
+
+	return newSecureClient(auctioneerURL, httpClient, requireTLS, opts), nil
+}
+
+func newSecureClient(auctioneerURL string, httpClient *http.Client, requireTLS bool, opts []ClientOption) *auctioneerClient {
+	auctioneerURL = normalizeURL(auctioneerURL)
+	client := newDefaultClient(auctioneerURL)
+	client.httpClient = httpClient
+	client.insecureHTTPClient = cfhttp.NewClient()
+	client.requireTLS = requireTLS
+	client.applyOptions(opts)
+	client.reqGen = rata.NewRequestGenerator(auctioneerURL, client.routes)
+	client.configureTransport(client.httpClient)
+	client.configureTransport(client.insecureHTTPClient)
+	client.warnOnInsecureFallback(auctioneerURL)
+
+	return client
+}
+
+// newDefaultClient returns a client for auctioneerURL with every option at
+// its default, for a constructor to give its HTTP clients and apply options
+// to.
+func newDefaultClient(auctioneerURL string) *auctioneerClient {
 	return &auctioneerClient{
-		httpClient: cfhttp.NewClient(),
-		url:        auctioneerURL,
+		url:                    auctioneerURL,
+		routes:                 Routes,
+		metrics:                noopMetricsHook{},
+		bufferPool:             defaultBufferPool,
+		contentType:            jsonContentType,
+		maxResponseHeaderBytes: DefaultMaxResponseHeaderBytes,
+		tlsSessionCacheSize:    DefaultTLSSessionCacheSize,
+		stats:                  &clientStats{},
+		capabilities:           &capabilitiesCache{ttl: DefaultCapabilitiesTTL},
+		isolationSegmentHeader: IsolationSegmentHeader,
+		clock:                  clock.NewClock(),
+	}
+}
+
+func (c *auctioneerClient) applyOptions(opts []ClientOption) {
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.rateLimitQPS > 0 {
+		c.rateLimiter = newRateLimiter(c.rateLimitQPS, c.rateLimitBurst, c.operationWeights)
 	}
 }
 
-func NewSecureClient(auctioneerURL, caFile, certFile, keyFile string, requireTLS bool) (Client, error) {
-	insecureHTTPClient := cfhttp.NewClient()
+// configureTransport applies the transport-level options to an HTTP client
+// built by cfhttp.
+func (c *auctioneerClient) configureTransport(httpClient *http.Client) {
+	if c.roundTripper != nil {
+		httpClient.Transport = c.roundTripper
+		return
+	}
+
+	tr, ok := httpClient.Transport.(*http.Transport)
+	if !ok {
+		return
+	}
+
+	c.configureDialer(tr)
+
+	if c.responseHeaderTimeout > 0 {
+		tr.ResponseHeaderTimeout = c.responseHeaderTimeout
+	}
+	tr.MaxResponseHeaderBytes = c.maxResponseHeaderBytes
+	if c.disableCompression {
+		tr.DisableCompression = true
+	}
+
+	if tr.TLSClientConfig != nil && tr.TLSClientConfig.ClientSessionCache == nil {
+		tr.TLSClientConfig.ClientSessionCache = c.newTLSSessionCache()
+	}
+
+	if tr.TLSClientConfig != nil && len(c.serverNames) > 0 {
+		tr.TLSClientConfig.InsecureSkipVerify = true
+		tr.TLSClientConfig.VerifyPeerCertificate = verifyPeerCertificateForNames(tr.TLSClientConfig.RootCAs, c.serverNames)
+	}
+
+	if tr.TLSClientConfig != nil && c.ocspStapling {
+		tr.TLSClientConfig.VerifyConnection = chainConnectionVerification(tr.TLSClientConfig.VerifyConnection, c.verifyOCSPStaple)
+	}
+
+	if tr.TLSClientConfig != nil && c.crl != nil {
+		tr.TLSClientConfig.VerifyConnection = chainConnectionVerification(tr.TLSClientConfig.VerifyConnection, c.verifyCRL)
+	}
+
+	for _, configure := range c.transportConfigs {
+		configure(tr)
+	}
+}
+
+func newTLSHTTPClient(caFile, certFile, keyFile string, now time.Time) (*http.Client, error) {
+	err := validateTLSMaterial(caFile, certFile, keyFile, now)
+	if err != nil {
+		return nil, err
+	}
+
 	httpClient := cfhttp.NewClient()
 
 	tlsConfig, err := cfhttp.NewTLSConfig(certFile, keyFile, caFile)
@@ -47,81 +350,542 @@ func NewSecureClient(auctioneerURL, caFile, certFile, keyFile string, requireTLS
 		return nil, errors.New("Invalid transport")
 	}
 
-	return &auctioneerClient{
-		httpClient:         httpClient,
-		insecureHTTPClient: insecureHTTPClient,
-		url:                auctioneerURL,
-		requireTLS:         requireTLS,
-	}, nil
+	return httpClient, nil
 }
 
-func (c *auctioneerClient) RequestLRPAuctions(logger lager.Logger, lrpStarts []*LRPStartRequest) error {
-	logger = logger.Session("request-lrp-auctions")
+// normalizeURL strips trailing slashes from auctioneerURL, since request
+// paths are appended to it: http://auctioneer:9016/ would otherwise yield
+// //v1/lrps, which the auctioneer does not route.
+func normalizeURL(auctioneerURL string) string {
+	return strings.TrimRight(auctioneerURL, "/")
+}
+
+// SetURL changes the auctioneer URL used by subsequent requests.
+func (c *auctioneerClient) SetURL(auctioneerURL string) {
+	auctioneerURL = normalizeURL(auctioneerURL)
+	c.warnOnInsecureFallback(auctioneerURL)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
 
-	reqGen := rata.NewRequestGenerator(c.url, Routes)
-	payload, err := json.Marshal(lrpStarts)
+	c.url = auctioneerURL
+	c.reqGen = rata.NewRequestGenerator(auctioneerURL, c.routes)
+}
+
+// ReloadTLS re-reads the CA, certificate, and key files the client was
+// constructed with and uses them for subsequent requests. Requests already
+// in flight complete with the previous configuration.
+func (c *auctioneerClient) ReloadTLS() error {
+	if c.transportOwner != nil {
+		return c.transportOwner.ReloadTLS()
+	}
+
+	if c.certFile == "" {
+		return ErrTLSNotConfigured
+	}
+
+	return c.loadTLS()
+}
+
+// loadTLS reads the client's CA, certificate, and key files and replaces
+// its HTTP client with one using them.
+func (c *auctioneerClient) loadTLS() error {
+	httpClient, err := newTLSHTTPClient(c.caFile, c.certFile, c.keyFile, c.clock.Now())
 	if err != nil {
 		return err
 	}
+	c.configureTransport(httpClient)
 
-	req, err := reqGen.CreateRequest(CreateLRPAuctionsRoute, rata.Params{}, bytes.NewBuffer(payload))
-	if err != nil {
+	c.lock.Lock()
+	previous := c.httpClient
+	c.httpClient = httpClient
+	c.lock.Unlock()
+
+	if tr, ok := previous.Transport.(*http.Transport); ok {
+		tr.CloseIdleConnections()
+	}
+
+	return nil
+}
+
+// requestContext returns ctx, or context.Background() when a caller passed
+// a nil context, which would otherwise panic deep inside the request.
+func requestContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+// requestLogger returns the logger for a request made with logger and ctx.
+// A nil logger, which would panic on first use, is replaced with the
+// context's logger, which discards everything unless one was set.
+func (c *auctioneerClient) requestLogger(logger lager.Logger, ctx context.Context) lager.Logger {
+	if logger == nil {
+		return lagerctx.FromContext(ctx)
+	}
+
+	if !c.useContextLogger {
+		return logger
+	}
+
+	ctxLogger := lagerctx.FromContext(ctx)
+	if reflect.TypeOf(ctxLogger) == discardLoggerType {
+		return logger
+	}
+
+	return ctxLogger
+}
+
+func (c *auctioneerClient) currentURL() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.url
+}
+
+func (c *auctioneerClient) currentRequestGenerator() *rata.RequestGenerator {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.reqGen
+}
+
+func (c *auctioneerClient) currentHTTPClients() (*http.Client, *http.Client) {
+	if c.transportOwner != nil {
+		return c.transportOwner.currentHTTPClients()
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.httpClient, c.insecureHTTPClient
+}
+
+func (c *auctioneerClient) RequestLRPAuctions(logger lager.Logger, lrpStarts []*LRPStartRequest) error {
+	_, err := c.RequestLRPAuctionsWithResult(logger, context.Background(), lrpStarts)
+	return err
+}
+
+func (c *auctioneerClient) RequestTaskAuctions(logger lager.Logger, tasks []*TaskStartRequest) error {
+	_, err := c.RequestTaskAuctionsWithResult(logger, context.Background(), tasks)
+	return err
+}
+
+func (c *auctioneerClient) RequestLRPAuctionsWithResult(logger lager.Logger, ctx context.Context, lrpStarts []*LRPStartRequest) (AuctionResult, error) {
+	ctx = requestContext(ctx)
+	logger = c.requestLogger(logger, ctx).Session("request-lrp-auctions")
+	lrpStarts = c.transformLRPs(lrpStarts)
+	if len(c.shardBackends) > 0 {
+		return c.requestShardedLRPAuctions(logger, ctx, lrpStarts)
+	}
+
+	return c.requestAuctions(logger, ctx, auctionRequest{
+		operation: OperationLRP,
+		route:     CreateLRPAuctionsRoute,
+		auctions:  lrpStarts,
+		count:     len(lrpStarts),
+	})
+}
+
+// RequestLRPAuctionsRaw submits an LRP auction batch that the caller has
+// already marshaled to JSON, read from body, without decoding and
+// marshaling it again. It is sent like any other batch, with the client's
+// content type, headers, tracing, retries and status handling. A client
+// configured with WithSharding must decode the batch to split it, so it
+// does.
+func (c *auctioneerClient) RequestLRPAuctionsRaw(logger lager.Logger, ctx context.Context, body io.Reader) error {
+	ctx = requestContext(ctx)
+	logger = c.requestLogger(logger, ctx).Session("request-lrp-auctions-raw")
+	if len(c.shardBackends) > 0 {
+		lrpStarts := []*LRPStartRequest{}
+		err := json.NewDecoder(body).Decode(&lrpStarts)
+		if err != nil {
+			return fmt.Errorf("decoding lrp auctions to shard them: %w", err)
+		}
+		_, err = c.requestShardedLRPAuctions(logger, ctx, lrpStarts)
 		return err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	_, err := c.requestAuctions(logger, ctx, auctionRequest{
+		operation: OperationLRP,
+		route:     CreateLRPAuctionsRoute,
+		raw:       body,
+	})
+	return err
+}
+
+func (c *auctioneerClient) RequestTaskAuctionsWithResult(logger lager.Logger, ctx context.Context, tasks []*TaskStartRequest) (AuctionResult, error) {
+	ctx = requestContext(ctx)
+	logger = c.requestLogger(logger, ctx).Session("request-task-auctions")
+	tasks = c.transformTasks(tasks)
+	return c.requestAuctions(logger, ctx, auctionRequest{
+		operation: OperationTask,
+		route:     CreateTaskAuctionsRoute,
+		auctions:  tasks,
+		count:     len(tasks),
+	})
+}
+
+// auctionRequest describes a batch of auctions of a single kind.
+type auctionRequest struct {
+	operation string
+	route     string
+	auctions  interface{}
+	// raw, when not nil, is the batch already marshaled, sent in place of
+	// auctions.
+	raw    io.Reader
+	count  int
+	dryRun bool
+	// dryRunResult receives the validation outcome of a dry-run batch.
+	dryRunResult *DryRunResult
+	// response, when not nil, receives the final response to the batch.
+	response *capturedResponse
+}
+
+// spanOperation returns the operation the batch's span is named for.
+func (ar auctionRequest) spanOperation() string {
+	verb := "Request"
+	if ar.dryRun {
+		verb = "DryRun"
+	}
+	if ar.operation == OperationTask {
+		return verb + "TaskAuctions"
+	}
+	return verb + "LRPAuctions"
+}
 
-	resp, err := c.doRequest(logger, req)
+func (c *auctioneerClient) requestAuctions(logger lager.Logger, ctx context.Context, ar auctionRequest) (AuctionResult, error) {
+	if c.failover != nil && !hasTargetURL(ctx) {
+		return c.requestAuctionsWithFailover(logger, ctx, ar)
+	}
+
+	start := c.clock.Now()
+	var outcome requestOutcome
+	result, err := c.submitAuctions(logger, ctx, ar, &outcome)
+	logRequestSummary(logger, ar, outcome, err, c.clock.Since(start))
+	return result, err
+}
+
+func (c *auctioneerClient) submitAuctions(logger lager.Logger, ctx context.Context, ar auctionRequest, outcome *requestOutcome) (AuctionResult, error) {
+	// the post-success hook gets the caller's context, so that requests it
+	// makes are not traced as this submission's
+	hookCtx := ctx
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			outcome.remoteAddr = info.Conn.RemoteAddr().String()
+			outcome.connReused = info.Reused
+		},
+	})
+
+	if ar.response != nil {
+		// only the response from the last backend tried is returned
+		ar.response.resp = nil
+	}
+
+	resp, err := c.sendAuctions(logger, ctx, ar, outcome)
 	if err != nil {
-		return err
+		return AuctionResult{}, err
 	}
 	defer resp.Body.Close()
+	outcome.statusCode = resp.StatusCode
 
-	if resp.StatusCode != http.StatusAccepted {
-		return fmt.Errorf("http error: status code %d (%s)", resp.StatusCode, http.StatusText(resp.StatusCode))
+	if ar.response != nil {
+		resp, err = ar.response.capture(resp)
+		if err != nil {
+			return AuctionResult{}, err
+		}
 	}
 
-	return nil
+	if ar.dryRun {
+		*ar.dryRunResult, err = readDryRunResult(ar.operation, resp)
+		return AuctionResult{}, err
+	}
+
+	err = c.checkResponse(ar.operation, resp, c.isSuccessStatus)
+	if err != nil {
+		return AuctionResult{}, err
+	}
+
+	result := newAuctionResult(logger, resp, ar.count)
+	result.Retries = outcome.retries
+	result.UsedFallback = outcome.usedFallback
+	result.RemoteAddr = outcome.remoteAddr
+	result.ConnReused = outcome.connReused
+
+	if c.postSuccessHook != nil {
+		err = c.postSuccessHook(hookCtx, ar.operation, ar.count)
+		if err != nil {
+			return AuctionResult{}, &classifiedError{err: err}
+		}
+	}
+	return result, nil
 }
 
-func (c *auctioneerClient) RequestTaskAuctions(logger lager.Logger, tasks []*TaskStartRequest) error {
-	logger = logger.Session("request-task-auctions")
+// logRequestSummary logs, at debug level, one line describing a completed
+// auction submission. A raw batch's entries are not counted.
+func logRequestSummary(logger lager.Logger, ar auctionRequest, outcome requestOutcome, err error, duration time.Duration) {
+	data := lager.Data{
+		"operation":     ar.operation,
+		"payload-bytes": outcome.payloadBytes,
+		"status":        outcome.statusCode,
+		"attempts":      outcome.retries + 1,
+		"fallback":      outcome.usedFallback,
+		"duration":      duration.String(),
+	}
+	if ar.raw == nil {
+		data["entries"] = ar.count
+	}
+	if outcome.remoteAddr != "" {
+		data["remote-addr"] = outcome.remoteAddr
+		data["conn-reused"] = outcome.connReused
+	}
+	if err != nil {
+		data["error"] = err.Error()
+	}
+	logger.Debug("request-complete", data)
+}
 
-	reqGen := rata.NewRequestGenerator(c.url, Routes)
-	payload, err := json.Marshal(tasks)
+// sendAuctions submits the batch, recording any retries and fallback in
+// outcome, which may be nil.
+func (c *auctioneerClient) sendAuctions(logger lager.Logger, ctx context.Context, ar auctionRequest, outcome *requestOutcome) (*http.Response, error) {
+	if c.rateLimiter != nil {
+		err := c.rateLimiter.wait(ctx, ar.operation)
+		if err != nil {
+			return nil, wrapCanceled(ar.operation, err)
+		}
+	}
+
+	route, _ := c.routes.FindRouteByName(ar.route)
+	span := c.startSpan(ctx, route.Method, ar.spanOperation())
+
+	var req *http.Request
+	var err error
+	if c.shouldStream(logger, ctx, ar) {
+		req, err = c.newStreamingAuctionsRequest(ctx, ar)
+	} else {
+		var payload *payload
+		req, payload, err = c.newAuctionsRequest(logger, ctx, ar)
+		if err == nil {
+			defer payload.release()
+			c.observePayloadBytes(ar.operation, payload.Len())
+			if outcome != nil {
+				outcome.payloadBytes = payload.Len()
+			}
+		}
+	}
 	if err != nil {
-		return err
+		finishRequestSpan(span, nil, err)
+		return nil, wrapCanceled(ar.operation, err)
 	}
 
-	req, err := reqGen.CreateRequest(CreateTaskAuctionsRoute, rata.Params{}, bytes.NewBuffer(payload))
+	resp, err := c.doTracedRequest(logger, req, span, outcome)
 	if err != nil {
-		return err
+		return nil, wrapCanceled(ar.operation, err)
+	}
+	return resp, nil
+}
+
+// newAuctionsRequest marshals the batch into a pooled payload, which the
+// caller must release once the request is done.
+func (c *auctioneerClient) newAuctionsRequest(logger lager.Logger, ctx context.Context, ar auctionRequest) (*http.Request, *payload, error) {
+	var payload *payload
+	var err error
+	if ar.raw != nil {
+		payload, err = readPayload(c.bufferPool, ar.raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %s auctions: %w", ar.operation, err)
+		}
+	} else {
+		payload, err = c.marshalAuctions(ctx, ar)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling %s auctions (batch size %d): %w", ar.operation, ar.count, err)
+		}
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	if c.maxPayloadBytes > 0 && payload.Len() > c.maxPayloadBytes {
+		size := payload.Len()
+		payload.release()
+		return nil, nil, &PayloadTooLargeError{Operation: ar.operation, Size: size, Limit: c.maxPayloadBytes}
+	}
 
-	resp, err := c.doRequest(logger, req)
+	err = c.checkServerPayloadLimit(logger, ctx, ar.operation, payload.Len())
 	if err != nil {
-		return err
+		payload.release()
+		return nil, nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusAccepted {
-		return fmt.Errorf("http error: status code %d (%s)", resp.StatusCode, http.StatusText(resp.StatusCode))
+	compressed := c.shouldCompress(logger, ctx, payload.Len())
+	if compressed {
+		err = payload.compress()
+		if err != nil {
+			payload.release()
+			return nil, nil, fmt.Errorf("compressing %s auctions (batch size %d): %w", ar.operation, ar.count, err)
+		}
 	}
 
-	return nil
+	reqGen, err := c.requestGenerator(ctx)
+	if err != nil {
+		payload.release()
+		return nil, nil, err
+	}
+
+	body := payload.body()
+	req, err := reqGen.CreateRequest(ar.route, nil, body)
+	if err != nil {
+		body.Close()
+		payload.release()
+		return nil, nil, err
+	}
+	req = req.WithContext(ctx)
+	req.ContentLength = int64(payload.Len())
+	req.GetBody = func() (io.ReadCloser, error) {
+		return payload.body(), nil
+	}
+
+	if c.contentType != nil {
+		req.Header["Content-Type"] = c.contentType
+	}
+	if compressed {
+		req.Header["Content-Encoding"] = gzipContentEncoding
+	}
+	if ar.dryRun {
+		req.Header[DryRunHeader] = dryRunHeaderValue
+	}
+	c.setContentDigest(req, payload.Bytes())
+
+	if c.batchTrailers {
+		err = setBatchTrailers(req, payload.Bytes())
+		if err != nil {
+			body.Close()
+			payload.release()
+			return nil, nil, err
+		}
+	}
+
+	return req, payload, nil
+}
+
+// doRequest sends req, made for op (see WithSpanNamer).
+func (c *auctioneerClient) doRequest(logger lager.Logger, req *http.Request, op string) (*http.Response, error) {
+	return c.doTracedRequest(logger, req, c.startSpan(req.Context(), req.Method, op), nil)
+}
+
+// doTracedRequest sends req and finishes span, which may be nil, with the
+// outcome. Retries and fallback are recorded in outcome, if not nil.
+func (c *auctioneerClient) doTracedRequest(logger lager.Logger, req *http.Request, span opentracing.Span, outcome *requestOutcome) (*http.Response, error) {
+	parent := req.Context()
+	req, done, err := c.requests.begin(req)
+	if err != nil {
+		finishRequestSpan(span, nil, err)
+		return nil, err
+	}
+
+	c.setRequestHeaders(req)
+	c.injectSpan(logger, span, req)
+	c.logRequestWire(req)
+
+	resp, err := c.doRequestWithRetries(logger, req, span, outcome)
+	c.stats.recordRequest(resp, err)
+	if err != nil {
+		done()
+		err = c.classifyError(classifyRequestError(req, c.closedError(parent, err)))
+	} else {
+		resp.Body = &releasingBody{ReadCloser: resp.Body, release: done}
+		c.recordServerVersion(resp)
+		c.logResponseWire(resp)
+	}
+	finishRequestSpan(span, resp, err)
+
+	return resp, err
+}
+
+// requestOutcome records how a request was sent, for AuctionResult and the
+// request summary log.
+type requestOutcome struct {
+	retries      int
+	usedFallback bool
+	// payloadBytes is the size of the body sent, after any compression,
+	// and statusCode the status of the final response, if any.
+	payloadBytes int
+	statusCode   int
+	// remoteAddr is the address of the connection the last attempt was
+	// sent on, once one was obtained, and connReused whether that
+	// connection came from the pool.
+	remoteAddr string
+	connReused bool
 }
 
-func (c *auctioneerClient) doRequest(logger lager.Logger, req *http.Request) (*http.Response, error) {
-	resp, err := c.httpClient.Do(req)
+func (c *auctioneerClient) doRequestWithFallback(logger lager.Logger, req *http.Request, outcome *requestOutcome) (*http.Response, error) {
+	release, err := c.acquireBackendConn(req)
+	if err != nil {
+		return nil, err
+	}
+
+	parent := req.Context()
+	req, cancel := c.withBackendTimeout(req)
+	if cancel != nil {
+		release = cancelAndRelease(cancel, release)
+	}
+
+	resp, err := c.sendWithFallback(logger, req, outcome)
+	if release != nil {
+		if err != nil {
+			release()
+		} else {
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+		}
+	}
+	if err != nil && cancel != nil {
+		err = c.backendTimeoutError(parent, req, err)
+	}
+	return resp, err
+}
+
+func (c *auctioneerClient) sendWithFallback(logger lager.Logger, req *http.Request, outcome *requestOutcome) (*http.Response, error) {
+	httpClient, insecureHTTPClient := c.currentHTTPClients()
+
+	start := c.clock.Now()
+	resp, err := c.doWithAcquireTimeout(httpClient, req)
+	c.observeAttempt(start, false, err)
 	if err != nil {
 		// Fall back to HTTP and try again if we do not require TLS
-		if !c.requireTLS && c.insecureHTTPClient != nil {
-			logger.Error("retrying-on-http", err)
+		if !c.requireTLS && insecureHTTPClient != nil && !errors.Is(err, ErrConnectionAcquireTimeout) {
+			if !hasFallbackBudget(req.Context()) {
+				c.logError(logger, "skipping-http-fallback-near-deadline", err)
+				return resp, err
+			}
+
+			c.logError(logger, "retrying-on-http", err)
+			c.metrics.IncrementCounter(InsecureFallbackMetric, nil)
+			atomic.AddUint64(&c.stats.fallbacks, 1)
+			if outcome != nil {
+				outcome.usedFallback = true
+			}
 			req.URL.Scheme = "http"
-			return c.insecureHTTPClient.Do(req)
+			if req.GetBody != nil {
+				req.Body, err = req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+			}
+			start = c.clock.Now()
+			resp, err = c.doWithAcquireTimeout(insecureHTTPClient, req)
+			c.observeAttempt(start, true, err)
 		}
 	}
 	return resp, err
 }
+
+// minFallbackBudget is the least time that must remain before the request
+// deadline for the HTTP fallback to be worth attempting.
+const minFallbackBudget = 100 * time.Millisecond
+
+func hasFallbackBudget(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) >= minFallbackBudget
+}
@@ -0,0 +1,157 @@
+package auctioneer
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// ErrInvalidClientConfig is matched, with errors.Is, by the errors returned
+// for an invalid ClientConfig.
+var ErrInvalidClientConfig = errors.New("invalid auctioneer client config")
+
+// ClientConfig describes a Client for NewClientFromConfig. Zero values leave
+// the corresponding behavior at its default.
+type ClientConfig struct {
+	// URL is the auctioneer's base URL, for example
+	// https://auctioneer.service.cf.internal:9016. It is required.
+	URL string
+
+	// CACertFile, ClientCertFile and ClientKeyFile enable mutual TLS. They
+	// must be set together.
+	CACertFile     string
+	ClientCertFile string
+	ClientKeyFile  string
+	// RequireTLS disables the fallback to plain HTTP when a TLS request
+	// fails. It requires the TLS files.
+	RequireTLS bool
+	// ServerNames, when set, are the names the auctioneer's certificate is
+	// accepted for; see WithServerNames.
+	ServerNames []string
+
+	ResponseHeaderTimeout time.Duration
+
+	// MaxRetries and RetryAfterSend configure retries; see WithRetries and
+	// WithRetryAfterSend.
+	MaxRetries     int
+	RetryAfterSend bool
+
+	Tracer           opentracing.Tracer
+	MetricsHook      MetricsHook
+	UseContextLogger bool
+
+	// Options are applied after the options derived from the fields above,
+	// so they take precedence.
+	Options []ClientOption
+}
+
+// NewClientFromConfig validates cfg and returns the Client it describes: a
+// client built by NewSecureClient when TLS files are configured, and by
+// NewClient otherwise.
+func NewClientFromConfig(cfg ClientConfig) (ExtendedClient, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := cfg.options()
+
+	if !cfg.tlsConfigured() {
+		return NewClient(cfg.URL, opts...), nil
+	}
+
+	return NewSecureClient(cfg.URL, cfg.CACertFile, cfg.ClientCertFile, cfg.ClientKeyFile, cfg.RequireTLS, opts...)
+}
+
+// Validate reports the first problem that would prevent cfg from describing
+// a working client.
+func (cfg ClientConfig) Validate() error {
+	if cfg.URL == "" {
+		return invalidClientConfig("URL is required")
+	}
+
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return invalidClientConfig(fmt.Sprintf("URL %q: %s", cfg.URL, err))
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return invalidClientConfig(fmt.Sprintf("URL %q must use http or https", cfg.URL))
+	}
+	if u.Host == "" {
+		return invalidClientConfig(fmt.Sprintf("URL %q has no host", cfg.URL))
+	}
+
+	if cfg.tlsConfigured() {
+		missing := []string{}
+		if cfg.CACertFile == "" {
+			missing = append(missing, "CACertFile")
+		}
+		if cfg.ClientCertFile == "" {
+			missing = append(missing, "ClientCertFile")
+		}
+		if cfg.ClientKeyFile == "" {
+			missing = append(missing, "ClientKeyFile")
+		}
+		if len(missing) > 0 {
+			return invalidClientConfig("TLS is partially configured: missing " + strings.Join(missing, ", "))
+		}
+	} else if cfg.RequireTLS {
+		return tlsRequiredButNotConfigured("RequireTLS is set but no TLS files are configured")
+	} else if u.Scheme == "https" {
+		return tlsRequiredButNotConfigured(fmt.Sprintf("URL %q uses https but no TLS files are configured", cfg.URL))
+	}
+
+	if cfg.ResponseHeaderTimeout < 0 {
+		return invalidClientConfig("ResponseHeaderTimeout must not be negative")
+	}
+
+	if cfg.MaxRetries < 0 {
+		return invalidClientConfig("MaxRetries must not be negative")
+	}
+
+	return nil
+}
+
+func (cfg ClientConfig) tlsConfigured() bool {
+	return cfg.CACertFile != "" || cfg.ClientCertFile != "" || cfg.ClientKeyFile != ""
+}
+
+func (cfg ClientConfig) options() []ClientOption {
+	opts := []ClientOption{}
+
+	if len(cfg.ServerNames) > 0 {
+		opts = append(opts, WithServerNames(cfg.ServerNames...))
+	}
+	if cfg.ResponseHeaderTimeout > 0 {
+		opts = append(opts, WithResponseHeaderTimeout(cfg.ResponseHeaderTimeout))
+	}
+	if cfg.MaxRetries > 0 {
+		opts = append(opts, WithRetries(cfg.MaxRetries))
+	}
+	if cfg.RetryAfterSend {
+		opts = append(opts, WithRetryAfterSend())
+	}
+	if cfg.Tracer != nil {
+		opts = append(opts, WithTracer(cfg.Tracer))
+	}
+	if cfg.MetricsHook != nil {
+		opts = append(opts, WithMetricsHook(cfg.MetricsHook))
+	}
+	if cfg.UseContextLogger {
+		opts = append(opts, WithContextLogger())
+	}
+
+	return append(opts, cfg.Options...)
+}
+
+func invalidClientConfig(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidClientConfig, reason)
+}
+
+func tlsRequiredButNotConfigured(reason string) error {
+	return fmt.Errorf("%w: %w: %s", ErrInvalidClientConfig, ErrTLSRequiredButNotConfigured, reason)
+}
@@ -0,0 +1,164 @@
+package auctioneer_test
+
+import (
+	"errors"
+	"net/http"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/opentracing/opentracing-go/mocktracer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("NewClientFromConfig", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		cfg        ClientConfig
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		cfg = ClientConfig{URL: fakeServer.URL()}
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("returns a client for the URL", func() {
+		fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+
+		client, err := NewClientFromConfig(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+	})
+
+	It("applies the configured options", func() {
+		fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+		tracer := mocktracer.New()
+		configured := false
+		cfg.Tracer = tracer
+		cfg.Options = []ClientOption{WithTransportConfig(func(*http.Transport) {
+			configured = true
+		})}
+
+		client, err := NewClientFromConfig(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(tracer.FinishedSpans()).To(HaveLen(1))
+		Expect(configured).To(BeTrue())
+	})
+
+	Context("with TLS files", func() {
+		BeforeEach(func() {
+			cfg.CACertFile = blueCerts.CAFile
+			cfg.ClientCertFile = blueCerts.ClientCertFile
+			cfg.ClientKeyFile = blueCerts.ClientKeyFile
+		})
+
+		It("returns a secure client", func() {
+			client, err := NewClientFromConfig(cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.ReloadTLS()).To(Succeed())
+		})
+
+		It("returns the error loading the TLS files", func() {
+			cfg.ClientKeyFile = greenCerts.ClientKeyFile
+
+			_, err := NewClientFromConfig(cfg)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("validation", func() {
+		var err error
+
+		JustBeforeEach(func() {
+			_, err = NewClientFromConfig(cfg)
+		})
+
+		Context("when the URL is missing", func() {
+			BeforeEach(func() {
+				cfg.URL = ""
+			})
+
+			It("returns an error", func() {
+				Expect(errors.Is(err, ErrInvalidClientConfig)).To(BeTrue())
+				Expect(err).To(MatchError(ContainSubstring("URL is required")))
+			})
+		})
+
+		Context("when the URL is not HTTP", func() {
+			BeforeEach(func() {
+				cfg.URL = "ftp://auctioneer"
+			})
+
+			It("returns an error", func() {
+				Expect(errors.Is(err, ErrInvalidClientConfig)).To(BeTrue())
+				Expect(err).To(MatchError(ContainSubstring("must use http or https")))
+			})
+		})
+
+		Context("when the URL has no host", func() {
+			BeforeEach(func() {
+				cfg.URL = "http://"
+			})
+
+			It("returns an error", func() {
+				Expect(errors.Is(err, ErrInvalidClientConfig)).To(BeTrue())
+				Expect(err).To(MatchError(ContainSubstring("has no host")))
+			})
+		})
+
+		Context("when TLS is partially configured", func() {
+			BeforeEach(func() {
+				cfg.CACertFile = "ca.crt"
+			})
+
+			It("names the missing files", func() {
+				Expect(errors.Is(err, ErrInvalidClientConfig)).To(BeTrue())
+				Expect(err).To(MatchError(ContainSubstring("missing ClientCertFile, ClientKeyFile")))
+			})
+		})
+
+		Context("when TLS is required but not configured", func() {
+			BeforeEach(func() {
+				cfg.RequireTLS = true
+			})
+
+			It("returns an error", func() {
+				Expect(errors.Is(err, ErrInvalidClientConfig)).To(BeTrue())
+				Expect(errors.Is(err, ErrTLSRequiredButNotConfigured)).To(BeTrue())
+				Expect(err).To(MatchError(ContainSubstring("no TLS files")))
+			})
+		})
+
+		Context("when the URL uses https but TLS is not configured", func() {
+			BeforeEach(func() {
+				cfg.URL = "https://auctioneer.service.cf.internal:9016"
+			})
+
+			It("returns an error", func() {
+				Expect(errors.Is(err, ErrInvalidClientConfig)).To(BeTrue())
+				Expect(errors.Is(err, ErrTLSRequiredButNotConfigured)).To(BeTrue())
+				Expect(err).To(MatchError(ContainSubstring("uses https")))
+			})
+		})
+
+		Context("when MaxRetries is negative", func() {
+			BeforeEach(func() {
+				cfg.MaxRetries = -1
+			})
+
+			It("returns an error", func() {
+				Expect(errors.Is(err, ErrInvalidClientConfig)).To(BeTrue())
+				Expect(err).To(MatchError(ContainSubstring("MaxRetries")))
+			})
+		})
+	})
+})
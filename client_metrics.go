@@ -0,0 +1,94 @@
+package auctioneer
+
+import "time"
+
+// Metric names reported to a MetricsHook.
+const (
+	InsecureFallbackMetric = "insecure_fallback_total"
+	RetryMetric            = "request_retries_total"
+
+	// RequestAttemptDurationMetric times each attempt to send a request,
+	// labeled with AttemptLabel and ResultLabel, so that a failed TLS attempt
+	// and the plain HTTP fallback that follows are reported separately.
+	RequestAttemptDurationMetric = "request_attempt_duration"
+
+	// PayloadBytesMetric is the size in bytes of each auction batch
+	// submitted, as sent after any compression, labeled with
+	// OperationLabel. Batches streamed with WithNDJSONStreaming, whose size
+	// is not known up front, are not reported.
+	PayloadBytesMetric = "request_payload_bytes"
+)
+
+// Labels of RequestAttemptDurationMetric.
+const (
+	AttemptLabel    = "attempt"
+	AttemptPrimary  = "primary_attempt"
+	AttemptFallback = "fallback_attempt"
+
+	ResultLabel   = "result"
+	ResultSuccess = "success"
+	ResultError   = "error"
+)
+
+// OperationLabel of PayloadBytesMetric is OperationLRP or OperationTask.
+const OperationLabel = "operation"
+
+// The attempt labels are shared by every request; hooks must not modify them.
+var (
+	primarySuccessLabels  = map[string]string{AttemptLabel: AttemptPrimary, ResultLabel: ResultSuccess}
+	primaryErrorLabels    = map[string]string{AttemptLabel: AttemptPrimary, ResultLabel: ResultError}
+	fallbackSuccessLabels = map[string]string{AttemptLabel: AttemptFallback, ResultLabel: ResultSuccess}
+	fallbackErrorLabels   = map[string]string{AttemptLabel: AttemptFallback, ResultLabel: ResultError}
+
+	operationLabels = map[string]map[string]string{
+		OperationLRP:  {OperationLabel: OperationLRP},
+		OperationTask: {OperationLabel: OperationTask},
+	}
+)
+
+// MetricsHook receives the Client's request metrics. Labels may be nil and
+// must not be modified. Implementations must be safe for concurrent use.
+//
+//go:generate counterfeiter -o auctioneerfakes/fake_metrics_hook.go . MetricsHook
+type MetricsHook interface {
+	IncrementCounter(name string, labels map[string]string)
+	ObserveDuration(name string, duration time.Duration, labels map[string]string)
+	// ObserveValue records one sample of a distribution, such as
+	// PayloadBytesMetric, to be reported as a histogram.
+	ObserveValue(name string, value float64, labels map[string]string)
+}
+
+type noopMetricsHook struct{}
+
+func (noopMetricsHook) IncrementCounter(string, map[string]string) {}
+
+func (noopMetricsHook) ObserveDuration(string, time.Duration, map[string]string) {}
+
+func (noopMetricsHook) ObserveValue(string, float64, map[string]string) {}
+
+func (c *auctioneerClient) observeAttempt(start time.Time, fallback bool, err error) {
+	labels := primarySuccessLabels
+	switch {
+	case fallback && err != nil:
+		labels = fallbackErrorLabels
+	case fallback:
+		labels = fallbackSuccessLabels
+	case err != nil:
+		labels = primaryErrorLabels
+	}
+
+	c.metrics.ObserveDuration(RequestAttemptDurationMetric, c.clock.Since(start), labels)
+}
+
+// WithMetricsHook reports the client's request metrics to hook.
+func WithMetricsHook(hook MetricsHook) ClientOption {
+	return func(c *auctioneerClient) {
+		if hook != nil {
+			c.metrics = hook
+		}
+	}
+}
+
+func (c *auctioneerClient) observePayloadBytes(operation string, size int) {
+	c.metrics.ObserveValue(PayloadBytesMetric, float64(size), operationLabels[operation])
+}
@@ -0,0 +1,159 @@
+package auctioneer
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/tedsuo/rata"
+)
+
+// ClientOption configures optional behavior of the Client returned by
+// NewClient and NewSecureClient.
+type ClientOption func(*auctioneerClient)
+
+// WithContextLogger makes request methods log through the lager.Logger
+// stored in the request context with lagerctx.NewContext, so session data
+// stamped upstream appears in the client's logs. The logger passed to the
+// request method is used when the context carries no logger.
+func WithContextLogger() ClientOption {
+	return func(c *auctioneerClient) {
+		c.useContextLogger = true
+	}
+}
+
+// WithResponseHeaderTimeout bounds how long the client waits for the
+// auctioneer's response headers after the request has been fully written,
+// independently of the overall request timeout. This fails fast when the
+// auctioneer accepts a request and then goes silent.
+func WithResponseHeaderTimeout(timeout time.Duration) ClientOption {
+	return func(c *auctioneerClient) {
+		c.responseHeaderTimeout = timeout
+	}
+}
+
+// DefaultMaxResponseHeaderBytes bounds the size of the auctioneer's response
+// headers unless WithMaxResponseHeaderBytes says otherwise.
+const DefaultMaxResponseHeaderBytes = 1 << 20
+
+// WithMaxResponseHeaderBytes fails a request whose response headers exceed
+// maxBytes, so that a misbehaving proxy cannot make the client buffer
+// arbitrarily large headers. A non-positive value restores the default.
+func WithMaxResponseHeaderBytes(maxBytes int64) ClientOption {
+	return func(c *auctioneerClient) {
+		if maxBytes <= 0 {
+			maxBytes = DefaultMaxResponseHeaderBytes
+		}
+		c.maxResponseHeaderBytes = maxBytes
+	}
+}
+
+// WithClock makes the client measure and wait with clock, so that tests can
+// drive retry and batch poll backoff, cache expiry and log throttling with a
+// fake clock. Context deadlines and WithRateLimit still follow real time.
+func WithClock(clock clock.Clock) ClientOption {
+	return func(c *auctioneerClient) {
+		if clock != nil {
+			c.clock = clock
+		}
+	}
+}
+
+// WithSuccessStatusCodes makes an auction request succeed when the
+// auctioneer responds with any of codes, instead of with any 2xx status, for
+// deployments behind proxies that rewrite the status to something else. It
+// takes precedence over WithStrictStatus. Calling it with no codes restores
+// the default.
+func WithSuccessStatusCodes(codes ...int) ClientOption {
+	return func(c *auctioneerClient) {
+		c.successStatusCodes = codes
+	}
+}
+
+// WithStrictStatus makes an auction request succeed only when the
+// auctioneer responds with 202 Accepted.
+//
+// By default any 2xx status is a success, since ingress controllers and
+// other proxies in front of the auctioneer commonly rewrite its 202 to 200
+// or 204. Earlier versions of this client accepted only 202. The auctioneer
+// answers 202 only once it has accepted a batch, while a proxy or another
+// service can answer 200 for a request that never reached it, so with the
+// default an auction can be reported as submitted when it was not. Use
+// WithStrictStatus where nothing between the client and the auctioneer
+// rewrites its status.
+func WithStrictStatus() ClientOption {
+	return func(c *auctioneerClient) {
+		c.strictStatus = true
+	}
+}
+
+func (c *auctioneerClient) isSuccessStatus(code int) bool {
+	if len(c.successStatusCodes) == 0 {
+		if c.strictStatus {
+			return code == http.StatusAccepted
+		}
+		return code >= 200 && code < 300
+	}
+
+	for _, successCode := range c.successStatusCodes {
+		if code == successCode {
+			return true
+		}
+	}
+	return false
+}
+
+// PostSuccessHook is called by a client configured with WithPostSuccessHook
+// after the auctioneer accepts a batch of submitted auctions for operation,
+// OperationLRP or OperationTask.
+type PostSuccessHook func(ctx context.Context, operation string, submitted int) error
+
+// WithPostSuccessHook calls hook after each accepted auction submission, so
+// that a verification step, such as confirming through another channel
+// that the batch was enqueued, can fail the call. submitted is the number of
+// entries sent, 0 for a batch submitted with RequestLRPAuctionsRaw, and
+// hook is called once per shard of a batch split by WithSharding. Dry runs
+// are not submissions and do not call it.
+//
+// When hook returns an error the call fails with an error that wraps it,
+// for errors.Is and errors.As, and that IsRetryable reports as not
+// retryable: the auctioneer has already accepted the batch, so it is never
+// retried or sent to another backend.
+func WithPostSuccessHook(hook PostSuccessHook) ClientOption {
+	return func(c *auctioneerClient) {
+		c.postSuccessHook = hook
+	}
+}
+
+// WithTransportConfig calls configure with each *http.Transport the client
+// builds, after the client's own options are applied, so any setting Go
+// supports can be tuned directly. It is called again for the transport built
+// by ReloadTLS.
+func WithTransportConfig(configure func(*http.Transport)) ClientOption {
+	return func(c *auctioneerClient) {
+		c.transportConfigs = append(c.transportConfigs, configure)
+	}
+}
+
+// WithRoundTripper sends every request through roundTripper instead of the
+// transport the client builds, for example to answer requests in tests
+// without a server; see the auctioneertest package. Transport options, TLS
+// files included, have no effect on it, and a failed request still falls
+// back to plain HTTP, through roundTripper, unless TLS is required.
+func WithRoundTripper(roundTripper http.RoundTripper) ClientOption {
+	return func(c *auctioneerClient) {
+		c.roundTripper = roundTripper
+	}
+}
+
+// WithRoutes generates requests from routes instead of Routes, for an
+// auctioneer that serves the auction endpoints at other paths. routes must
+// define CreateLRPAuctionsRoute and CreateTaskAuctionsRoute.
+func WithRoutes(routes rata.Routes) ClientOption {
+	return func(c *auctioneerClient) {
+		if routes != nil {
+			c.routes = routes
+		}
+	}
+}
@@ -1,21 +1,54 @@
 package auctioneer_test
 
 import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/auctioneer/auctioneerfakes"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagerctx"
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/tedsuo/rata"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/ghttp"
 )
 
 var _ = Describe("Auctioneer Client", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		client     ExtendedClient
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		client = NewClient(fakeServer.URL())
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
 	Describe("NewSecureClient", func() {
 		var caFile, certFile, keyFile, auctioneerURL string
 
 		BeforeEach(func() {
 			auctioneerURL = "http://jim.jim.jim"
-			caFile = "cmd/auctioneer/fixtures/blue-certs/ca.crt"
-			certFile = "cmd/auctioneer/fixtures/blue-certs/client.crt"
-			keyFile = "cmd/auctioneer/fixtures/blue-certs/client.key"
+			caFile = blueCerts.CAFile
+			certFile = blueCerts.ClientCertFile
+			keyFile = blueCerts.ClientKeyFile
 		})
 
 		It("works", func() {
@@ -23,16 +56,969 @@ var _ = Describe("Auctioneer Client", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		Context("when TLS is required but no TLS files are given", func() {
+			It("returns ErrTLSRequiredButNotConfigured", func() {
+				_, err := NewSecureClient(auctioneerURL, "", "", "", true)
+				Expect(err).To(MatchError(ErrTLSRequiredButNotConfigured))
+			})
+		})
+
 		Context("when the tls config is invalid", func() {
 			BeforeEach(func() {
-				certFile = "cmd/auctioneer/fixtures/green-certs/client.crt"
+				certFile = greenCerts.ClientCertFile
 			})
 
 			It("returns an error", func() {
 				_, err := NewSecureClient(auctioneerURL, caFile, certFile, keyFile, false)
 				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(MatchRegexp("failed to load keypair.*"))
+				Expect(errors.Is(err, ErrTLSCertKeyMismatch)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("RequestLRPAuctionsWithResult", func() {
+		var lrpStarts []*LRPStartRequest
+
+		BeforeEach(func() {
+			lrpStarts = []*LRPStartRequest{{ProcessGuid: "some-guid", Domain: "some-domain", Indices: []int{0}}}
+		})
+
+		Context("when the auctioneer returns a Location header", func() {
+			BeforeEach(func() {
+				fakeServer.AppendHandlers(ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/lrps"),
+					ghttp.RespondWith(http.StatusAccepted, "{}", http.Header{"Location": []string{"/v1/batches/some-batch"}}),
+				))
+			})
+
+			It("returns the absolute status location in the result", func() {
+				result, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), lrpStarts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Location).To(Equal(fakeServer.URL() + "/v1/batches/some-batch"))
+			})
+		})
+
+		Context("when the auctioneer does not return a Location header", func() {
+			BeforeEach(func() {
+				fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+			})
+
+			It("returns an empty location", func() {
+				result, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), lrpStarts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Location).To(BeEmpty())
+			})
+
+			It("returns the address of the auctioneer that served the batch", func() {
+				result, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), lrpStarts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RemoteAddr).To(Equal(fakeServer.Addr()))
+			})
+
+			It("reports whether the batch was sent on a reused connection", func() {
+				fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+
+				result, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), lrpStarts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.ConnReused).To(BeFalse())
+
+				result, err = client.RequestLRPAuctionsWithResult(logger, context.Background(), lrpStarts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.ConnReused).To(BeTrue())
+			})
+		})
+
+		Context("when the auctioneer responds with an empty body", func() {
+			BeforeEach(func() {
+				fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, ""))
+			})
+
+			It("assumes the whole batch was accepted", func() {
+				result, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), lrpStarts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Accepted).To(Equal(1))
+				Expect(result.Rejected).To(BeEmpty())
+			})
+		})
+
+		Context("when the auctioneer responds with a body without a result", func() {
+			BeforeEach(func() {
+				fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+			})
+
+			It("assumes the whole batch was accepted", func() {
+				result, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), lrpStarts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Accepted).To(Equal(1))
+			})
+		})
+
+		Context("when the auctioneer responds with a result", func() {
+			BeforeEach(func() {
+				lrpStarts = append(lrpStarts, &LRPStartRequest{ProcessGuid: "other-guid", Domain: "some-domain", Indices: []int{0}})
+				fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted,
+					`{"accepted":1,"rejected":[{"index":1,"error":"invalid","reason":"invalid_request"}]}`))
+			})
+
+			It("returns it", func() {
+				result, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), lrpStarts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Accepted).To(Equal(1))
+				Expect(result.Rejected).To(Equal([]DryRunRejection{
+					{Index: 1, Error: "invalid", Reason: RejectionReasonInvalidRequest},
+				}))
+			})
+		})
+
+		Context("when the auctioneer responds with a body that is not JSON", func() {
+			BeforeEach(func() {
+				fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "accepted"))
+			})
+
+			It("assumes the whole batch was accepted", func() {
+				result, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), lrpStarts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Accepted).To(Equal(1))
+			})
+		})
+
+		Context("when the auctioneer does not accept the batch", func() {
+			BeforeEach(func() {
+				fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, "{}"))
+			})
+
+			It("returns an error", func() {
+				_, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), lrpStarts)
+				Expect(err).To(MatchError("http error: status code 500 (Internal Server Error)"))
+			})
+		})
+	})
+
+	Describe("falling back to HTTP", func() {
+		var lrpStarts []*LRPStartRequest
+
+		BeforeEach(func() {
+			var err error
+			client, err = NewSecureClient(
+				strings.Replace(fakeServer.URL(), "http:", "https:", 1),
+				blueCerts.CAFile,
+				blueCerts.ClientCertFile,
+				blueCerts.ClientKeyFile,
+				false,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			lrpStarts = []*LRPStartRequest{{ProcessGuid: "some-guid", Domain: "some-domain", Indices: []int{0}}}
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v1/lrps"),
+				ghttp.VerifyJSONRepresenting(lrpStarts),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+		})
+
+		It("resends the full payload", func() {
+			Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		Context("when too little time remains before the deadline", func() {
+			It("returns the original error without falling back", func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+
+				_, err := client.RequestLRPAuctionsWithResult(logger, ctx, lrpStarts)
+				Expect(err).To(HaveOccurred())
+				Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+				Expect(logger).To(gbytes.Say("skipping-http-fallback-near-deadline"))
+			})
+		})
+	})
+
+	Describe("RequestTaskAuctionsWithResult", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v1/tasks"),
+				ghttp.RespondWith(http.StatusAccepted, "{}", http.Header{"Location": []string{"/v1/batches/some-batch"}}),
+			))
+		})
+
+		It("returns the absolute status location in the result", func() {
+			tasks := []*TaskStartRequest{{}}
+			result, err := client.RequestTaskAuctionsWithResult(logger, context.Background(), tasks)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Location).To(Equal(fakeServer.URL() + "/v1/batches/some-batch"))
+		})
+	})
+
+	Describe("RequestLRPAuctionsRaw", func() {
+		const body = `[{"process_guid":"some-guid","domain":"some-domain","indices":[0]}]`
+
+		It("sends the body as it is", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v1/lrps"),
+				ghttp.VerifyContentType("application/json"),
+				ghttp.VerifyBody([]byte(body)),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+
+			Expect(client.RequestLRPAuctionsRaw(logger, context.Background(), strings.NewReader(body))).To(Succeed())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("sends the configured content type", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyContentType("application/vnd.auctioneer+json"),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+
+			client = NewClient(fakeServer.URL(), WithContentType("application/vnd.auctioneer+json"))
+			Expect(client.RequestLRPAuctionsRaw(logger, context.Background(), strings.NewReader(body))).To(Succeed())
+		})
+
+		It("returns a StatusError for an unexpected status", func() {
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusBadRequest, "{}"))
+
+			err := client.RequestLRPAuctionsRaw(logger, context.Background(), strings.NewReader(body))
+			var statusErr *StatusError
+			Expect(errors.As(err, &statusErr)).To(BeTrue())
+			Expect(statusErr.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+
+		It("sends the whole body again on retry", func() {
+			fakeServer.AppendHandlers(
+				closeConnection,
+				ghttp.CombineHandlers(
+					ghttp.VerifyBody([]byte(body)),
+					ghttp.RespondWith(http.StatusAccepted, "{}"),
+				),
+			)
+
+			client = NewClient(fakeServer.URL(), WithRetries(1), WithRetryAfterSend())
+			Expect(client.RequestLRPAuctionsRaw(logger, context.Background(), strings.NewReader(body))).To(Succeed())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("enforces WithMaxPayloadBytes", func() {
+			client = NewClient(fakeServer.URL(), WithMaxPayloadBytes(len(body)-1))
+
+			err := client.RequestLRPAuctionsRaw(logger, context.Background(), strings.NewReader(body))
+			Expect(err).To(Equal(&PayloadTooLargeError{Operation: OperationLRP, Size: len(body), Limit: len(body) - 1}))
+			Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+		})
+
+		It("decodes the batch to shard it", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyJSONRepresenting([]*LRPStartRequest{{ProcessGuid: "some-guid", Domain: "some-domain", Indices: []int{0}}}),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+
+			client = NewClient("http://unused.example.com", WithSharding([]string{fakeServer.URL()}, nil))
+			Expect(client.RequestLRPAuctionsRaw(logger, context.Background(), strings.NewReader(body))).To(Succeed())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+
+	Describe("DryRunLRPAuctions", func() {
+		var lrpStarts []*LRPStartRequest
+
+		BeforeEach(func() {
+			lrpStarts = []*LRPStartRequest{{ProcessGuid: "some-guid", Domain: "some-domain", Indices: []int{0}}}
+		})
+
+		Context("when the auctioneer supports dry runs", func() {
+			BeforeEach(func() {
+				fakeServer.AppendHandlers(ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/lrps"),
+					ghttp.VerifyHeaderKV(DryRunHeader, "true"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, DryRunResult{
+						Rejected: []DryRunRejection{{Index: 0, Error: "placement constraint cannot be empty"}},
+					}),
+				))
+			})
+
+			It("returns the validation outcome", func() {
+				result, err := client.DryRunLRPAuctions(logger, context.Background(), lrpStarts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Valid).To(Equal(0))
+				Expect(result.Rejected).To(ConsistOf(DryRunRejection{Index: 0, Error: "placement constraint cannot be empty"}))
+			})
+		})
+
+		Context("when the auctioneer ignores the dry run header", func() {
+			BeforeEach(func() {
+				fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+			})
+
+			It("returns ErrDryRunNotSupported", func() {
+				_, err := client.DryRunLRPAuctions(logger, context.Background(), lrpStarts)
+				Expect(err).To(Equal(ErrDryRunNotSupported))
+			})
+		})
+
+		It("fails over like a submission", func() {
+			standby := ghttp.NewServer()
+			defer standby.Close()
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusServiceUnavailable, "{}"))
+			standby.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV(DryRunHeader, "true"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, DryRunResult{Valid: 1}),
+			))
+			client = NewClient("http://unused.example.com", WithFailover([]string{fakeServer.URL(), standby.URL()}))
+
+			result, err := client.DryRunLRPAuctions(logger, context.Background(), lrpStarts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Valid).To(Equal(1))
+			Expect(standby.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("splits the batch across shards like a submission", func() {
+			other := ghttp.NewServer()
+			defer other.Close()
+			rejectFirst := ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV(DryRunHeader, "true"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, DryRunResult{
+					Rejected: []DryRunRejection{{Index: 0, Error: "invalid"}},
+				}),
+			)
+			fakeServer.AppendHandlers(rejectFirst)
+			other.AppendHandlers(rejectFirst)
+			client = NewClient("http://unused.example.com", WithSharding([]string{fakeServer.URL(), other.URL()}, func(processGuid string, _ int) int {
+				if processGuid == "guid-b" {
+					return 1
+				}
+				return 0
+			}))
+
+			result, err := client.DryRunLRPAuctions(logger, context.Background(), []*LRPStartRequest{
+				{ProcessGuid: "guid-a"}, {ProcessGuid: "guid-b"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Rejected).To(Equal([]DryRunRejection{
+				{Index: 0, Error: "invalid"},
+				{Index: 1, Error: "invalid"},
+			}))
+		})
+	})
+
+	Describe("DryRunTaskAuctions", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v1/tasks"),
+				ghttp.VerifyHeaderKV(DryRunHeader, "true"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, DryRunResult{Valid: 1}),
+			))
+		})
+
+		It("returns the validation outcome", func() {
+			result, err := client.DryRunTaskAuctions(logger, context.Background(), []*TaskStartRequest{{}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Valid).To(Equal(1))
+		})
+	})
+
+	Describe("WithContextLogger", func() {
+		var ctxLogger *lagertest.TestLogger
+
+		BeforeEach(func() {
+			ctxLogger = lagertest.NewTestLogger("ctx")
+
+			var err error
+			client, err = NewSecureClient(
+				strings.Replace(fakeServer.URL(), "http:", "https:", 1),
+				blueCerts.CAFile,
+				blueCerts.ClientCertFile,
+				blueCerts.ClientKeyFile,
+				false,
+				WithContextLogger(),
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+		})
+
+		Context("when the context carries a logger", func() {
+			It("logs through the context logger", func() {
+				ctx := lagerctx.NewContext(context.Background(), ctxLogger)
+
+				_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ctxLogger.Buffer()).To(gbytes.Say("ctx.request-lrp-auctions.retrying-on-http"))
+				Expect(logger.LogMessages()).To(BeEmpty())
+			})
+		})
+
+		Context("when the context does not carry a logger", func() {
+			It("logs through the logger argument", func() {
+				_, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), []*LRPStartRequest{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(logger.Buffer()).To(gbytes.Say("test.request-lrp-auctions.retrying-on-http"))
+			})
+		})
+	})
+
+	Context("when the logger is nil", func() {
+		BeforeEach(func() {
+			var err error
+			client, err = NewSecureClient(
+				strings.Replace(fakeServer.URL(), "http:", "https:", 1),
+				blueCerts.CAFile,
+				blueCerts.ClientCertFile,
+				blueCerts.ClientKeyFile,
+				false,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+		})
+
+		It("sends the request without logging", func() {
+			Expect(client.RequestLRPAuctions(nil, []*LRPStartRequest{})).To(Succeed())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("logs through the context logger, if any", func() {
+			ctxLogger := lagertest.NewTestLogger("ctx")
+			ctx := lagerctx.NewContext(context.Background(), ctxLogger)
+
+			_, err := client.RequestTaskAuctionsWithResult(nil, ctx, []*TaskStartRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ctxLogger.Buffer()).To(gbytes.Say("ctx.request-task-auctions.retrying-on-http"))
+		})
+	})
+
+	Context("when the context is nil", func() {
+		var nilCtx context.Context
+
+		It("sends auction requests with a background context", func() {
+			fakeServer.AppendHandlers(
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			)
+
+			_, err := client.RequestLRPAuctionsWithResult(logger, nilCtx, []*LRPStartRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = client.RequestTaskAuctionsWithResult(logger, nilCtx, []*TaskStartRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("pings with a background context", func() {
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusOK, nil))
+
+			_, err := client.Ping(logger, nilCtx)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("the request summary", func() {
+		It("logs one line describing each submission", func() {
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+
+			lrpStarts := []*LRPStartRequest{{ProcessGuid: "some-guid", Domain: "some-domain", Indices: []int{0}}}
+			Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+
+			logs := logger.Logs()
+			Expect(logs).To(HaveLen(1))
+			Expect(logs[0].Message).To(Equal("test.request-lrp-auctions.request-complete"))
+			Expect(logs[0].LogLevel).To(Equal(lager.DEBUG))
+			Expect(logs[0].Data).To(HaveKeyWithValue("operation", OperationLRP))
+			Expect(logs[0].Data).To(HaveKeyWithValue("entries", BeNumerically("==", 1)))
+			Expect(logs[0].Data).To(HaveKeyWithValue("payload-bytes", BeNumerically(">", 0)))
+			Expect(logs[0].Data).To(HaveKeyWithValue("status", BeNumerically("==", http.StatusAccepted)))
+			Expect(logs[0].Data).To(HaveKeyWithValue("attempts", BeNumerically("==", 1)))
+			Expect(logs[0].Data).To(HaveKeyWithValue("fallback", false))
+			Expect(logs[0].Data).To(HaveKey("duration"))
+			Expect(logs[0].Data).To(HaveKeyWithValue("remote-addr", fakeServer.Addr()))
+			Expect(logs[0].Data).To(HaveKeyWithValue("conn-reused", false))
+			Expect(logs[0].Data).NotTo(HaveKey("error"))
+		})
+
+		It("includes the error of a failed submission", func() {
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, ""))
+
+			Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).NotTo(Succeed())
+
+			logs := logger.Logs()
+			Expect(logs).To(HaveLen(1))
+			Expect(logs[0].Message).To(Equal("test.request-task-auctions.request-complete"))
+			Expect(logs[0].Data).To(HaveKeyWithValue("status", BeNumerically("==", http.StatusInternalServerError)))
+			Expect(logs[0].Data).To(HaveKeyWithValue("error", ContainSubstring("500")))
+			Expect(logs[0].Data).To(HaveKeyWithValue("remote-addr", fakeServer.Addr()))
+		})
+
+		It("omits the remote address when no connection was made", func() {
+			client = NewClient("http://127.0.0.1:1")
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+
+			logs := logger.Logs()
+			Expect(logs).To(HaveLen(1))
+			Expect(logs[0].Data).NotTo(HaveKey("remote-addr"))
+			Expect(logs[0].Data).NotTo(HaveKey("conn-reused"))
+		})
+	})
+
+	Describe("WithPostSuccessHook", func() {
+		type hookCall struct {
+			operation string
+			submitted int
+		}
+
+		var (
+			calls   []hookCall
+			hookErr error
+		)
+
+		BeforeEach(func() {
+			calls = nil
+			hookErr = nil
+			client = NewClient(fakeServer.URL(), WithPostSuccessHook(func(ctx context.Context, operation string, submitted int) error {
+				Expect(ctx).NotTo(BeNil())
+				calls = append(calls, hookCall{operation: operation, submitted: submitted})
+				return hookErr
+			}))
+		})
+
+		It("is called after each accepted submission", func() {
+			fakeServer.AppendHandlers(
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			)
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{{ProcessGuid: "some-guid"}, {ProcessGuid: "other-guid"}})).To(Succeed())
+			Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{{}})).To(Succeed())
+
+			Expect(calls).To(Equal([]hookCall{
+				{operation: OperationLRP, submitted: 2},
+				{operation: OperationTask, submitted: 1},
+			}))
+		})
+
+		It("is not called when the submission fails", func() {
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, ""))
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+			Expect(calls).To(BeEmpty())
+		})
+
+		Context("when the hook fails", func() {
+			BeforeEach(func() {
+				hookErr = io.ErrUnexpectedEOF
+				fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+			})
+
+			It("fails the call with the hook's error", func() {
+				_, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), []*LRPStartRequest{})
+				Expect(errors.Is(err, io.ErrUnexpectedEOF)).To(BeTrue())
+				Expect(err).To(MatchError(io.ErrUnexpectedEOF.Error()))
+			})
+
+			It("does not report the error as retryable", func() {
+				err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+				Expect(IsRetryable(err)).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("WithMetricsHook", func() {
+		var metricsHook *auctioneerfakes.FakeMetricsHook
+
+		BeforeEach(func() {
+			metricsHook = &auctioneerfakes.FakeMetricsHook{}
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+		})
+
+		Context("when the request falls back to HTTP", func() {
+			BeforeEach(func() {
+				var err error
+				client, err = NewSecureClient(
+					strings.Replace(fakeServer.URL(), "http:", "https:", 1),
+					blueCerts.CAFile,
+					blueCerts.ClientCertFile,
+					blueCerts.ClientKeyFile,
+					false,
+					WithMetricsHook(metricsHook),
+				)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("reports the fallback in the result", func() {
+				result, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), []*LRPStartRequest{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.UsedFallback).To(BeTrue())
+				Expect(result.Retries).To(Equal(0))
+			})
+
+			It("increments the insecure fallback counter", func() {
+				Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+
+				Expect(metricsHook.IncrementCounterCallCount()).To(Equal(1))
+				name, _ := metricsHook.IncrementCounterArgsForCall(0)
+				Expect(name).To(Equal(InsecureFallbackMetric))
+			})
+
+			It("times the primary and fallback attempts separately", func() {
+				Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+
+				Expect(metricsHook.ObserveDurationCallCount()).To(Equal(2))
+
+				name, duration, labels := metricsHook.ObserveDurationArgsForCall(0)
+				Expect(name).To(Equal(RequestAttemptDurationMetric))
+				Expect(duration).To(BeNumerically(">", 0))
+				Expect(labels).To(Equal(map[string]string{AttemptLabel: AttemptPrimary, ResultLabel: ResultError}))
+
+				name, duration, labels = metricsHook.ObserveDurationArgsForCall(1)
+				Expect(name).To(Equal(RequestAttemptDurationMetric))
+				Expect(duration).To(BeNumerically(">", 0))
+				Expect(labels).To(Equal(map[string]string{AttemptLabel: AttemptFallback, ResultLabel: ResultSuccess}))
+			})
+		})
+
+		Context("when the request does not fall back", func() {
+			BeforeEach(func() {
+				client = NewClient(fakeServer.URL(), WithMetricsHook(metricsHook))
+			})
+
+			It("does not increment the insecure fallback counter", func() {
+				Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+				Expect(metricsHook.IncrementCounterCallCount()).To(Equal(0))
+			})
+
+			It("times only the primary attempt", func() {
+				Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+
+				Expect(metricsHook.ObserveDurationCallCount()).To(Equal(1))
+				_, _, labels := metricsHook.ObserveDurationArgsForCall(0)
+				Expect(labels).To(Equal(map[string]string{AttemptLabel: AttemptPrimary, ResultLabel: ResultSuccess}))
+			})
+
+			It("observes the size of each batch sent, by operation", func() {
+				var sizes []int64
+				fakeServer.SetHandler(0, func(w http.ResponseWriter, r *http.Request) {
+					sizes = append(sizes, r.ContentLength)
+					w.WriteHeader(http.StatusAccepted)
+				})
+				fakeServer.AppendHandlers(func(w http.ResponseWriter, r *http.Request) {
+					sizes = append(sizes, r.ContentLength)
+					w.WriteHeader(http.StatusAccepted)
+				})
+
+				Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{{ProcessGuid: "some-guid"}})).To(Succeed())
+				Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).To(Succeed())
+
+				Expect(metricsHook.ObserveValueCallCount()).To(Equal(2))
+				name, value, labels := metricsHook.ObserveValueArgsForCall(0)
+				Expect(name).To(Equal(PayloadBytesMetric))
+				Expect(value).To(BeNumerically("==", sizes[0]))
+				Expect(labels).To(Equal(map[string]string{OperationLabel: OperationLRP}))
+
+				name, value, labels = metricsHook.ObserveValueArgsForCall(1)
+				Expect(name).To(Equal(PayloadBytesMetric))
+				Expect(value).To(BeNumerically("==", sizes[1]))
+				Expect(labels).To(Equal(map[string]string{OperationLabel: OperationTask}))
+			})
+		})
+	})
+
+	Describe("WithResponseHeaderTimeout", func() {
+		BeforeEach(func() {
+			client = NewClient(fakeServer.URL(), WithResponseHeaderTimeout(50*time.Millisecond))
+		})
+
+		Context("when the auctioneer stalls before sending response headers", func() {
+			BeforeEach(func() {
+				fakeServer.AppendHandlers(ghttp.CombineHandlers(
+					func(http.ResponseWriter, *http.Request) {
+						time.Sleep(200 * time.Millisecond)
+					},
+					ghttp.RespondWith(http.StatusAccepted, "{}"),
+				))
+			})
+
+			It("fails fast", func() {
+				err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+				Expect(err).To(MatchError(ContainSubstring("timeout awaiting response headers")))
+			})
+		})
+
+		Context("when the auctioneer responds promptly", func() {
+			BeforeEach(func() {
+				fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+			})
+
+			It("succeeds", func() {
+				Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			})
+		})
+	})
+
+	Describe("WithMaxResponseHeaderBytes", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}", http.Header{
+				"X-Padding": []string{strings.Repeat("a", 4096)},
+			}))
+		})
+
+		Context("when the response headers exceed the limit", func() {
+			BeforeEach(func() {
+				client = NewClient(fakeServer.URL(), WithMaxResponseHeaderBytes(1024))
 			})
+
+			It("fails the request", func() {
+				err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+				Expect(err).To(MatchError(ContainSubstring("response headers exceeded 1024 bytes")))
+			})
+		})
+
+		Context("by default", func() {
+			It("accepts reasonably sized headers", func() {
+				Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			})
+		})
+	})
+
+	Describe("WithSuccessStatusCodes", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusCreated, "{}"))
+		})
+
+		It("accepts any 2xx by default", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		})
+
+		Context("when only 200 and 202 are accepted", func() {
+			BeforeEach(func() {
+				client = NewClient(fakeServer.URL(), WithSuccessStatusCodes(http.StatusOK, http.StatusAccepted), WithStrictStatus())
+				fakeServer.AppendHandlers(
+					ghttp.RespondWith(http.StatusOK, "{}"),
+					ghttp.RespondWith(http.StatusAccepted, "{}"),
+				)
+			})
+
+			It("treats only each configured status as a success", func() {
+				err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+				Expect(err).To(MatchError(ContainSubstring("status code 201")))
+				Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+				Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).To(Succeed())
+			})
+		})
+	})
+
+	Describe("WithStrictStatus", func() {
+		BeforeEach(func() {
+			client = NewClient(fakeServer.URL(), WithStrictStatus())
+			fakeServer.AppendHandlers(
+				ghttp.RespondWith(http.StatusOK, "{}"),
+				ghttp.RespondWith(http.StatusNoContent, nil),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			)
+		})
+
+		It("accepts only a 202", func() {
+			err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+			Expect(err).To(MatchError(ContainSubstring("status code 200")))
+			err = client.RequestTaskAuctions(logger, []*TaskStartRequest{})
+			Expect(err).To(MatchError(ContainSubstring("status code 204")))
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		})
+	})
+
+	Describe("WithTransportConfig", func() {
+		var dials int32
+
+		BeforeEach(func() {
+			dials = 0
+			client = NewClient(fakeServer.URL(), WithTransportConfig(func(tr *http.Transport) {
+				dialer := &net.Dialer{}
+				tr.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+					atomic.AddInt32(&dials, 1)
+					return dialer.DialContext(ctx, network, addr)
+				}
+			}))
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+		})
+
+		It("applies the configuration to the transport", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			Expect(atomic.LoadInt32(&dials)).To(BeEquivalentTo(1))
+		})
+	})
+
+	Describe("WithRoutes", func() {
+		BeforeEach(func() {
+			client = NewClient(fakeServer.URL(), WithRoutes(rata.Routes{
+				{Path: "/custom/tasks", Method: "POST", Name: CreateTaskAuctionsRoute},
+				{Path: "/custom/lrps", Method: "POST", Name: CreateLRPAuctionsRoute},
+			}))
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/custom/lrps"),
+					ghttp.RespondWith(http.StatusAccepted, "{}"),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/custom/tasks"),
+					ghttp.RespondWith(http.StatusAccepted, "{}"),
+				),
+			)
+		})
+
+		It("sends requests to the custom paths", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).To(Succeed())
+		})
+
+		It("keeps the custom paths after SetURL", func() {
+			client.SetURL(fakeServer.URL())
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		})
+	})
+
+	Describe("a URL with a trailing slash", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/lrps"),
+					ghttp.RespondWith(http.StatusAccepted, "{}"),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/tasks"),
+					ghttp.RespondWith(http.StatusAccepted, "{}"),
+				),
+			)
+		})
+
+		It("generates the same paths as the URL without one", func() {
+			client = NewClient(fakeServer.URL() + "/")
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).To(Succeed())
+		})
+
+		It("is normalized by SetURL", func() {
+			client.SetURL(fakeServer.URL() + "//")
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).To(Succeed())
+		})
+
+		It("is normalized for secure clients", func() {
+			var err error
+			client, err = NewSecureClient(
+				fakeServer.URL()+"/",
+				blueCerts.CAFile,
+				blueCerts.ClientCertFile,
+				blueCerts.ClientKeyFile,
+				false,
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).To(Succeed())
+		})
+
+		It("is normalized for target URLs", func() {
+			ctx := ContextWithTargetURL(context.Background(), fakeServer.URL()+"/")
+			_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = client.RequestTaskAuctionsWithResult(logger, ctx, []*TaskStartRequest{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("SetURL", func() {
+		var otherServer *ghttp.Server
+
+		BeforeEach(func() {
+			otherServer = ghttp.NewServer()
+			otherServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+		})
+
+		AfterEach(func() {
+			otherServer.Close()
+		})
+
+		It("sends subsequent requests to the new URL", func() {
+			client.SetURL(otherServer.URL())
+
+			err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+			Expect(otherServer.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+
+	Describe("ReloadTLS", func() {
+		Context("when the client was not configured with TLS", func() {
+			It("returns ErrTLSNotConfigured", func() {
+				Expect(client.ReloadTLS()).To(Equal(ErrTLSNotConfigured))
+			})
+		})
+
+		Context("when the client was configured with TLS", func() {
+			BeforeEach(func() {
+				var err error
+				client, err = NewSecureClient(
+					fakeServer.URL(),
+					blueCerts.CAFile,
+					blueCerts.ClientCertFile,
+					blueCerts.ClientKeyFile,
+					false,
+				)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("reloads the TLS material", func() {
+				Expect(client.ReloadTLS()).To(Succeed())
+			})
+		})
+	})
+
+	Describe("concurrent use", func() {
+		var otherServer *ghttp.Server
+
+		BeforeEach(func() {
+			var err error
+			client, err = NewSecureClient(
+				fakeServer.URL(),
+				blueCerts.CAFile,
+				blueCerts.ClientCertFile,
+				blueCerts.ClientKeyFile,
+				false,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			otherServer = ghttp.NewServer()
+			fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+			otherServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+		})
+
+		AfterEach(func() {
+			otherServer.Close()
+		})
+
+		// Run with -race to verify the client's locking.
+		It("is safe while the URL and TLS configuration change", func() {
+			urls := []string{fakeServer.URL(), otherServer.URL()}
+
+			wg := sync.WaitGroup{}
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+
+					for j := 0; j < 10; j++ {
+						switch i % 4 {
+						case 0:
+							client.SetURL(urls[j%2])
+						case 1:
+							Expect(client.ReloadTLS()).To(Succeed())
+						default:
+							Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+						}
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			Expect(len(fakeServer.ReceivedRequests()) + len(otherServer.ReceivedRequests())).To(Equal(100))
 		})
 	})
 })
@@ -0,0 +1,74 @@
+package clientmetricemitter
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/runtimeschema/metric"
+	"github.com/cloudfoundry/dropsonde/metrics"
+)
+
+// Metrics emitted for a client's requests.
+const (
+	// RequestAttempts and RequestAttemptsFailed count each attempt,
+	// including the plain HTTP fallback made after a failed TLS attempt.
+	RequestAttempts         = metric.Counter("AuctioneerClientRequestAttempts")
+	RequestAttemptsFailed   = metric.Counter("AuctioneerClientRequestAttemptsFailed")
+	RequestDuration         = metric.Duration("AuctioneerClientRequestDuration")
+	RequestRetries          = metric.Counter("AuctioneerClientRequestRetries")
+	InsecureFallbacks       = metric.Counter("AuctioneerClientInsecureFallbacks")
+	FallbackRequestDuration = metric.Duration("AuctioneerClientFallbackRequestDuration")
+
+	// LRPPayloadBytes and TaskPayloadBytes are sent, in bytes, for each
+	// batch submitted.
+	LRPPayloadBytes  = "AuctioneerClientLRPPayloadBytes"
+	TaskPayloadBytes = "AuctioneerClientTaskPayloadBytes"
+)
+
+type clientMetricEmitter struct{}
+
+// New returns an auctioneer.MetricsHook that emits the client's request
+// metrics through dropsonde, which must be initialized, to metron. Pass it to
+// auctioneer.WithMetricsHook.
+func New() clientMetricEmitter {
+	return clientMetricEmitter{}
+}
+
+func (_ clientMetricEmitter) IncrementCounter(name string, _ map[string]string) {
+	switch name {
+	case auctioneer.RetryMetric:
+		RequestRetries.Increment()
+	case auctioneer.InsecureFallbackMetric:
+		InsecureFallbacks.Increment()
+	}
+}
+
+func (_ clientMetricEmitter) ObserveDuration(name string, duration time.Duration, labels map[string]string) {
+	if name != auctioneer.RequestAttemptDurationMetric {
+		return
+	}
+
+	RequestAttempts.Increment()
+	if labels[auctioneer.ResultLabel] == auctioneer.ResultError {
+		RequestAttemptsFailed.Increment()
+	}
+
+	if labels[auctioneer.AttemptLabel] == auctioneer.AttemptFallback {
+		FallbackRequestDuration.Send(duration)
+	} else {
+		RequestDuration.Send(duration)
+	}
+}
+
+func (_ clientMetricEmitter) ObserveValue(name string, value float64, labels map[string]string) {
+	if name != auctioneer.PayloadBytesMetric {
+		return
+	}
+
+	switch labels[auctioneer.OperationLabel] {
+	case auctioneer.OperationLRP:
+		metrics.SendValue(LRPPayloadBytes, value, "bytes")
+	case auctioneer.OperationTask:
+		metrics.SendValue(TaskPayloadBytes, value, "bytes")
+	}
+}
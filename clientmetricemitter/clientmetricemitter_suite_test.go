@@ -0,0 +1,13 @@
+package clientmetricemitter_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestClientmetricemitter(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Client Metric Emitter Suite")
+}
@@ -0,0 +1,110 @@
+package clientmetricemitter_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/auctioneer/clientmetricemitter"
+	"github.com/cloudfoundry/dropsonde/metric_sender/fake"
+	"github.com/cloudfoundry/dropsonde/metrics"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client Metric Emitter", func() {
+	var hook auctioneer.MetricsHook
+	var metricSender *fake.FakeMetricSender
+
+	BeforeEach(func() {
+		metricSender = fake.NewFakeMetricSender()
+		metrics.Initialize(metricSender, nil)
+
+		hook = clientmetricemitter.New()
+	})
+
+	Describe("IncrementCounter", func() {
+		It("should count retries and insecure fallbacks", func() {
+			hook.IncrementCounter(auctioneer.RetryMetric, nil)
+			hook.IncrementCounter(auctioneer.RetryMetric, nil)
+			hook.IncrementCounter(auctioneer.InsecureFallbackMetric, nil)
+
+			Expect(metricSender.GetCounter("AuctioneerClientRequestRetries")).To(BeNumerically("==", 2))
+			Expect(metricSender.GetCounter("AuctioneerClientInsecureFallbacks")).To(BeNumerically("==", 1))
+		})
+
+		It("should ignore unknown metrics", func() {
+			hook.IncrementCounter("something_else", nil)
+
+			Expect(metricSender.GetCounter("something_else")).To(BeNumerically("==", 0))
+		})
+	})
+
+	Describe("ObserveDuration", func() {
+		It("should count the attempt and send its duration", func() {
+			hook.ObserveDuration(auctioneer.RequestAttemptDurationMetric, time.Second, map[string]string{
+				auctioneer.AttemptLabel: auctioneer.AttemptPrimary,
+				auctioneer.ResultLabel:  auctioneer.ResultSuccess,
+			})
+
+			Expect(metricSender.GetCounter("AuctioneerClientRequestAttempts")).To(BeNumerically("==", 1))
+			Expect(metricSender.GetCounter("AuctioneerClientRequestAttemptsFailed")).To(BeNumerically("==", 0))
+
+			sentMetric := metricSender.GetValue("AuctioneerClientRequestDuration")
+			Expect(sentMetric.Value).To(Equal(1e+09))
+			Expect(sentMetric.Unit).To(Equal("nanos"))
+		})
+
+		It("should count failed attempts", func() {
+			hook.ObserveDuration(auctioneer.RequestAttemptDurationMetric, time.Second, map[string]string{
+				auctioneer.AttemptLabel: auctioneer.AttemptPrimary,
+				auctioneer.ResultLabel:  auctioneer.ResultError,
+			})
+
+			Expect(metricSender.GetCounter("AuctioneerClientRequestAttempts")).To(BeNumerically("==", 1))
+			Expect(metricSender.GetCounter("AuctioneerClientRequestAttemptsFailed")).To(BeNumerically("==", 1))
+		})
+
+		It("should count a failed TLS attempt and its fallback as two attempts", func() {
+			hook.ObserveDuration(auctioneer.RequestAttemptDurationMetric, time.Second, map[string]string{
+				auctioneer.AttemptLabel: auctioneer.AttemptPrimary,
+				auctioneer.ResultLabel:  auctioneer.ResultError,
+			})
+			hook.ObserveDuration(auctioneer.RequestAttemptDurationMetric, time.Second, map[string]string{
+				auctioneer.AttemptLabel: auctioneer.AttemptFallback,
+				auctioneer.ResultLabel:  auctioneer.ResultSuccess,
+			})
+
+			Expect(metricSender.GetCounter("AuctioneerClientRequestAttempts")).To(BeNumerically("==", 2))
+			Expect(metricSender.GetCounter("AuctioneerClientRequestAttemptsFailed")).To(BeNumerically("==", 1))
+		})
+
+		It("should send the duration of insecure fallback attempts separately", func() {
+			hook.ObserveDuration(auctioneer.RequestAttemptDurationMetric, 2*time.Second, map[string]string{
+				auctioneer.AttemptLabel: auctioneer.AttemptFallback,
+				auctioneer.ResultLabel:  auctioneer.ResultSuccess,
+			})
+
+			Expect(metricSender.GetValue("AuctioneerClientFallbackRequestDuration").Value).To(Equal(2e+09))
+			Expect(metricSender.GetValue("AuctioneerClientRequestDuration").Value).To(BeZero())
+		})
+	})
+
+	Describe("ObserveValue", func() {
+		It("should send payload sizes by operation", func() {
+			hook.ObserveValue(auctioneer.PayloadBytesMetric, 512, map[string]string{auctioneer.OperationLabel: auctioneer.OperationLRP})
+			hook.ObserveValue(auctioneer.PayloadBytesMetric, 256, map[string]string{auctioneer.OperationLabel: auctioneer.OperationTask})
+
+			sentMetric := metricSender.GetValue("AuctioneerClientLRPPayloadBytes")
+			Expect(sentMetric.Value).To(Equal(512.0))
+			Expect(sentMetric.Unit).To(Equal("bytes"))
+			Expect(metricSender.GetValue("AuctioneerClientTaskPayloadBytes").Value).To(Equal(256.0))
+		})
+
+		It("should ignore unknown metrics", func() {
+			hook.ObserveValue("something_else", 1, nil)
+
+			Expect(metricSender.GetValue("something_else").Value).To(BeZero())
+		})
+	})
+})
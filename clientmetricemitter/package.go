@@ -0,0 +1 @@
+package clientmetricemitter // import "code.cloudfoundry.org/auctioneer/clientmetricemitter"
@@ -0,0 +1,81 @@
+package auctioneer
+
+import (
+	"compress/gzip"
+	"context"
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+)
+
+const gzipEncoding = "gzip"
+
+var gzipContentEncoding = []string{gzipEncoding}
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// WithAutoCompression gzips an auction batch whose marshaled size exceeds
+// threshold bytes, provided the auctioneer's Capabilities include the gzip
+// encoding. Batches are sent uncompressed when the auctioneer does not
+// advertise gzip or its capabilities cannot be determined.
+func WithAutoCompression(threshold int) ClientOption {
+	return func(c *auctioneerClient) {
+		c.autoCompression = true
+		c.autoCompressionThreshold = threshold
+	}
+}
+
+// WithDisableCompression sets DisableCompression on the client's transport,
+// so that it neither asks the auctioneer for gzipped responses nor
+// transparently decompresses them, and response bodies reach wire logging
+// and digest checks as sent. It does not affect WithAutoCompression, which
+// compresses requests.
+func WithDisableCompression() ClientOption {
+	return func(c *auctioneerClient) {
+		c.disableCompression = true
+	}
+}
+
+// shouldCompress reports whether a batch of size bytes is to be gzipped.
+func (c *auctioneerClient) shouldCompress(logger lager.Logger, ctx context.Context, size int) bool {
+	if !c.autoCompression || size <= c.autoCompressionThreshold {
+		return false
+	}
+
+	capabilities, err := c.batchCapabilities(logger, ctx)
+	if err != nil {
+		logger.Debug("sending-uncompressed", lager.Data{"error": err.Error()})
+		return false
+	}
+
+	return capabilities.SupportsEncoding(gzipEncoding)
+}
+
+// compress replaces the payload's content with its gzip encoding. It must be
+// called before any body reading the payload is created.
+func (p *payload) compress() error {
+	compressed := p.pool.Get()
+
+	zw := gzipWriterPool.Get().(*gzip.Writer)
+	zw.Reset(compressed)
+	_, err := zw.Write(p.buf.Bytes())
+	if err == nil {
+		err = zw.Close()
+	}
+	zw.Reset(nil)
+	gzipWriterPool.Put(zw)
+
+	if err != nil {
+		p.pool.Put(compressed)
+		return err
+	}
+
+	p.pool.Put(p.buf)
+	p.buf = compressed
+	p.writer.buf = compressed
+	return nil
+}
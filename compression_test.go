@@ -0,0 +1,168 @@
+package auctioneer_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/auctioneer/auctioneerfakes"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("WithAutoCompression", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		client     ExtendedClient
+		lrpStarts  []*LRPStartRequest
+
+		encoding string
+		received []*LRPStartRequest
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		client = NewClient(fakeServer.URL(), WithAutoCompression(100))
+		lrpStarts = []*LRPStartRequest{{ProcessGuid: strings.Repeat("a", 200), Domain: "some-domain", Indices: []int{0}}}
+
+		encoding = ""
+		received = nil
+		fakeServer.RouteToHandler("POST", "/v1/lrps", func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+
+			encoding = r.Header.Get("Content-Encoding")
+			body := r.Body
+			if encoding == "gzip" {
+				zr, err := gzip.NewReader(r.Body)
+				Expect(err).NotTo(HaveOccurred())
+				body = zr
+			}
+			Expect(json.NewDecoder(body).Decode(&received)).To(Succeed())
+			w.WriteHeader(http.StatusAccepted)
+		})
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	Context("when the auctioneer supports gzip", func() {
+		BeforeEach(func() {
+			fakeServer.RouteToHandler("OPTIONS", "/", ghttp.RespondWithJSONEncoded(http.StatusOK, Capabilities{Encodings: []string{"gzip"}}))
+		})
+
+		It("compresses batches over the threshold", func() {
+			Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+			Expect(encoding).To(Equal("gzip"))
+			Expect(received).To(Equal(lrpStarts))
+		})
+
+		It("sends smaller batches uncompressed", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			Expect(encoding).To(BeEmpty())
+		})
+
+		It("reports the compressed size of the batch", func() {
+			metricsHook := &auctioneerfakes.FakeMetricsHook{}
+			client = NewClient(fakeServer.URL(), WithAutoCompression(100), WithMetricsHook(metricsHook))
+			uncompressed, err := json.Marshal(lrpStarts)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+			Expect(metricsHook.ObserveValueCallCount()).To(Equal(1))
+			_, value, _ := metricsHook.ObserveValueArgsForCall(0)
+			Expect(value).To(BeNumerically("<", len(uncompressed)))
+		})
+	})
+
+	Context("when the auctioneer does not advertise gzip", func() {
+		BeforeEach(func() {
+			fakeServer.RouteToHandler("OPTIONS", "/", ghttp.RespondWithJSONEncoded(http.StatusOK, Capabilities{}))
+		})
+
+		It("sends the batch uncompressed", func() {
+			Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+			Expect(encoding).To(BeEmpty())
+			Expect(received).To(Equal(lrpStarts))
+		})
+	})
+
+	Context("when the auctioneer predates capability detection", func() {
+		BeforeEach(func() {
+			fakeServer.RouteToHandler("OPTIONS", "/", ghttp.RespondWith(http.StatusNotFound, ""))
+		})
+
+		It("sends the batch uncompressed", func() {
+			Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+			Expect(encoding).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("WithDisableCompression", func() {
+	var (
+		logger         *lagertest.TestLogger
+		fakeServer     *ghttp.Server
+		acceptEncoding []string
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		acceptEncoding = nil
+		fakeServer.RouteToHandler("POST", "/v1/lrps", func(w http.ResponseWriter, r *http.Request) {
+			acceptEncoding = r.Header["Accept-Encoding"]
+			w.WriteHeader(http.StatusAccepted)
+		})
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("asks for gzipped responses by default", func() {
+		client := NewClient(fakeServer.URL())
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(acceptEncoding).To(Equal([]string{"gzip"}))
+	})
+
+	It("does not ask for gzipped responses", func() {
+		client := NewClient(fakeServer.URL(), WithDisableCompression())
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(acceptEncoding).To(BeEmpty())
+	})
+
+	Context("when the auctioneer gzips a response", func() {
+		BeforeEach(func() {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			_, err := zw.Write([]byte(`{"content_types":["application/json"]}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zw.Close()).To(Succeed())
+
+			fakeServer.RouteToHandler("OPTIONS", "/", ghttp.RespondWith(http.StatusOK, buf.Bytes(), http.Header{"Content-Encoding": []string{"gzip"}}))
+		})
+
+		It("decompresses it by default", func() {
+			client := NewClient(fakeServer.URL())
+			capabilities, err := client.Capabilities(logger, context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(capabilities.ContentTypes).To(Equal([]string{"application/json"}))
+		})
+
+		It("leaves it compressed", func() {
+			client := NewClient(fakeServer.URL(), WithDisableCompression())
+			_, err := client.Capabilities(logger, context.Background())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
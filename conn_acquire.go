@@ -0,0 +1,128 @@
+package auctioneer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+)
+
+// ErrConnectionAcquireTimeout is matched, with errors.Is, by the error a
+// request fails with when it waited longer than WithConnectionAcquireTimeout
+// allows for a connection.
+var ErrConnectionAcquireTimeout = errors.New("timed out waiting for a connection")
+
+// WithConnectionAcquireTimeout bounds how long a request waits for a
+// connection to the auctioneer when none is free: for a slot under
+// WithBackendMaxConns, and for the transport to hand it an idle connection
+// when its pool is full, as it is when the transport's MaxConnsPerHost,
+// set with WithTransportConfig, is reached. Dialing a new connection is not
+// waiting and stays bounded by the dial timeout. A request that waits
+// longer fails, without being sent or falling back to plain HTTP, with an
+// error matching ErrConnectionAcquireTimeout, which IsRetryable reports as
+// not retryable, so that a burst beyond the pool is shed instead of queued.
+// It has no effect on requests sent through WithRoundTripper.
+func WithConnectionAcquireTimeout(timeout time.Duration) ClientOption {
+	return func(c *auctioneerClient) {
+		c.connAcquireTimeout = timeout
+	}
+}
+
+func (c *auctioneerClient) connAcquireTimeoutError(host string) error {
+	return fmt.Errorf("%w to %s after %s", ErrConnectionAcquireTimeout, host, c.connAcquireTimeout)
+}
+
+// The states of a request waiting for the transport to hand it a
+// connection.
+const (
+	connWaiting int32 = iota
+	connAcquired
+	connTimedOut
+)
+
+// doWithAcquireTimeout sends req with httpClient, abandoning it if the
+// transport does not hand it a connection, or start dialing one, within the
+// connection acquire timeout.
+func (c *auctioneerClient) doWithAcquireTimeout(httpClient *http.Client, req *http.Request) (*http.Response, error) {
+	if c.connAcquireTimeout <= 0 {
+		return httpClient.Do(req)
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+
+	var state int32
+	acquired := make(chan struct{})
+	stopWaiting := func() {
+		if atomic.CompareAndSwapInt32(&state, connWaiting, connAcquired) {
+			close(acquired)
+		}
+	}
+
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: func(string) {
+			go c.expireConnWait(ctx, &state, acquired, cancel)
+		},
+		GotConn:      func(httptrace.GotConnInfo) { stopWaiting() },
+		DNSStart:     func(httptrace.DNSStartInfo) { stopWaiting() },
+		ConnectStart: func(string, string) { stopWaiting() },
+	})
+
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if atomic.LoadInt32(&state) == connTimedOut {
+			return nil, c.connAcquireTimeoutError(req.URL.Host)
+		}
+		return nil, err
+	}
+
+	// the body is read after this returns, so the context it is read with
+	// is only canceled once it is closed
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: cancel}
+	return resp, nil
+}
+
+// expireConnWait cancels a request still waiting for a connection once the
+// connection acquire timeout passes.
+func (c *auctioneerClient) expireConnWait(ctx context.Context, state *int32, acquired <-chan struct{}, cancel context.CancelFunc) {
+	timer := c.clock.NewTimer(c.connAcquireTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-acquired:
+	case <-ctx.Done():
+	case <-timer.C():
+		if atomic.CompareAndSwapInt32(state, connWaiting, connTimedOut) {
+			cancel()
+		}
+	}
+}
+
+// acquireSlot waits for a free slot in conns, for at most the connection
+// acquire timeout when one is set.
+func (c *auctioneerClient) acquireSlot(ctx context.Context, host string, conns chan struct{}) error {
+	select {
+	case conns <- struct{}{}:
+		return nil
+	default:
+	}
+
+	var expired <-chan time.Time
+	if c.connAcquireTimeout > 0 {
+		timer := c.clock.NewTimer(c.connAcquireTimeout)
+		defer timer.Stop()
+		expired = timer.C()
+	}
+
+	select {
+	case conns <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-expired:
+		return c.connAcquireTimeoutError(host)
+	}
+}
@@ -0,0 +1,113 @@
+package auctioneer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("WithConnectionAcquireTimeout", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		unblock    chan struct{}
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		unblock = make(chan struct{})
+
+		fakeServer = ghttp.NewServer()
+		fakeServer.RouteToHandler("POST", "/v1/lrps", func(w http.ResponseWriter, r *http.Request) {
+			<-unblock
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"accepted":1}`))
+		})
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	// submitBlocked starts a submission through client that holds its
+	// connection until unblock is closed.
+	submitBlocked := func(client ExtendedClient, ctx context.Context) {
+		go client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+		Eventually(fakeServer.ReceivedRequests).Should(HaveLen(1))
+	}
+
+	Context("when the transport's connections are all in use", func() {
+		var client ExtendedClient
+
+		BeforeEach(func() {
+			client = NewClient(fakeServer.URL(),
+				WithConnectionAcquireTimeout(50*time.Millisecond),
+				WithRetries(2),
+				WithTransportConfig(func(tr *http.Transport) {
+					tr.MaxConnsPerHost = 1
+				}),
+			)
+		})
+
+		It("fails without sending the request once the timeout passes", func() {
+			submitBlocked(client, context.Background())
+
+			_, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), []*LRPStartRequest{{}})
+			Expect(errors.Is(err, ErrConnectionAcquireTimeout)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("after 50ms")))
+			Expect(IsRetryable(err)).To(BeFalse())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+			close(unblock)
+		})
+
+		It("leaves the response body readable when a connection is free", func() {
+			close(unblock)
+			result, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), []*LRPStartRequest{{}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Accepted).To(Equal(1))
+		})
+	})
+
+	Context("when a backend is at its WithBackendMaxConns cap", func() {
+		It("fails once the timeout passes", func() {
+			client := NewClient(fakeServer.URL(),
+				WithConnectionAcquireTimeout(50*time.Millisecond),
+				WithBackendMaxConns(map[string]int{fakeServer.URL(): 1}),
+			)
+			ctx := ContextWithTargetURL(context.Background(), fakeServer.URL())
+			submitBlocked(client, ctx)
+
+			_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+			Expect(errors.Is(err, ErrConnectionAcquireTimeout)).To(BeTrue())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+			close(unblock)
+		})
+	})
+
+	Context("without a timeout", func() {
+		It("waits for a connection to be free", func() {
+			client := NewClient(fakeServer.URL(), WithTransportConfig(func(tr *http.Transport) {
+				tr.MaxConnsPerHost = 1
+			}))
+			submitBlocked(client, context.Background())
+
+			errs := make(chan error, 1)
+			go func() {
+				_, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), []*LRPStartRequest{})
+				errs <- err
+			}()
+			Consistently(errs, 100*time.Millisecond).ShouldNot(Receive())
+
+			close(unblock)
+			Eventually(errs).Should(Receive(BeNil()))
+		})
+	})
+})
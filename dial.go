@@ -0,0 +1,63 @@
+package auctioneer
+
+import (
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// The dialer settings of the transports built by cfhttp, kept when the
+// client replaces their dialer.
+const (
+	dialTimeout   = 5 * time.Second
+	dialKeepAlive = 30 * time.Second
+)
+
+// WithResolver resolves the auctioneer's hostname with resolver instead of
+// the system resolver, for example to query a specific DNS server in a
+// split-horizon setup by supplying a resolver with a custom Dial.
+func WithResolver(resolver *net.Resolver) ClientOption {
+	return func(c *auctioneerClient) {
+		c.resolver = resolver
+	}
+}
+
+// WithDialFallbackDelay sets how long a dual-stack dial waits on the
+// preferred address family before racing a connection over the other one
+// (see net.Dialer.FallbackDelay). A negative delay disables the fallback
+// race, so the preferred family, usually IPv6, is used whenever it
+// connects.
+func WithDialFallbackDelay(delay time.Duration) ClientOption {
+	return func(c *auctioneerClient) {
+		c.dialFallbackDelay = delay
+		c.dialFallbackDelaySet = true
+	}
+}
+
+// WithDialControl calls control for each connection the client dials, after
+// the socket is created and before it connects (see net.Dialer.Control). The
+// network argument is "tcp4" or "tcp6", so returning an error for one of them
+// restricts the client to the other address family.
+func WithDialControl(control func(network, address string, conn syscall.RawConn) error) ClientOption {
+	return func(c *auctioneerClient) {
+		c.dialControl = control
+	}
+}
+
+// configureDialer replaces the transport's dialer when a dial option is set.
+func (c *auctioneerClient) configureDialer(tr *http.Transport) {
+	if c.resolver == nil && !c.dialFallbackDelaySet && c.dialControl == nil {
+		return
+	}
+
+	dialer := &net.Dialer{
+		Timeout:       dialTimeout,
+		KeepAlive:     dialKeepAlive,
+		Resolver:      c.resolver,
+		FallbackDelay: c.dialFallbackDelay,
+		Control:       c.dialControl,
+	}
+	tr.Dial = nil
+	tr.DialContext = dialer.DialContext
+}
@@ -0,0 +1,111 @@
+package auctioneer_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Dialing", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	Describe("WithResolver", func() {
+		var (
+			client   ExtendedClient
+			dnsDials int32
+		)
+
+		BeforeEach(func() {
+			dnsDials = 0
+			resolver := &net.Resolver{
+				PreferGo: true,
+				Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+					atomic.AddInt32(&dnsDials, 1)
+					return nil, errors.New("internal dns unavailable")
+				},
+			}
+
+			serverURL, err := url.Parse(fakeServer.URL())
+			Expect(err).NotTo(HaveOccurred())
+			client = NewClient("http://auctioneer.split-horizon.test:"+serverURL.Port(), WithResolver(resolver))
+		})
+
+		It("resolves the auctioneer through the resolver", func() {
+			err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+			Expect(errors.Is(err, ErrDNSResolution)).To(BeTrue())
+			Expect(atomic.LoadInt32(&dnsDials)).To(BeNumerically(">", 0))
+		})
+
+		It("still connects to IP addresses directly", func() {
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+			client.SetURL(fakeServer.URL())
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			Expect(atomic.LoadInt32(&dnsDials)).To(BeZero())
+		})
+	})
+
+	Describe("WithDialControl", func() {
+		var networks chan string
+
+		BeforeEach(func() {
+			networks = make(chan string, 1)
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+		})
+
+		It("is called for each dial", func() {
+			client := NewClient(fakeServer.URL(), WithDialControl(func(network, address string, conn syscall.RawConn) error {
+				networks <- network
+				return nil
+			}))
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			Expect(networks).To(Receive(Equal("tcp4")))
+		})
+
+		It("can refuse an address family", func() {
+			client := NewClient(fakeServer.URL(), WithDialControl(func(network, address string, conn syscall.RawConn) error {
+				if network == "tcp4" {
+					return errors.New("ipv4 disabled")
+				}
+				return nil
+			}))
+
+			err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+			Expect(err).To(MatchError(ContainSubstring("ipv4 disabled")))
+			Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+		})
+	})
+
+	Describe("WithDialFallbackDelay", func() {
+		It("connects with the fallback race disabled", func() {
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+			client := NewClient(fakeServer.URL(), WithDialFallbackDelay(-1))
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		})
+	})
+})
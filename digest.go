@@ -0,0 +1,50 @@
+package auctioneer
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"hash"
+	"net/http"
+)
+
+// ContentDigestHeader carries the digest of the body, in the RFC 9530 form
+// "sha-256=:<base64>:", for clients configured with WithContentDigest.
+const ContentDigestHeader = "Content-Digest"
+
+// DigestAlgorithm is a hash algorithm for WithContentDigest.
+type DigestAlgorithm struct {
+	name string
+	new  func() hash.Hash
+}
+
+// The DigestAlgorithms supported by WithContentDigest.
+var (
+	DigestSHA256 = DigestAlgorithm{name: "sha-256", new: sha256.New}
+	DigestSHA512 = DigestAlgorithm{name: "sha-512", new: sha512.New}
+)
+
+func (a DigestAlgorithm) String() string {
+	return a.name
+}
+
+// WithContentDigest sends each auction batch with a Content-Digest header
+// computed with algorithm over the body, so that a cooperating auctioneer
+// can detect corruption in transit.
+func WithContentDigest(algorithm DigestAlgorithm) ClientOption {
+	return func(c *auctioneerClient) {
+		if algorithm.new != nil {
+			c.digestAlgorithm = algorithm
+		}
+	}
+}
+
+func (c *auctioneerClient) setContentDigest(req *http.Request, body []byte) {
+	if c.digestAlgorithm.new == nil {
+		return
+	}
+
+	h := c.digestAlgorithm.new()
+	h.Write(body)
+	req.Header.Set(ContentDigestHeader, c.digestAlgorithm.name+"=:"+base64.StdEncoding.EncodeToString(h.Sum(nil))+":")
+}
@@ -0,0 +1,69 @@
+package auctioneer_test
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("WithContentDigest", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		digests    chan string
+		bodies     chan []byte
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		digests = make(chan string, 1)
+		bodies = make(chan []byte, 1)
+
+		fakeServer.RouteToHandler("POST", "/v1/tasks", ghttp.CombineHandlers(
+			func(w http.ResponseWriter, r *http.Request) {
+				body, err := ioutil.ReadAll(r.Body)
+				Expect(err).NotTo(HaveOccurred())
+				bodies <- body
+				digests <- r.Header.Get(ContentDigestHeader)
+			},
+			ghttp.RespondWith(http.StatusAccepted, "{}"),
+		))
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("does not send a digest by default", func() {
+		client := NewClient(fakeServer.URL())
+		Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).To(Succeed())
+
+		Expect(<-digests).To(BeEmpty())
+	})
+
+	It("sends the SHA-256 digest of the body", func() {
+		client := NewClient(fakeServer.URL(), WithContentDigest(DigestSHA256))
+		Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).To(Succeed())
+
+		sum := sha256.Sum256(<-bodies)
+		Expect(<-digests).To(Equal("sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"))
+	})
+
+	It("sends the digest with the configured algorithm", func() {
+		client := NewClient(fakeServer.URL(), WithContentDigest(DigestSHA512))
+		Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).To(Succeed())
+
+		sum := sha512.Sum512(<-bodies)
+		Expect(<-digests).To(Equal("sha-512=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"))
+	})
+})
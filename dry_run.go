@@ -0,0 +1,101 @@
+package auctioneer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// DryRunHeader asks the auctioneer to validate an auction batch without
+// scheduling it.
+const DryRunHeader = "X-Auctioneer-Dry-Run"
+
+// ErrDryRunNotSupported is returned when the auctioneer accepted a dry-run
+// batch for scheduling instead of only validating it. Auctioneers that
+// predate dry-run support ignore DryRunHeader, so only dry-run batches
+// against auctioneers known to support it.
+var ErrDryRunNotSupported = errors.New("auctioneer does not support dry-run requests")
+
+// DryRunResult is the validation outcome of a dry-run auction batch.
+type DryRunResult struct {
+	Valid    int               `json:"valid"`
+	Rejected []DryRunRejection `json:"rejected,omitempty"`
+}
+
+// DryRunRejection identifies an entry of a dry-run batch, by its index in
+// the batch, that the auctioneer would have rejected. Reason is empty when
+// the auctioneer does not provide one.
+type DryRunRejection struct {
+	Index  int             `json:"index"`
+	Error  string          `json:"error"`
+	Reason RejectionReason `json:"reason,omitempty"`
+}
+
+// DryRunLRPAuctions asks the auctioneer to validate lrpStarts without
+// scheduling them. The batch goes where RequestLRPAuctions would send it,
+// following WithFailover, WithBackendSelector and WithSharding, so that it
+// is validated by the auctioneers that would schedule it.
+func (c *auctioneerClient) DryRunLRPAuctions(logger lager.Logger, ctx context.Context, lrpStarts []*LRPStartRequest) (DryRunResult, error) {
+	ctx = requestContext(ctx)
+	logger = c.requestLogger(logger, ctx).Session("dry-run-lrp-auctions")
+	lrpStarts = c.transformLRPs(lrpStarts)
+	if len(c.shardBackends) > 0 {
+		return c.dryRunShardedLRPAuctions(logger, ctx, lrpStarts)
+	}
+
+	return c.dryRunAuctions(logger, ctx, auctionRequest{
+		operation: OperationLRP,
+		route:     CreateLRPAuctionsRoute,
+		auctions:  lrpStarts,
+		count:     len(lrpStarts),
+		dryRun:    true,
+	})
+}
+
+// DryRunTaskAuctions asks the auctioneer to validate tasks without
+// scheduling them. The batch goes where RequestTaskAuctions would send it.
+func (c *auctioneerClient) DryRunTaskAuctions(logger lager.Logger, ctx context.Context, tasks []*TaskStartRequest) (DryRunResult, error) {
+	ctx = requestContext(ctx)
+	logger = c.requestLogger(logger, ctx).Session("dry-run-task-auctions")
+	tasks = c.transformTasks(tasks)
+	return c.dryRunAuctions(logger, ctx, auctionRequest{
+		operation: OperationTask,
+		route:     CreateTaskAuctionsRoute,
+		auctions:  tasks,
+		count:     len(tasks),
+		dryRun:    true,
+	})
+}
+
+func (c *auctioneerClient) dryRunAuctions(logger lager.Logger, ctx context.Context, ar auctionRequest) (DryRunResult, error) {
+	result := DryRunResult{}
+	ar.dryRunResult = &result
+	_, err := c.requestAuctions(logger, ctx, ar)
+	if err != nil {
+		return DryRunResult{}, err
+	}
+	return result, nil
+}
+
+// readDryRunResult decodes the validation outcome in the response to a
+// dry-run batch.
+func readDryRunResult(operation string, resp *http.Response) (DryRunResult, error) {
+	if resp.StatusCode == http.StatusAccepted {
+		return DryRunResult{}, ErrDryRunNotSupported
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return DryRunResult{}, newStatusError(operation, resp)
+	}
+
+	result := DryRunResult{}
+	err := json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return DryRunResult{}, err
+	}
+
+	return result, nil
+}
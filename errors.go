@@ -0,0 +1,258 @@
+package auctioneer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+var ErrDNSResolution = errors.New("auctioneer host could not be resolved")
+
+// ErrRouteMismatch is matched, with errors.Is, by a StatusError for an
+// auction request answered with 404 or 405. Those usually mean the client's
+// URL points at a service other than the auctioneer, or that the auctioneer
+// serves different routes.
+var ErrRouteMismatch = errors.New("auction route is not served at this URL")
+
+// ErrProxyAuthRequired is matched, with errors.Is, by a StatusError for a
+// request answered with 407 by a proxy between the client and the
+// auctioneer. It points at the proxy credentials, not the auctioneer.
+var ErrProxyAuthRequired = errors.New("proxy authentication required")
+
+// Operations reported by StatusError.
+const (
+	OperationLRP          = "lrp"
+	OperationTask         = "task"
+	OperationBatchStatus  = "batch-status"
+	OperationCapabilities = "capabilities"
+)
+
+// StatusError is returned when the auctioneer responds with an unexpected
+// status code.
+type StatusError struct {
+	// Operation is the request that failed: OperationLRP, OperationTask,
+	// OperationBatchStatus or OperationCapabilities.
+	Operation  string
+	StatusCode int
+	// Status is the standard text for StatusCode, such as "Not Found".
+	Status string
+	// ProxyAuthenticate is the Proxy-Authenticate header of a 407 response,
+	// naming the authentication scheme the proxy expects.
+	ProxyAuthenticate string
+}
+
+func newStatusError(operation string, resp *http.Response) *StatusError {
+	statusErr := &StatusError{
+		Operation:  operation,
+		StatusCode: resp.StatusCode,
+		Status:     http.StatusText(resp.StatusCode),
+	}
+	if resp.StatusCode == http.StatusProxyAuthRequired {
+		statusErr.ProxyAuthenticate = resp.Header.Get("Proxy-Authenticate")
+	}
+	return statusErr
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("http error: status code %d (%s)", e.StatusCode, e.Status)
+	if e.routeMismatch() {
+		msg += ": check that the client URL points at the auctioneer and that its routes match"
+	}
+	if e.StatusCode == http.StatusProxyAuthRequired {
+		msg += ": check the proxy credentials"
+		if e.ProxyAuthenticate != "" {
+			msg += fmt.Sprintf(" (Proxy-Authenticate: %s)", e.ProxyAuthenticate)
+		}
+	}
+	return msg
+}
+
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrRouteMismatch:
+		return e.routeMismatch()
+	case ErrProxyAuthRequired:
+		return e.StatusCode == http.StatusProxyAuthRequired
+	}
+	return false
+}
+
+func (e *StatusError) routeMismatch() bool {
+	if e.Operation != OperationLRP && e.Operation != OperationTask {
+		return false
+	}
+	return e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusMethodNotAllowed
+}
+
+// CanceledError is returned when an auction request is abandoned because
+// its context was canceled or its deadline passed. It unwraps to the
+// underlying error, so errors.Is(err, context.Canceled) and
+// errors.Is(err, context.DeadlineExceeded) keep working.
+type CanceledError struct {
+	// Operation is the request that was abandoned: OperationLRP or
+	// OperationTask.
+	Operation string
+	Err       error
+}
+
+// wrapCanceled returns err as a CanceledError for operation if it is a
+// context error, and unchanged otherwise.
+func wrapCanceled(operation string, err error) error {
+	if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return &CanceledError{Operation: operation, Err: err}
+}
+
+func (e *CanceledError) Error() string {
+	if errors.Is(e.Err, context.DeadlineExceeded) {
+		return fmt.Sprintf("%s auction deadline exceeded: %s", e.Operation, e.Err)
+	}
+	return fmt.Sprintf("%s auction canceled: %s", e.Operation, e.Err)
+}
+
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
+// DNSResolutionError is returned when the auctioneer's hostname does not
+// resolve. It matches ErrDNSResolution with errors.Is and unwraps to the
+// underlying *net.DNSError.
+type DNSResolutionError struct {
+	Host string
+	Err  *net.DNSError
+}
+
+func (e *DNSResolutionError) Error() string {
+	return fmt.Sprintf("resolving auctioneer host %s: %s", e.Host, e.Err)
+}
+
+func (e *DNSResolutionError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DNSResolutionError) Is(target error) bool {
+	return target == ErrDNSResolution
+}
+
+// ErrPayloadTooLarge is matched, with errors.Is, by the *PayloadTooLargeError
+// returned for an auction batch larger than WithMaxPayloadBytes allows.
+var ErrPayloadTooLarge = errors.New("auction payload is too large")
+
+// ErrPayloadExceedsServerLimit is matched, with errors.Is, by the
+// *PayloadTooLargeError returned for an auction batch larger than the
+// auctioneer advertises it accepts; see WithServerPayloadLimit. Such an
+// error matches ErrPayloadTooLarge too.
+var ErrPayloadExceedsServerLimit = errors.New("auction payload exceeds the auctioneer's limit")
+
+// PayloadTooLargeError is returned, without sending anything, for an
+// auction batch whose marshaled size exceeds WithMaxPayloadBytes or, with
+// WithServerPayloadLimit, the auctioneer's advertised limit.
+type PayloadTooLargeError struct {
+	Operation string
+	// Size is the marshaled size of the batch in bytes or, for a batch
+	// streamed with WithNDJSONStreaming, the size it would have reached with
+	// the entry that was refused.
+	Size  int
+	Limit int
+	// Advertised reports whether Limit is the one the auctioneer advertises
+	// rather than WithMaxPayloadBytes.
+	Advertised bool
+}
+
+func (e *PayloadTooLargeError) Error() string {
+	if e.Advertised {
+		return fmt.Sprintf("%s auctions payload of %d bytes exceeds the auctioneer's advertised maximum of %d bytes", e.Operation, e.Size, e.Limit)
+	}
+	return fmt.Sprintf("%s auctions payload of %d bytes exceeds the maximum of %d bytes", e.Operation, e.Size, e.Limit)
+}
+
+func (e *PayloadTooLargeError) Is(target error) bool {
+	return target == ErrPayloadTooLarge || (e.Advertised && target == ErrPayloadExceedsServerLimit)
+}
+
+// IsRetryable reports whether a request that failed with err may succeed if
+// tried again. Transient resolution failures, refused, reset or dropped
+// connections, network timeouts and backend timeouts (see
+// WithBackendTimeouts) are retryable. Hosts that do not exist, canceled
+// requests, TLS failures such as an untrusted certificate, and any other
+// errors are not. Errors from a client with WithFailureClassifier are
+// retryable as its classifier decided.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if retryable, ok := asClassified(err); ok {
+		return retryable
+	}
+
+	var backendTimeoutErr *BackendTimeoutError
+	if errors.As(err, &backendTimeoutErr) {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return !dnsErr.IsNotFound && (dnsErr.IsTemporary || dnsErr.IsTimeout)
+	}
+
+	if isTLSError(err) {
+		return false
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		// TLS alerts sent by the server arrive as a "remote error".
+		return opErr.Op != "remote error"
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isTLSError reports whether err is a TLS handshake or certificate failure,
+// which trying again will not fix.
+func isTLSError(err error) bool {
+	var (
+		verificationErr *tls.CertificateVerificationError
+		recordHeaderErr tls.RecordHeaderError
+		alertErr        tls.AlertError
+		unknownAuthErr  x509.UnknownAuthorityError
+		hostnameErr     x509.HostnameError
+		invalidErr      x509.CertificateInvalidError
+	)
+
+	return errors.As(err, &verificationErr) ||
+		errors.As(err, &recordHeaderErr) ||
+		errors.As(err, &alertErr) ||
+		errors.As(err, &unknownAuthErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr)
+}
+
+// classifyRequestError wraps transport errors for req that callers need to
+// tell apart.
+func classifyRequestError(req *http.Request, err error) error {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return &DNSResolutionError{Host: req.URL.Hostname(), Err: dnsErr}
+	}
+
+	return err
+}
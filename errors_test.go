@@ -0,0 +1,331 @@
+package auctioneer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Errors", func() {
+	Describe("DNS resolution failures", func() {
+		It("returns a DNSResolutionError naming the host", func() {
+			client := NewClient("http://auctioneer.does-not-exist.invalid")
+
+			err := client.RequestLRPAuctions(lagertest.NewTestLogger("test"), []*LRPStartRequest{})
+			Expect(errors.Is(err, ErrDNSResolution)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("auctioneer.does-not-exist.invalid")))
+
+			var dnsErr *net.DNSError
+			Expect(errors.As(err, &dnsErr)).To(BeTrue())
+		})
+	})
+
+	Describe("canceled requests", func() {
+		var (
+			fakeServer *ghttp.Server
+			release    chan struct{}
+		)
+
+		BeforeEach(func() {
+			release = make(chan struct{})
+			fakeServer = ghttp.NewServer()
+			fakeServer.AppendHandlers(func(w http.ResponseWriter, r *http.Request) {
+				<-release
+			})
+		})
+
+		AfterEach(func() {
+			close(release)
+			fakeServer.Close()
+		})
+
+		It("names the operation and preserves the context error", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			client := NewClient(fakeServer.URL())
+			_, err := client.RequestLRPAuctionsWithResult(lagertest.NewTestLogger("test"), ctx, []*LRPStartRequest{})
+			Expect(err).To(MatchError(ContainSubstring("lrp auction deadline exceeded")))
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+
+			var canceledErr *CanceledError
+			Expect(errors.As(err, &canceledErr)).To(BeTrue())
+			Expect(canceledErr.Operation).To(Equal(OperationLRP))
+		})
+
+		It("reports a canceled context as canceled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			client := NewClient(fakeServer.URL())
+			_, err := client.RequestTaskAuctionsWithResult(lagertest.NewTestLogger("test"), ctx, []*TaskStartRequest{})
+			Expect(err).To(MatchError(ContainSubstring("task auction canceled")))
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		})
+	})
+
+	Describe("status errors", func() {
+		var (
+			logger     *lagertest.TestLogger
+			fakeServer *ghttp.Server
+			client     ExtendedClient
+		)
+
+		BeforeEach(func() {
+			logger = lagertest.NewTestLogger("test")
+			fakeServer = ghttp.NewServer()
+			client = NewClient(fakeServer.URL())
+		})
+
+		AfterEach(func() {
+			fakeServer.Close()
+		})
+
+		for _, statusCode := range []int{
+			http.StatusBadRequest,
+			http.StatusUnauthorized,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusServiceUnavailable,
+		} {
+			statusCode := statusCode
+
+			Context(fmt.Sprintf("when the auctioneer responds with %d", statusCode), func() {
+				BeforeEach(func() {
+					fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(statusCode, nil))
+					fakeServer.RouteToHandler("POST", "/v1/tasks", ghttp.RespondWith(statusCode, nil))
+					fakeServer.RouteToHandler("GET", "/v1/batches/some-batch", ghttp.RespondWith(statusCode, nil))
+				})
+
+				It("reports the status code and the LRP operation", func() {
+					err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+
+					var statusErr *StatusError
+					Expect(errors.As(err, &statusErr)).To(BeTrue())
+					Expect(statusErr.StatusCode).To(Equal(statusCode))
+					Expect(statusErr.Status).To(Equal(http.StatusText(statusCode)))
+					Expect(statusErr.Operation).To(Equal(OperationLRP))
+				})
+
+				It("reports the status code and the task operation", func() {
+					err := client.RequestTaskAuctions(logger, []*TaskStartRequest{})
+
+					var statusErr *StatusError
+					Expect(errors.As(err, &statusErr)).To(BeTrue())
+					Expect(statusErr.StatusCode).To(Equal(statusCode))
+					Expect(statusErr.Operation).To(Equal(OperationTask))
+				})
+
+				It("reports the status code of a batch status poll", func() {
+					ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+					defer cancel()
+
+					_, err := client.WaitForBatch(logger, ctx, fakeServer.URL()+"/v1/batches/some-batch")
+
+					var statusErr *StatusError
+					Expect(errors.As(err, &statusErr)).To(BeTrue())
+					Expect(statusErr.StatusCode).To(Equal(statusCode))
+					Expect(statusErr.Operation).To(Equal(OperationBatchStatus))
+				})
+
+				It("keeps the error message", func() {
+					err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+					Expect(err).To(MatchError(fmt.Sprintf("http error: status code %d (%s)", statusCode, http.StatusText(statusCode))))
+				})
+			})
+		}
+	})
+
+	Describe("route mismatches", func() {
+		var (
+			logger     *lagertest.TestLogger
+			fakeServer *ghttp.Server
+			client     ExtendedClient
+		)
+
+		BeforeEach(func() {
+			logger = lagertest.NewTestLogger("test")
+			fakeServer = ghttp.NewServer()
+			client = NewClient(fakeServer.URL())
+		})
+
+		AfterEach(func() {
+			fakeServer.Close()
+		})
+
+		for _, statusCode := range []int{http.StatusNotFound, http.StatusMethodNotAllowed} {
+			statusCode := statusCode
+
+			Context(fmt.Sprintf("when an auction route responds with %d", statusCode), func() {
+				BeforeEach(func() {
+					fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(statusCode, nil))
+					fakeServer.RouteToHandler("GET", "/v1/batches/some-batch", ghttp.RespondWith(statusCode, nil))
+				})
+
+				It("returns an error suggesting a URL or route mismatch", func() {
+					err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+					Expect(errors.Is(err, ErrRouteMismatch)).To(BeTrue())
+					Expect(err).To(MatchError(ContainSubstring("check that the client URL points at the auctioneer")))
+
+					var statusErr *StatusError
+					Expect(errors.As(err, &statusErr)).To(BeTrue())
+					Expect(statusErr.StatusCode).To(Equal(statusCode))
+				})
+
+				It("does not treat a batch status poll as a route mismatch", func() {
+					_, err := client.WaitForBatch(logger, context.Background(), fakeServer.URL()+"/v1/batches/some-batch")
+					Expect(errors.Is(err, ErrRouteMismatch)).To(BeFalse())
+				})
+			})
+		}
+
+		It("does not treat other statuses as a route mismatch", func() {
+			fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusInternalServerError, nil))
+
+			err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+			Expect(errors.Is(err, ErrRouteMismatch)).To(BeFalse())
+		})
+	})
+
+	Describe("proxy authentication failures", func() {
+		var (
+			logger     *lagertest.TestLogger
+			fakeServer *ghttp.Server
+			client     ExtendedClient
+		)
+
+		BeforeEach(func() {
+			logger = lagertest.NewTestLogger("test")
+			fakeServer = ghttp.NewServer()
+			client = NewClient(fakeServer.URL())
+		})
+
+		AfterEach(func() {
+			fakeServer.Close()
+		})
+
+		Context("when a proxy responds with 407", func() {
+			BeforeEach(func() {
+				fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusProxyAuthRequired, nil, http.Header{
+					"Proxy-Authenticate": []string{`Basic realm="corp-proxy"`},
+				}))
+			})
+
+			It("returns an error pointing at the proxy credentials", func() {
+				err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+				Expect(errors.Is(err, ErrProxyAuthRequired)).To(BeTrue())
+				Expect(errors.Is(err, ErrRouteMismatch)).To(BeFalse())
+				Expect(err).To(MatchError(`http error: status code 407 (Proxy Authentication Required): check the proxy credentials (Proxy-Authenticate: Basic realm="corp-proxy")`))
+
+				var statusErr *StatusError
+				Expect(errors.As(err, &statusErr)).To(BeTrue())
+				Expect(statusErr.ProxyAuthenticate).To(Equal(`Basic realm="corp-proxy"`))
+			})
+		})
+
+		Context("when the 407 has no Proxy-Authenticate header", func() {
+			BeforeEach(func() {
+				fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusProxyAuthRequired, nil))
+			})
+
+			It("still points at the proxy credentials", func() {
+				err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+				Expect(errors.Is(err, ErrProxyAuthRequired)).To(BeTrue())
+				Expect(err).To(MatchError("http error: status code 407 (Proxy Authentication Required): check the proxy credentials"))
+			})
+		})
+
+		It("does not treat other statuses as a proxy authentication failure", func() {
+			fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusUnauthorized, nil))
+
+			err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+			Expect(errors.Is(err, ErrProxyAuthRequired)).To(BeFalse())
+		})
+	})
+
+	Describe("IsRetryable", func() {
+		It("does not retry hosts that do not exist", func() {
+			err := &DNSResolutionError{Host: "some-host", Err: &net.DNSError{IsNotFound: true}}
+			Expect(IsRetryable(err)).To(BeFalse())
+		})
+
+		It("retries temporary resolution failures", func() {
+			err := &DNSResolutionError{Host: "some-host", Err: &net.DNSError{IsTemporary: true}}
+			Expect(IsRetryable(err)).To(BeTrue())
+		})
+
+		It("retries connection failures", func() {
+			err := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+			Expect(IsRetryable(err)).To(BeTrue())
+		})
+
+		It("does not retry canceled requests", func() {
+			Expect(IsRetryable(context.Canceled)).To(BeFalse())
+			Expect(IsRetryable(context.DeadlineExceeded)).To(BeFalse())
+		})
+
+		It("retries reset and dropped connections", func() {
+			Expect(IsRetryable(&url.Error{Op: "Post", Err: syscall.ECONNRESET})).To(BeTrue())
+			Expect(IsRetryable(&url.Error{Op: "Post", Err: io.EOF})).To(BeTrue())
+		})
+
+		It("does not retry TLS alerts from the server", func() {
+			err := &url.Error{Op: "Post", Err: &net.OpError{Op: "remote error", Err: errors.New("tls: bad certificate")}}
+			Expect(IsRetryable(err)).To(BeFalse())
+		})
+
+		Context("against a real server", func() {
+			var (
+				logger     *lagertest.TestLogger
+				fakeServer *ghttp.Server
+			)
+
+			BeforeEach(func() {
+				logger = lagertest.NewTestLogger("test")
+			})
+
+			AfterEach(func() {
+				fakeServer.Close()
+			})
+
+			It("does not retry a TLS verification failure", func() {
+				fakeServer = ghttp.NewTLSServer()
+				client := NewClient(fakeServer.URL(), WithRetries(3))
+
+				err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+				Expect(err).To(HaveOccurred())
+				Expect(IsRetryable(err)).To(BeFalse())
+				Expect(client.Stats().Retries).To(BeZero())
+			})
+
+			It("does not retry an HTTPS request to an HTTP server", func() {
+				fakeServer = ghttp.NewServer()
+				client := NewClient(strings.Replace(fakeServer.URL(), "http://", "https://", 1), WithRetries(3))
+
+				err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+				Expect(err).To(MatchError(ContainSubstring("server gave HTTP response to HTTPS client")))
+				Expect(IsRetryable(err)).To(BeFalse())
+				Expect(client.Stats().Retries).To(BeZero())
+			})
+		})
+
+		It("does not retry other errors", func() {
+			Expect(IsRetryable(nil)).To(BeFalse())
+			Expect(IsRetryable(errors.New("boom"))).To(BeFalse())
+		})
+	})
+})
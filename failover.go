@@ -0,0 +1,355 @@
+package auctioneer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagerctx"
+)
+
+// ErrNoHealthyBackend is returned, without sending anything, by a client
+// configured with WithFailover and WithFailFast when every backend is
+// marked unhealthy.
+var ErrNoHealthyBackend = errors.New("no healthy auctioneer backend")
+
+// WithFailover submits auction batches to backends, the base URLs of
+// interchangeable auctioneers, instead of the client's URL. Each batch goes
+// to the first healthy backend in order and fails over to the next when the
+// backend is unreachable or answers 502 or 503; the error of the last
+// backend tried is returned when none accepts it. A backend that fails this
+// way is marked unhealthy and tried only after the healthy ones until it
+// accepts a batch again or WithHealthProbe finds it reachable.
+// WithBackendSelector replaces this order with a strategy of its own, and
+// WithHedging sends slow LRP batches to more than one backend at once.
+//
+// Failing over follows the same rule as WithRetries: a batch that was
+// written to a backend's connection before it failed may already have been
+// scheduled, so it is only sent to the next backend with WithRetryAfterSend.
+// Requests made with ContextWithTargetURL, including the shards of
+// WithSharding, go to their target without failing over.
+func WithFailover(backends []string) ClientOption {
+	return func(c *auctioneerClient) {
+		c.failover = &failover{backends: backends, health: newBackendHealth()}
+	}
+}
+
+// WithFailFast makes a client configured with WithFailover fail with
+// ErrNoHealthyBackend when every backend is marked unhealthy, rather than
+// trying each of them in turn, and never send batches to unhealthy backends
+// while a healthy one remains. Combine it with WithHealthProbe, without
+// which an unhealthy backend is not tried again.
+func WithFailFast() ClientOption {
+	return func(c *auctioneerClient) {
+		c.failoverFailFast = true
+	}
+}
+
+// WithWeightedFailover makes a client configured with WithFailover choose
+// where a batch goes after its first backend fails at random, rather than in
+// configured order, favoring backends that recently accepted batches over
+// those that recently failed them. During a partial outage this steers
+// failovers away from other degraded backends. The first backend tried, and
+// trying healthy backends before unhealthy ones, are unchanged.
+func WithWeightedFailover() ClientOption {
+	return func(c *auctioneerClient) {
+		c.failoverWeighted = true
+	}
+}
+
+// WithHealthProbe makes a client configured with WithFailover probe each
+// unhealthy backend every interval, with the same request as Ping, and mark
+// it healthy again once it responds. Probing a backend stops as soon as it
+// is healthy.
+func WithHealthProbe(interval time.Duration) ClientOption {
+	return func(c *auctioneerClient) {
+		c.healthProbeInterval = interval
+	}
+}
+
+// WithHealthProbeJitter delays each probe made by WithHealthProbe by a
+// random extra duration of up to spread, so that many clients that lost the
+// same backend at once do not all probe it in lockstep.
+func WithHealthProbeJitter(spread time.Duration) ClientOption {
+	return func(c *auctioneerClient) {
+		c.healthProbeJitter = spread
+	}
+}
+
+type failover struct {
+	backends []string
+	health   *backendHealth
+}
+
+const (
+	// successRateDecay is how much each batch moves a backend's success
+	// rate toward its outcome.
+	successRateDecay = 0.2
+	// minFailoverWeight keeps a backend that has failed every recent batch
+	// eligible for weighted failover.
+	minFailoverWeight = 0.05
+)
+
+// backendHealth tracks which backends are unhealthy and which of those are
+// being probed, and the recent success rate of each.
+type backendHealth struct {
+	lock      sync.Mutex
+	unhealthy map[string]bool
+	probing   map[string]bool
+	// failureRate is the complement of the success rate, so that backends
+	// without a recorded outcome start out fully successful.
+	failureRate map[string]float64
+}
+
+func newBackendHealth() *backendHealth {
+	return &backendHealth{unhealthy: map[string]bool{}, probing: map[string]bool{}, failureRate: map[string]float64{}}
+}
+
+// recordOutcome folds whether backend accepted a batch into its recent
+// success rate.
+func (h *backendHealth) recordOutcome(backend string, accepted bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	outcome := 1.0
+	if accepted {
+		outcome = 0
+	}
+	h.failureRate[backend] += successRateDecay * (outcome - h.failureRate[backend])
+}
+
+// weightedShuffle reorders backends at random, each position drawn with
+// probability proportional to the recent success rate of the backends left.
+func (h *backendHealth) weightedShuffle(backends []string) {
+	if len(backends) < 2 {
+		return
+	}
+
+	h.lock.Lock()
+	weights := make([]float64, len(backends))
+	for i, backend := range backends {
+		weights[i] = math.Max(1-h.failureRate[backend], minFailoverWeight)
+	}
+	h.lock.Unlock()
+
+	for i := range backends {
+		total := 0.0
+		for _, weight := range weights[i:] {
+			total += weight
+		}
+
+		pick := len(backends) - 1
+		r := rand.Float64() * total
+		for j := i; j < len(backends); j++ {
+			r -= weights[j]
+			if r < 0 {
+				pick = j
+				break
+			}
+		}
+
+		backends[i], backends[pick] = backends[pick], backends[i]
+		weights[i], weights[pick] = weights[pick], weights[i]
+	}
+}
+
+func (h *backendHealth) isHealthy(backend string) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return !h.unhealthy[backend]
+}
+
+func (h *backendHealth) markHealthy(backend string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	delete(h.unhealthy, backend)
+}
+
+func (h *backendHealth) markUnhealthy(backend string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.unhealthy[backend] = true
+}
+
+// startProbing reports whether the caller should start probing backend,
+// which no one else is probing.
+func (h *backendHealth) startProbing(backend string) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.probing[backend] {
+		return false
+	}
+	h.probing[backend] = true
+	return true
+}
+
+// recovered marks a probed backend healthy and ends its probing.
+func (h *backendHealth) recovered(backend string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	delete(h.unhealthy, backend)
+	delete(h.probing, backend)
+}
+
+// order returns the backends to try, the healthy ones first, each group in
+// configured order. Unhealthy backends are left out when failFast is set.
+// When weighted is set, the backends after the first are shuffled within
+// their group by recent success rate.
+func (f *failover) order(failFast, weighted bool) []string {
+	healthy := make([]string, 0, len(f.backends))
+	var unhealthy []string
+	for _, backend := range f.backends {
+		if f.health.isHealthy(backend) {
+			healthy = append(healthy, backend)
+		} else if !failFast {
+			unhealthy = append(unhealthy, backend)
+		}
+	}
+
+	if weighted {
+		if len(healthy) > 0 {
+			f.health.weightedShuffle(healthy[1:])
+			f.health.weightedShuffle(unhealthy)
+		} else if len(unhealthy) > 0 {
+			f.health.weightedShuffle(unhealthy[1:])
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+func (c *auctioneerClient) requestAuctionsWithFailover(logger lager.Logger, ctx context.Context, ar auctionRequest) (AuctionResult, error) {
+	nextBackend := c.backendSequence(ctx)
+	backend, err := nextBackend()
+	if err != nil {
+		return AuctionResult{}, err
+	}
+	if backend == "" {
+		return AuctionResult{}, ErrNoHealthyBackend
+	}
+
+	var raw []byte
+	if ar.raw != nil {
+		// every backend tried needs the whole batch
+		raw, err = ioutil.ReadAll(ar.raw)
+		if err != nil {
+			return AuctionResult{}, err
+		}
+	}
+
+	if c.maxHedges > 0 && ar.operation == OperationLRP && !ar.dryRun {
+		return c.requestAuctionsHedged(logger, ctx, ar, raw, backend, nextBackend)
+	}
+
+	var lastErr error
+	for backend != "" {
+		result, failOver, err := c.attemptBackend(logger, ctx, ar, raw, backend)
+		if err == nil {
+			return result, nil
+		}
+		if !failOver {
+			return AuctionResult{}, err
+		}
+		lastErr = err
+
+		backend, err = nextBackend()
+		if err != nil {
+			return AuctionResult{}, err
+		}
+	}
+
+	return AuctionResult{}, lastErr
+}
+
+// attemptBackend submits ar, with a body over raw when it is not nil, to
+// backend, recording the outcome in the backend's health, and reports
+// whether a batch that failed may be sent to the next backend.
+func (c *auctioneerClient) attemptBackend(logger lager.Logger, ctx context.Context, ar auctionRequest, raw []byte, backend string) (AuctionResult, bool, error) {
+	if raw != nil {
+		ar.raw = bytes.NewReader(raw)
+	}
+
+	var sent int32
+	backendCtx := httptrace.WithClientTrace(ContextWithTargetURL(ctx, backend), &httptrace.ClientTrace{
+		WroteHeaderField: func(string, []string) {
+			atomic.StoreInt32(&sent, 1)
+		},
+	})
+
+	result, err := c.requestAuctions(logger, backendCtx, ar)
+	if err == nil {
+		c.failover.health.markHealthy(backend)
+		c.failover.health.recordOutcome(backend, true)
+		return result, false, nil
+	}
+
+	if !c.shouldFailOver(err, atomic.LoadInt32(&sent) == 1) {
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) {
+			// the backend answered, so it is up
+			c.failover.health.markHealthy(backend)
+		}
+		return AuctionResult{}, false, err
+	}
+
+	c.logError(logger, "failing-over", err, lager.Data{"backend": backend})
+	c.failover.health.markUnhealthy(backend)
+	c.failover.health.recordOutcome(backend, false)
+	if c.healthProbeInterval > 0 && c.failover.health.startProbing(backend) {
+		go c.probeBackend(backend)
+	}
+	return AuctionResult{}, true, err
+}
+
+// shouldFailOver reports whether a batch that failed with err on one
+// backend should be sent to the next.
+func (c *auctioneerClient) shouldFailOver(err error, sent bool) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusBadGateway || statusErr.StatusCode == http.StatusServiceUnavailable
+	}
+
+	return IsRetryable(err) && (!sent || c.retryAfterSend)
+}
+
+// probeBackend pings backend every probe interval, jittered, until it
+// responds or the client is closed.
+func (c *auctioneerClient) probeBackend(backend string) {
+	for {
+		timer := c.clock.NewTimer(c.probeDelay())
+		select {
+		case <-timer.C():
+		case <-c.requests.done():
+			timer.Stop()
+			return
+		}
+		if c.requests.isClosed() {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(ContextWithTargetURL(context.Background(), backend), c.healthProbeInterval)
+		_, err := c.ping(lagerctx.FromContext(ctx), ctx)
+		cancel()
+
+		if err == nil {
+			c.failover.health.recovered(backend)
+			return
+		}
+	}
+}
+
+func (c *auctioneerClient) probeDelay() time.Duration {
+	if c.healthProbeJitter <= 0 {
+		return c.healthProbeInterval
+	}
+	return c.healthProbeInterval + time.Duration(rand.Int63n(int64(c.healthProbeJitter)))
+}
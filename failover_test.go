@@ -0,0 +1,273 @@
+package auctioneer_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("WithFailover", func() {
+	var (
+		logger  *lagertest.TestLogger
+		primary *ghttp.Server
+		standby *ghttp.Server
+		client  ExtendedClient
+	)
+
+	newFailoverClient := func(opts ...ClientOption) ExtendedClient {
+		opts = append([]ClientOption{WithFailover([]string{primary.URL(), standby.URL()})}, opts...)
+		return NewClient("http://unused.example.com", opts...)
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		primary = ghttp.NewServer()
+		standby = ghttp.NewServer()
+		standby.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+		client = newFailoverClient()
+	})
+
+	AfterEach(func() {
+		primary.Close()
+		standby.Close()
+	})
+
+	It("submits to the first backend", func() {
+		primary.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(primary.ReceivedRequests()).To(HaveLen(1))
+		Expect(standby.ReceivedRequests()).To(BeEmpty())
+	})
+
+	It("fails over when a backend cannot be reached", func() {
+		primary.Close()
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(standby.ReceivedRequests()).To(HaveLen(1))
+		Expect(logger).To(gbytes.Say("failing-over"))
+	})
+
+	Context("when a backend is unavailable", func() {
+		BeforeEach(func() {
+			primary.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusServiceUnavailable, "{}"))
+		})
+
+		It("fails over and tries the unhealthy backend last", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+
+			Expect(primary.ReceivedRequests()).To(HaveLen(1))
+			Expect(standby.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("returns the last backend's error when every backend fails", func() {
+			standby.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusBadGateway, "{}"))
+
+			err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+			var statusErr *StatusError
+			Expect(errors.As(err, &statusErr)).To(BeTrue())
+			Expect(statusErr.StatusCode).To(Equal(http.StatusBadGateway))
+		})
+	})
+
+	It("does not fail over on other error statuses", func() {
+		primary.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusBadRequest, "{}"))
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+		Expect(standby.ReceivedRequests()).To(BeEmpty())
+	})
+
+	Context("when the connection fails after the batch is sent", func() {
+		BeforeEach(func() {
+			primary.RouteToHandler("POST", "/v1/lrps", closeConnection)
+		})
+
+		It("does not fail over by default", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+			Expect(standby.ReceivedRequests()).To(BeEmpty())
+		})
+
+		It("fails over with WithRetryAfterSend", func() {
+			client = newFailoverClient(WithRetryAfterSend())
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			Expect(standby.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+
+	It("fails over a batch refused before it is sent while capabilities are not yet cached", func() {
+		primary.RouteToHandler("OPTIONS", "/", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Connection", "close")
+			w.Write([]byte(`{"max_payload_bytes": 1048576}`))
+		})
+		standby.RouteToHandler("OPTIONS", "/", ghttp.RespondWith(http.StatusOK, "{}"))
+
+		// the capabilities request dials the primary; the batch's dial is
+		// refused
+		var primaryDials int32
+		client = newFailoverClient(WithServerPayloadLimit(), WithTransportConfig(func(tr *http.Transport) {
+			dialer := &net.Dialer{}
+			tr.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				if addr == primary.Addr() && atomic.AddInt32(&primaryDials, 1) > 1 {
+					return nil, &net.OpError{Op: "dial", Net: network, Err: errors.New("connection refused")}
+				}
+				return dialer.DialContext(ctx, network, addr)
+			}
+		}))
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(primary.ReceivedRequests()).To(HaveLen(1))
+		Expect(standby.ReceivedRequests()).To(HaveLen(2))
+	})
+
+	It("sends requests with a target URL to their target", func() {
+		primary.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusServiceUnavailable, "{}"))
+
+		ctx := ContextWithTargetURL(context.Background(), primary.URL())
+		_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+		Expect(err).To(HaveOccurred())
+		Expect(standby.ReceivedRequests()).To(BeEmpty())
+	})
+
+	Describe("WithFailFast", func() {
+		BeforeEach(func() {
+			primary.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusServiceUnavailable, "{}"))
+			standby.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusServiceUnavailable, "{}"))
+			client = newFailoverClient(WithFailFast())
+		})
+
+		It("fails without sending once every backend is unhealthy", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+
+			err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+			Expect(err).To(Equal(ErrNoHealthyBackend))
+			Expect(primary.ReceivedRequests()).To(HaveLen(1))
+			Expect(standby.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+
+	Describe("WithHealthProbe", func() {
+		var fakeClock *fakeclock.FakeClock
+
+		BeforeEach(func() {
+			fakeClock = fakeclock.NewFakeClock(time.Now())
+			primary.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusServiceUnavailable, "{}"))
+			primary.RouteToHandler("HEAD", "/", ghttp.RespondWith(http.StatusOK, nil))
+			client = newFailoverClient(WithFailFast(), WithHealthProbe(time.Second), WithClock(fakeClock))
+		})
+
+		It("resumes sending to a backend once it responds to a probe", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			Expect(standby.ReceivedRequests()).To(HaveLen(1))
+
+			primary.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(primary.ReceivedRequests).Should(HaveLen(2))
+
+			// the batch goes to the standby until the probe has succeeded
+			Eventually(func() *http.Request {
+				Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+				requests := primary.ReceivedRequests()
+				return requests[len(requests)-1]
+			}).Should(HaveField("Method", "POST"))
+			Expect(primary.ReceivedRequests()).To(HaveLen(3))
+		})
+
+		It("stops probing once the client is closed", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+
+			Expect(client.Close()).To(Succeed())
+			Eventually(fakeClock.WatcherCount).Should(Equal(0))
+
+			fakeClock.Increment(time.Second)
+			Consistently(primary.ReceivedRequests).Should(HaveLen(1))
+		})
+
+		Context("with WithHealthProbeJitter", func() {
+			BeforeEach(func() {
+				client = newFailoverClient(WithFailFast(), WithHealthProbe(time.Second), WithHealthProbeJitter(time.Minute), WithClock(fakeClock))
+			})
+
+			It("probes after the interval and up to the spread more", func() {
+				Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+				Expect(primary.ReceivedRequests()).To(HaveLen(1))
+
+				fakeClock.WaitForWatcherAndIncrement(time.Second)
+				Consistently(primary.ReceivedRequests).Should(HaveLen(1))
+
+				fakeClock.Increment(time.Minute)
+				Eventually(primary.ReceivedRequests).Should(HaveLen(2))
+				Expect(primary.ReceivedRequests()[1].Method).To(Equal("HEAD"))
+			})
+		})
+	})
+
+	Describe("WithWeightedFailover", func() {
+		var (
+			fakeClock *fakeclock.FakeClock
+			flaky     *ghttp.Server
+		)
+
+		countRequests := func(server *ghttp.Server, method string) int {
+			count := 0
+			for _, request := range server.ReceivedRequests() {
+				if request.Method == method {
+					count++
+				}
+			}
+			return count
+		}
+
+		BeforeEach(func() {
+			fakeClock = fakeclock.NewFakeClock(time.Now())
+			flaky = ghttp.NewServer()
+			for _, server := range []*ghttp.Server{primary, flaky} {
+				server.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusServiceUnavailable, "{}"))
+				server.RouteToHandler("HEAD", "/", ghttp.RespondWith(http.StatusOK, nil))
+			}
+
+			client = NewClient("http://unused.example.com",
+				WithFailover([]string{primary.URL(), flaky.URL(), standby.URL()}),
+				WithWeightedFailover(),
+				WithHealthProbe(time.Second),
+				WithClock(fakeClock),
+			)
+		})
+
+		AfterEach(func() {
+			flaky.Close()
+		})
+
+		It("fails over less often to a backend that keeps failing", func() {
+			const batches = 30
+			for i := 0; i < batches; i++ {
+				Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+
+				// wait for the probes to find every backend that failed
+				// reachable again, so that it is healthy for the next batch
+				Eventually(func() bool {
+					fakeClock.Increment(time.Second)
+					return countRequests(primary, "HEAD") == countRequests(primary, "POST") &&
+						countRequests(flaky, "HEAD") == countRequests(flaky, "POST")
+				}).Should(BeTrue())
+			}
+
+			Expect(countRequests(primary, "POST")).To(BeNumerically(">", batches/2))
+			Expect(countRequests(flaky, "POST")).To(BeNumerically("<", batches/2))
+			Expect(countRequests(standby, "POST")).To(Equal(batches))
+		})
+	})
+})
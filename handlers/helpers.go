@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+
+	"code.cloudfoundry.org/auctioneer"
 )
 
 func writeInvalidJSONResponse(w http.ResponseWriter, err error) {
@@ -26,6 +28,17 @@ func writeStatusAcceptedResponse(w http.ResponseWriter) {
 	writeJSONResponse(w, http.StatusAccepted, struct{}{})
 }
 
+func writeDryRunResponse(w http.ResponseWriter, valid int, rejected []auctioneer.DryRunRejection) {
+	writeJSONResponse(w, http.StatusOK, auctioneer.DryRunResult{
+		Valid:    valid,
+		Rejected: rejected,
+	})
+}
+
+func isDryRun(r *http.Request) bool {
+	return r.Header.Get(auctioneer.DryRunHeader) == "true"
+}
+
 func writeJSONResponse(w http.ResponseWriter, statusCode int, jsonObj interface{}) {
 	jsonBytes, err := json.Marshal(jsonObj)
 	if err != nil {
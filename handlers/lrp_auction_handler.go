@@ -43,6 +43,7 @@ func (h *LRPAuctionHandler) Create(w http.ResponseWriter, r *http.Request, logge
 	}
 
 	validStarts := make([]auctioneer.LRPStartRequest, 0, len(starts))
+	rejected := []auctioneer.DryRunRejection{}
 	lrpGuids := make(map[string][]int)
 	for i := range starts {
 		start := &starts[i]
@@ -53,9 +54,16 @@ func (h *LRPAuctionHandler) Create(w http.ResponseWriter, r *http.Request, logge
 			lrpGuids[start.ProcessGuid] = indices
 		} else {
 			logger.Error("start-validate-failed", err, lager.Data{"lrp-start": start})
+			rejected = append(rejected, auctioneer.DryRunRejection{Index: i, Error: err.Error(), Reason: auctioneer.RejectionReasonInvalidRequest})
 		}
 	}
 
+	if isDryRun(r) {
+		logger.Info("dry-run", lager.Data{"valid": len(validStarts), "rejected": len(rejected)})
+		writeDryRunResponse(w, len(validStarts), rejected)
+		return
+	}
+
 	h.runner.ScheduleLRPsForAuctions(validStarts)
 
 	logLRPGuids(lrpGuids, logger)
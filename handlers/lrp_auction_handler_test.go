@@ -74,6 +74,42 @@ var _ = Describe("LRPAuctionHandler", func() {
 			})
 		})
 
+		Context("when the request is a dry run", func() {
+			BeforeEach(func() {
+				starts := []auctioneer.LRPStartRequest{{
+					Indices:     []int{2, 3},
+					Domain:      "tests",
+					ProcessGuid: "some-guid",
+					PlacementConstraint: rep.PlacementConstraint{
+						RootFs: "docker:///docker.com/docker",
+					},
+				}, {
+					Domain:      "tests",
+					ProcessGuid: "some-other-guid",
+				}}
+
+				req := newTestRequest(starts)
+				req.Header.Set(auctioneer.DryRunHeader, "true")
+				handler.Create(responseRecorder, req, logger)
+			})
+
+			It("responds with 200", func() {
+				Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			})
+
+			It("responds with the validation outcome", func() {
+				result := auctioneer.DryRunResult{}
+				err := json.NewDecoder(responseRecorder.Body).Decode(&result)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Valid).To(Equal(1))
+				Expect(result.Rejected).To(ConsistOf(auctioneer.DryRunRejection{Index: 1, Error: "indices must not be empty", Reason: auctioneer.RejectionReasonInvalidRequest}))
+			})
+
+			It("should not submit the start auction to the auction runner", func() {
+				Expect(runner.ScheduleLRPsForAuctionsCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("when the start auction has invalid index", func() {
 			var start auctioneer.LRPStartRequest
 
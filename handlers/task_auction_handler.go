@@ -43,6 +43,7 @@ func (h *TaskAuctionHandler) Create(w http.ResponseWriter, r *http.Request, logg
 	}
 
 	validTasks := make([]auctioneer.TaskStartRequest, 0, len(tasks))
+	rejected := []auctioneer.DryRunRejection{}
 	taskGuids := make([]string, 0, len(tasks))
 	for i := range tasks {
 		t := &tasks[i]
@@ -51,9 +52,16 @@ func (h *TaskAuctionHandler) Create(w http.ResponseWriter, r *http.Request, logg
 			taskGuids = append(taskGuids, t.TaskGuid)
 		} else {
 			logger.Error("task-validate-failed", err, lager.Data{"task": t})
+			rejected = append(rejected, auctioneer.DryRunRejection{Index: i, Error: err.Error(), Reason: auctioneer.RejectionReasonInvalidRequest})
 		}
 	}
 
+	if isDryRun(r) {
+		logger.Info("dry-run", lager.Data{"valid": len(validTasks), "rejected": len(rejected)})
+		writeDryRunResponse(w, len(validTasks), rejected)
+		return
+	}
+
 	h.runner.ScheduleTasksForAuctions(validTasks)
 
 	logger.Info("submitted", lager.Data{"tasks": taskGuids})
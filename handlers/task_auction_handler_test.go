@@ -61,6 +61,35 @@ var _ = Describe("TaskAuctionHandler", func() {
 			})
 		})
 
+		Context("when the request is a dry run", func() {
+			BeforeEach(func() {
+				resource := rep.NewResource(1, 2, 3)
+				pc := rep.NewPlacementConstraint("rootfs", []string{}, []string{})
+				task := rep.NewTask("the-task-guid", "test", resource, pc)
+				tasks := []auctioneer.TaskStartRequest{auctioneer.TaskStartRequest{task}, auctioneer.TaskStartRequest{rep.Task{}}}
+
+				req := newTestRequest(tasks)
+				req.Header.Set(auctioneer.DryRunHeader, "true")
+				handler.Create(responseRecorder, req, logger)
+			})
+
+			It("responds with 200", func() {
+				Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			})
+
+			It("responds with the validation outcome", func() {
+				result := auctioneer.DryRunResult{}
+				err := json.NewDecoder(responseRecorder.Body).Decode(&result)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Valid).To(Equal(1))
+				Expect(result.Rejected).To(ConsistOf(auctioneer.DryRunRejection{Index: 1, Error: "task guid is empty", Reason: auctioneer.RejectionReasonInvalidRequest}))
+			})
+
+			It("should not submit the task to the auction runner", func() {
+				Expect(runner.ScheduleTasksForAuctionsCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("when the request body is a not a valid task", func() {
 			var tasks []auctioneer.TaskStartRequest
 
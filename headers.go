@@ -0,0 +1,113 @@
+package auctioneer
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RequestTimeoutHeader tells the auctioneer how many milliseconds remain
+// before the client abandons the request, so that it can bound its own
+// processing. It is sent only when the request context has a deadline.
+const RequestTimeoutHeader = "X-Request-Timeout"
+
+// ReplicaReadHeader, sent with the value "true" by a client configured with
+// WithReplicaReads, tells the auctioneer that a request may be answered by a
+// read replica rather than the primary.
+const ReplicaReadHeader = "X-Allow-Replica-Read"
+
+var replicaReadHeaderValue = []string{"true"}
+
+// WithReplicaReads marks Ping, Warmup and Capabilities requests with
+// ReplicaReadHeader, so that an auctioneer with read replicas can answer
+// them off the primary. Auction submissions, dry runs and batch status
+// polls never carry it: they must see the primary's state.
+func WithReplicaReads() ClientOption {
+	return func(c *auctioneerClient) {
+		c.replicaReads = true
+	}
+}
+
+// WithContextHeaders sends the value stored in the request context under
+// each key of headers as the named header, so that identifiers such as a
+// tenant or org propagated through the context reach the auctioneer. Values
+// must be strings or fmt.Stringers; keys missing from the context are
+// skipped.
+func WithContextHeaders(headers map[interface{}]string) ClientOption {
+	return func(c *auctioneerClient) {
+		if c.contextHeaders == nil {
+			c.contextHeaders = map[interface{}]string{}
+		}
+		for key, header := range headers {
+			c.contextHeaders[key] = header
+		}
+	}
+}
+
+// WithConstantTags sends each value of tags as the header named by its key,
+// such as a deploy version or cell ID, on every request, so the auctioneer's
+// logs can be correlated with the client without a tracer. Headers set by
+// the client itself or by WithContextHeaders take precedence.
+func WithConstantTags(tags map[string]string) ClientOption {
+	return func(c *auctioneerClient) {
+		if c.constantTags == nil {
+			c.constantTags = map[string]string{}
+		}
+		for header, value := range tags {
+			c.constantTags[header] = value
+		}
+	}
+}
+
+// WithContentType sends auction batches with contentType, such as a vendor
+// media type required by a gateway in front of the auctioneer, in place of
+// application/json. The body is JSON regardless.
+func WithContentType(contentType string) ClientOption {
+	return func(c *auctioneerClient) {
+		if contentType != "" {
+			c.contentType = []string{contentType}
+		}
+	}
+}
+
+// WithoutContentType sends auction batches without a Content-Type header,
+// for gateways that reject requests carrying one. Batches streamed with
+// WithNDJSONStreaming still declare their content type, which the
+// auctioneer needs to read them.
+func WithoutContentType() ClientOption {
+	return func(c *auctioneerClient) {
+		c.contentType = nil
+	}
+}
+
+// setRequestHeaders adds the headers derived from the client's options and
+// the request context to req.
+func (c *auctioneerClient) setRequestHeaders(req *http.Request) {
+	c.injectMetadata(req.Context(), HTTPHeaderCarrier(req.Header))
+
+	// Only pings (HEAD) and capability queries (OPTIONS) may be answered
+	// by a replica.
+	if c.replicaReads && (req.Method == http.MethodHead || req.Method == http.MethodOptions) {
+		req.Header[ReplicaReadHeader] = replicaReadHeaderValue
+	}
+}
+
+// setRequestTimeoutHeader is called again before each retry, so the header
+// reflects the time remaining for that attempt.
+func setRequestTimeoutHeader(req *http.Request) {
+	injectRequestTimeout(req.Context(), HTTPHeaderCarrier(req.Header))
+}
+
+func injectRequestTimeout(ctx context.Context, carrier MetadataCarrier) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return
+	}
+
+	remaining := time.Until(deadline).Milliseconds()
+	if remaining < 0 {
+		remaining = 0
+	}
+	carrier.Set(RequestTimeoutHeader, strconv.FormatInt(remaining, 10))
+}
@@ -0,0 +1,268 @@
+package auctioneer_test
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+type tenantKey struct{}
+
+type spaceID string
+
+func (s spaceID) String() string { return "space-" + string(s) }
+
+type spaceKey struct{}
+
+var _ = Describe("Request headers", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		client     ExtendedClient
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	Describe("WithContextHeaders", func() {
+		BeforeEach(func() {
+			client = NewClient(fakeServer.URL(), WithContextHeaders(map[interface{}]string{
+				tenantKey{}: "X-Tenant-Id",
+				spaceKey{}:  "X-Space-Id",
+			}))
+		})
+
+		It("sends the context values as headers", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV("X-Tenant-Id", "some-org"),
+				ghttp.VerifyHeaderKV("X-Space-Id", "space-some-space"),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+
+			ctx := context.WithValue(context.Background(), tenantKey{}, "some-org")
+			ctx = context.WithValue(ctx, spaceKey{}, spaceID("some-space"))
+
+			_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("omits headers for values missing from the context", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				func(w http.ResponseWriter, r *http.Request) {
+					Expect(r.Header).NotTo(HaveKey("X-Tenant-Id"))
+					Expect(r.Header).NotTo(HaveKey("X-Space-Id"))
+				},
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		})
+	})
+
+	Describe("WithConstantTags", func() {
+		BeforeEach(func() {
+			client = NewClient(fakeServer.URL(),
+				WithConstantTags(map[string]string{
+					"X-Deploy-Version": "v42",
+					"X-Cell-Id":        "cell-1",
+					"X-Tenant-Id":      "constant-org",
+				}),
+				WithContextHeaders(map[interface{}]string{tenantKey{}: "X-Tenant-Id"}),
+			)
+		})
+
+		It("sends the tags as headers on every request", func() {
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("X-Deploy-Version", "v42"),
+					ghttp.VerifyHeaderKV("X-Cell-Id", "cell-1"),
+					ghttp.RespondWith(http.StatusAccepted, "{}"),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("HEAD", "/"),
+					ghttp.VerifyHeaderKV("X-Deploy-Version", "v42"),
+					ghttp.VerifyHeaderKV("X-Cell-Id", "cell-1"),
+					ghttp.RespondWith(http.StatusOK, nil),
+				),
+			)
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			_, err := client.Ping(logger, context.Background())
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("lets context headers override a tag", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV("X-Tenant-Id", "some-org"),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+
+			ctx := context.WithValue(context.Background(), tenantKey{}, "some-org")
+			_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("does not replace the content type", func() {
+			client = NewClient(fakeServer.URL(), WithConstantTags(map[string]string{"content-type": "text/plain"}))
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyContentType("application/json"),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		})
+	})
+
+	Describe("WithReplicaReads", func() {
+		BeforeEach(func() {
+			client = NewClient(fakeServer.URL(), WithReplicaReads())
+		})
+
+		It("marks pings and capability queries", func() {
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("HEAD", "/"),
+					ghttp.VerifyHeaderKV(ReplicaReadHeader, "true"),
+					ghttp.RespondWith(http.StatusOK, nil),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("OPTIONS", "/"),
+					ghttp.VerifyHeaderKV(ReplicaReadHeader, "true"),
+					ghttp.RespondWith(http.StatusOK, "{}"),
+				),
+			)
+
+			_, err := client.Ping(logger, context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			_, err = client.Capabilities(logger, context.Background())
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("never marks auctions, dry runs or batch status polls", func() {
+			withoutHint := func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Header).NotTo(HaveKey(ReplicaReadHeader))
+			}
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(withoutHint, ghttp.RespondWith(http.StatusAccepted, "{}")),
+				ghttp.CombineHandlers(withoutHint, ghttp.RespondWith(http.StatusAccepted, "{}")),
+				ghttp.CombineHandlers(withoutHint, ghttp.RespondWith(http.StatusOK, "{}")),
+				ghttp.CombineHandlers(withoutHint, ghttp.RespondWith(http.StatusOK, `{"state":"complete"}`)),
+			)
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).To(Succeed())
+			_, err := client.DryRunLRPAuctions(logger, context.Background(), []*LRPStartRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = client.WaitForBatch(logger, context.Background(), fakeServer.URL()+"/v1/batches/some-batch")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(4))
+		})
+
+		It("is off by default", func() {
+			client = NewClient(fakeServer.URL())
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				func(w http.ResponseWriter, r *http.Request) {
+					Expect(r.Header).NotTo(HaveKey(ReplicaReadHeader))
+				},
+				ghttp.RespondWith(http.StatusOK, nil),
+			))
+
+			_, err := client.Ping(logger, context.Background())
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("WithContentType", func() {
+		It("sends application/json by default", func() {
+			client = NewClient(fakeServer.URL())
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyContentType("application/json"),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		})
+
+		It("sends the configured content type", func() {
+			client = NewClient(fakeServer.URL(), WithContentType("application/vnd.cf.auction+json"))
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyContentType("application/vnd.cf.auction+json"),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+
+			Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).To(Succeed())
+		})
+	})
+
+	Describe("WithoutContentType", func() {
+		It("sends batches without a content type", func() {
+			client = NewClient(fakeServer.URL(), WithoutContentType())
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				func(w http.ResponseWriter, r *http.Request) {
+					Expect(r.Header).NotTo(HaveKey("Content-Type"))
+				},
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		})
+
+		It("is overridden by a later WithContentType", func() {
+			client = NewClient(fakeServer.URL(), WithoutContentType(), WithContentType("application/vnd.cf.auction+json"))
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyContentType("application/vnd.cf.auction+json"),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		})
+	})
+
+	Describe("request timeout", func() {
+		BeforeEach(func() {
+			client = NewClient(fakeServer.URL())
+		})
+
+		It("sends the time remaining before the context deadline", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				func(w http.ResponseWriter, r *http.Request) {
+					timeout, err := strconv.Atoi(r.Header.Get(RequestTimeoutHeader))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(timeout).To(BeNumerically("~", 5000, 1000))
+				},
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("omits the header when the context has no deadline", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				func(w http.ResponseWriter, r *http.Request) {
+					Expect(r.Header).NotTo(HaveKey(RequestTimeoutHeader))
+				},
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,127 @@
+package auctioneer
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager"
+)
+
+// WithHedging makes a client configured with WithFailover hedge LRP batches
+// to cut tail latency: when the backend a batch went to has not answered
+// within delay, the batch is also sent to the next backend, up to maxHedges
+// times, each delay after the last. The first backend to accept the batch
+// wins and the requests to the others are canceled. A batch still fails
+// over when every backend it is in flight to has failed, and fails with the
+// error of the last one when no backend is left. Task batches and dry runs
+// are never hedged. A maxHedges of 0 or less disables hedging.
+//
+// Hedging is not safe for every deployment: auctions are not idempotent,
+// and a backend whose request is canceled may already have scheduled the
+// batch, so a hedged LRP can be started on more than one auctioneer's
+// cells. Enable it only where the extra instances that result, until
+// convergence removes them, are an acceptable price for latency.
+func WithHedging(delay time.Duration, maxHedges int) ClientOption {
+	return func(c *auctioneerClient) {
+		c.hedgeDelay = delay
+		c.maxHedges = maxHedges
+	}
+}
+
+type hedgedAttempt struct {
+	result   AuctionResult
+	failOver bool
+	err      error
+	response *capturedResponse
+}
+
+// requestAuctionsHedged submits ar, with a body over raw when it is not nil,
+// to backend, and to the backends nextBackend yields as WithHedging
+// describes.
+func (c *auctioneerClient) requestAuctionsHedged(logger lager.Logger, ctx context.Context, ar auctionRequest, raw []byte, backend string, nextBackend func() (string, error)) (AuctionResult, error) {
+	// canceling ctx on return cancels the attempts that lost
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// each backend is attempted at most once, so no attempt blocks on
+	// reporting after the winner has returned
+	attempts := make(chan hedgedAttempt, len(c.failover.backends))
+	launch := func(backend string) {
+		attemptAR := ar
+		if ar.response != nil {
+			// concurrent attempts must not capture into the same response
+			attemptAR.response = &capturedResponse{}
+		}
+		go func() {
+			result, failOver, err := c.attemptBackend(logger, ctx, attemptAR, raw, backend)
+			attempts <- hedgedAttempt{result: result, failOver: failOver, err: err, response: attemptAR.response}
+		}()
+	}
+
+	hedges := 0
+	var timer clock.Timer
+	var hedgeC <-chan time.Time
+	startHedgeTimer := func() {
+		if timer != nil {
+			timer.Stop()
+		}
+		hedgeC = nil
+		if hedges < c.maxHedges {
+			timer = c.clock.NewTimer(c.hedgeDelay)
+			hedgeC = timer.C()
+		}
+	}
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	launch(backend)
+	pending := 1
+	startHedgeTimer()
+
+	var lastErr error
+	for {
+		select {
+		case attempt := <-attempts:
+			pending--
+			if attempt.err == nil {
+				if ar.response != nil {
+					ar.response.resp = attempt.response.resp
+				}
+				return attempt.result, nil
+			}
+			lastErr = attempt.err
+			if pending > 0 {
+				continue
+			}
+			if !attempt.failOver {
+				return AuctionResult{}, lastErr
+			}
+
+		case <-hedgeC:
+			hedges++
+			hedgeC = nil
+		}
+
+		next, err := nextBackend()
+		if err != nil || next == "" {
+			if pending > 0 {
+				continue
+			}
+			if err != nil {
+				return AuctionResult{}, err
+			}
+			return AuctionResult{}, lastErr
+		}
+
+		if pending > 0 {
+			logger.Info("hedging", lager.Data{"backend": next, "hedge": hedges})
+		}
+		launch(next)
+		pending++
+		startHedgeTimer()
+	}
+}
@@ -0,0 +1,126 @@
+package auctioneer_test
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("WithHedging", func() {
+	var (
+		logger  *lagertest.TestLogger
+		primary *ghttp.Server
+		standby *ghttp.Server
+		unblock chan struct{}
+	)
+
+	// slow holds each request until unblock is closed.
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+		w.WriteHeader(http.StatusAccepted)
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		unblock = make(chan struct{})
+		primary = ghttp.NewServer()
+		primary.RouteToHandler("POST", "/v1/lrps", slow)
+		standby = ghttp.NewServer()
+		standby.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+	})
+
+	AfterEach(func() {
+		select {
+		case <-unblock:
+		default:
+			close(unblock)
+		}
+		primary.Close()
+		standby.Close()
+	})
+
+	newHedgingClient := func(delay time.Duration, maxHedges int, backends ...string) ExtendedClient {
+		if backends == nil {
+			backends = []string{primary.URL(), standby.URL()}
+		}
+		return NewClient("http://unused.example.com", WithFailover(backends), WithHedging(delay, maxHedges))
+	}
+
+	It("sends the batch to the next backend when the first is slow, and cancels the loser", func() {
+		client := newHedgingClient(50*time.Millisecond, 1)
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(primary.ReceivedRequests()).To(HaveLen(1))
+		Expect(standby.ReceivedRequests()).To(HaveLen(1))
+		Expect(logger).To(gbytes.Say("hedging"))
+		Eventually(logger).Should(gbytes.Say("context canceled"))
+	})
+
+	It("does not hedge a batch answered within the delay", func() {
+		close(unblock)
+		client := newHedgingClient(time.Hour, 1)
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(primary.ReceivedRequests()).To(HaveLen(1))
+		Expect(standby.ReceivedRequests()).To(BeEmpty())
+	})
+
+	It("sends at most maxHedges hedges", func() {
+		standby.RouteToHandler("POST", "/v1/lrps", slow)
+		third := ghttp.NewServer()
+		defer third.Close()
+		third.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+		client := newHedgingClient(20*time.Millisecond, 1, primary.URL(), standby.URL(), third.URL())
+
+		errs := make(chan error, 1)
+		go func() {
+			errs <- client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+		}()
+		Eventually(standby.ReceivedRequests).Should(HaveLen(1))
+		Consistently(errs, 100*time.Millisecond).ShouldNot(Receive())
+		Expect(third.ReceivedRequests()).To(BeEmpty())
+
+		close(unblock)
+		Eventually(errs).Should(Receive(BeNil()))
+	})
+
+	It("fails over without waiting for the delay when the backend fails", func() {
+		primary.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusServiceUnavailable, "{}"))
+		client := newHedgingClient(time.Hour, 1)
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(standby.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("returns the error of the last backend when every backend fails", func() {
+		primary.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusServiceUnavailable, "{}"))
+		standby.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusBadGateway, "{}"))
+		client := newHedgingClient(time.Hour, 1)
+
+		err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+		var statusErr *StatusError
+		Expect(errors.As(err, &statusErr)).To(BeTrue())
+		Expect(statusErr.StatusCode).To(Equal(http.StatusBadGateway))
+	})
+
+	It("never hedges task batches", func() {
+		primary.RouteToHandler("POST", "/v1/tasks", func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+			w.WriteHeader(http.StatusAccepted)
+		})
+		standby.RouteToHandler("POST", "/v1/tasks", ghttp.RespondWith(http.StatusAccepted, "{}"))
+		client := newHedgingClient(10*time.Millisecond, 1)
+
+		Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).To(Succeed())
+		Expect(primary.ReceivedRequests()).To(HaveLen(1))
+		Expect(standby.ReceivedRequests()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,39 @@
+package auctioneer
+
+import "context"
+
+// IsolationSegmentHeader is the header that carries a request's isolation
+// segment unless WithIsolationSegmentHeader names another.
+const IsolationSegmentHeader = "X-Isolation-Segment"
+
+type isolationSegmentKey struct{}
+
+// ContextWithIsolationSegment returns a context whose requests carry
+// segment in the isolation segment header, so that the auctioneer can
+// restrict their placement to the segment's cells.
+//
+// The segment does not affect WithSharding, which assigns LRPs to backends
+// by process GUID alone, and every shard's request carries the header. To
+// choose backends by segment as well, read it back with
+// IsolationSegmentFromContext and route the batch with ContextWithTargetURL,
+// or give each segment a client sharded across its own backends.
+func ContextWithIsolationSegment(ctx context.Context, segment string) context.Context {
+	return context.WithValue(ctx, isolationSegmentKey{}, segment)
+}
+
+// IsolationSegmentFromContext returns the isolation segment set with
+// ContextWithIsolationSegment, or "" if there is none.
+func IsolationSegmentFromContext(ctx context.Context) string {
+	segment, _ := ctx.Value(isolationSegmentKey{}).(string)
+	return segment
+}
+
+// WithIsolationSegmentHeader sends the isolation segment in the named header
+// instead of IsolationSegmentHeader.
+func WithIsolationSegmentHeader(header string) ClientOption {
+	return func(c *auctioneerClient) {
+		if header != "" {
+			c.isolationSegmentHeader = header
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package auctioneer_test
+
+import (
+	"context"
+	"net/http"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Isolation segments", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		ctx        context.Context
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		ctx = ContextWithIsolationSegment(context.Background(), "some-segment")
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("reads the segment back from the context", func() {
+		Expect(IsolationSegmentFromContext(ctx)).To(Equal("some-segment"))
+		Expect(IsolationSegmentFromContext(context.Background())).To(BeEmpty())
+	})
+
+	It("sends the segment in the isolation segment header", func() {
+		fakeServer.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyHeaderKV(IsolationSegmentHeader, "some-segment"),
+			ghttp.RespondWith(http.StatusAccepted, "{}"),
+		))
+
+		client := NewClient(fakeServer.URL())
+		_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("sends the segment in the configured header", func() {
+		fakeServer.AppendHandlers(ghttp.CombineHandlers(
+			func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Header.Get("X-Segment-Guid")).To(Equal("some-segment"))
+				Expect(r.Header).NotTo(HaveKey(IsolationSegmentHeader))
+			},
+			ghttp.RespondWith(http.StatusAccepted, "{}"),
+		))
+
+		client := NewClient(fakeServer.URL(), WithIsolationSegmentHeader("X-Segment-Guid"))
+		_, err := client.RequestTaskAuctionsWithResult(logger, ctx, []*TaskStartRequest{})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("omits the header without a segment", func() {
+		fakeServer.AppendHandlers(ghttp.CombineHandlers(
+			func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Header).NotTo(HaveKey(IsolationSegmentHeader))
+			},
+			ghttp.RespondWith(http.StatusAccepted, "{}"),
+		))
+
+		client := NewClient(fakeServer.URL())
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+	})
+
+	It("sends the segment to every shard", func() {
+		otherServer := ghttp.NewServer()
+		defer otherServer.Close()
+		for _, server := range []*ghttp.Server{fakeServer, otherServer} {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV(IsolationSegmentHeader, "some-segment"),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+		}
+
+		client := NewClient(fakeServer.URL(), WithSharding([]string{fakeServer.URL(), otherServer.URL()}, func(processGuid string, backends int) int {
+			if processGuid == "guid-a" {
+				return 0
+			}
+			return 1
+		}))
+		_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{{ProcessGuid: "guid-a"}, {ProcessGuid: "guid-b"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		Expect(otherServer.ReceivedRequests()).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,99 @@
+package auctioneer
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// maxThrottledLogs bounds the failures a log throttle remembers; beyond it,
+// failures not seen within the interval are forgotten.
+const maxThrottledLogs = 256
+
+// WithErrorLogThrottle logs repeated identical failures, the same message
+// with the same error, at most once per interval, so an auctioneer outage
+// does not flood the logs at the rate requests are made. The next failure
+// logged after a quiet period reports how many were suppressed in a
+// "suppressed" field.
+func WithErrorLogThrottle(interval time.Duration) ClientOption {
+	return func(c *auctioneerClient) {
+		if interval > 0 {
+			c.logThrottle = &logThrottle{interval: interval, entries: map[logThrottleKey]*logThrottleEntry{}}
+		}
+	}
+}
+
+type logThrottleKey struct {
+	message string
+	err     string
+}
+
+type logThrottleEntry struct {
+	logged     time.Time
+	suppressed int
+}
+
+type logThrottle struct {
+	interval time.Duration
+
+	lock    sync.Mutex
+	entries map[logThrottleKey]*logThrottleEntry
+}
+
+// allow reports whether the failure should be logged now and, if so, how
+// many identical failures were suppressed since it was last logged.
+func (t *logThrottle) allow(message string, err error, now time.Time) (int, bool) {
+	key := logThrottleKey{message: message, err: err.Error()}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	entry, ok := t.entries[key]
+	if ok && now.Sub(entry.logged) < t.interval {
+		entry.suppressed++
+		return 0, false
+	}
+
+	if !ok {
+		t.prune(now)
+		entry = &logThrottleEntry{}
+		t.entries[key] = entry
+	}
+
+	suppressed := entry.suppressed
+	entry.logged = now
+	entry.suppressed = 0
+	return suppressed, true
+}
+
+func (t *logThrottle) prune(now time.Time) {
+	if len(t.entries) < maxThrottledLogs {
+		return
+	}
+
+	for key, entry := range t.entries {
+		if now.Sub(entry.logged) >= t.interval {
+			delete(t.entries, key)
+		}
+	}
+}
+
+// logError logs err through logger unless the client's log throttle
+// suppresses it.
+func (c *auctioneerClient) logError(logger lager.Logger, message string, err error, data ...lager.Data) {
+	if c.logThrottle == nil {
+		logger.Error(message, err, data...)
+		return
+	}
+
+	suppressed, ok := c.logThrottle.allow(message, err, c.clock.Now())
+	if !ok {
+		return
+	}
+
+	if suppressed > 0 {
+		data = append(data, lager.Data{"suppressed": suppressed})
+	}
+	logger.Error(message, err, data...)
+}
@@ -0,0 +1,85 @@
+package auctioneer_test
+
+import (
+	"strings"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("WithErrorLogThrottle", func() {
+	var (
+		logger    *lagertest.TestLogger
+		serverURL string
+		client    ExtendedClient
+	)
+
+	newFallbackClient := func(opts ...ClientOption) ExtendedClient {
+		client, err := NewSecureClient(
+			strings.Replace(serverURL, "http:", "https:", 1),
+			blueCerts.CAFile,
+			blueCerts.ClientCertFile,
+			blueCerts.ClientKeyFile,
+			false,
+			opts...,
+		)
+		Expect(err).NotTo(HaveOccurred())
+		return client
+	}
+
+	fallbackLogs := func() []lager.LogFormat {
+		logs := []lager.LogFormat{}
+		for _, log := range logger.Logs() {
+			if strings.HasSuffix(log.Message, "retrying-on-http") {
+				logs = append(logs, log)
+			}
+		}
+		return logs
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer := ghttp.NewServer()
+		serverURL = fakeServer.URL()
+		fakeServer.Close()
+	})
+
+	It("logs every failure by default", func() {
+		client = newFallbackClient()
+		for i := 0; i < 3; i++ {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+		}
+
+		Expect(fallbackLogs()).To(HaveLen(3))
+	})
+
+	It("logs identical failures once per interval", func() {
+		client = newFallbackClient(WithErrorLogThrottle(time.Hour))
+		for i := 0; i < 3; i++ {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+		}
+
+		Expect(fallbackLogs()).To(HaveLen(1))
+	})
+
+	It("reports how many failures were suppressed", func() {
+		client = newFallbackClient(WithErrorLogThrottle(100 * time.Millisecond))
+		for i := 0; i < 3; i++ {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+		}
+
+		time.Sleep(150 * time.Millisecond)
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+
+		logs := fallbackLogs()
+		Expect(logs).To(HaveLen(2))
+		Expect(logs[0].Data).NotTo(HaveKey("suppressed"))
+		Expect(logs[1].Data).To(HaveKeyWithValue("suppressed", BeNumerically("==", 2)))
+	})
+})
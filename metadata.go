@@ -0,0 +1,72 @@
+package auctioneer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"code.cloudfoundry.org/lager/lagerctx"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// MetadataCarrier receives the metadata sent with a request to the
+// auctioneer: the trace context, the time remaining before the deadline, and
+// the values of WithConstantTags, WithContextHeaders and
+// ContextWithIsolationSegment. Injecting metadata through it keeps that
+// logic independent of the transport: HTTP requests carry it as headers,
+// through HTTPHeaderCarrier, and a gRPC transport would carry it as gRPC
+// metadata.
+type MetadataCarrier interface {
+	// Set sets key to value, replacing any value it had.
+	Set(key, value string)
+	// Has reports whether key has a value.
+	Has(key string) bool
+}
+
+// HTTPHeaderCarrier is the MetadataCarrier of an HTTP request's headers.
+type HTTPHeaderCarrier http.Header
+
+func (h HTTPHeaderCarrier) Set(key, value string) {
+	http.Header(h).Set(key, value)
+}
+
+func (h HTTPHeaderCarrier) Has(key string) bool {
+	_, ok := h[http.CanonicalHeaderKey(key)]
+	return ok
+}
+
+// InjectMetadata writes the metadata the client sends with requests made
+// with ctx, including the context of the span in ctx, if any, to carrier.
+func (c *auctioneerClient) InjectMetadata(ctx context.Context, carrier MetadataCarrier) {
+	ctx = requestContext(ctx)
+	c.injectMetadata(ctx, carrier)
+
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		c.injectSpanContext(lagerctx.FromContext(ctx), span, carrier)
+	}
+}
+
+// injectMetadata writes the metadata derived from the client's options and
+// ctx, other than the trace context, to carrier.
+func (c *auctioneerClient) injectMetadata(ctx context.Context, carrier MetadataCarrier) {
+	for key, value := range c.constantTags {
+		if !carrier.Has(key) {
+			carrier.Set(key, value)
+		}
+	}
+
+	injectRequestTimeout(ctx, carrier)
+
+	if segment := IsolationSegmentFromContext(ctx); segment != "" {
+		carrier.Set(c.isolationSegmentHeader, segment)
+	}
+
+	for key, name := range c.contextHeaders {
+		switch value := ctx.Value(key).(type) {
+		case string:
+			carrier.Set(name, value)
+		case fmt.Stringer:
+			carrier.Set(name, value.String())
+		}
+	}
+}
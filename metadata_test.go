@@ -0,0 +1,95 @@
+package auctioneer_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+// mapCarrier stands in for the metadata of a transport other than HTTP.
+type mapCarrier map[string]string
+
+func (m mapCarrier) Set(key, value string) { m[key] = value }
+
+func (m mapCarrier) Has(key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+var _ = Describe("InjectMetadata", func() {
+	var (
+		fakeServer *ghttp.Server
+		client     ExtendedClient
+		ctx        context.Context
+		cancel     context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		fakeServer = ghttp.NewServer()
+		client = NewClient(fakeServer.URL(),
+			WithConstantTags(map[string]string{"X-Deploy-Version": "v42"}),
+			WithContextHeaders(map[interface{}]string{tenantKey{}: "X-Tenant-Id"}),
+		)
+
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		ctx = context.WithValue(ctx, tenantKey{}, "some-org")
+		ctx = ContextWithIsolationSegment(ctx, "some-segment")
+	})
+
+	AfterEach(func() {
+		cancel()
+		fakeServer.Close()
+	})
+
+	It("writes the client's metadata to any carrier", func() {
+		carrier := mapCarrier{}
+		client.InjectMetadata(ctx, carrier)
+
+		Expect(carrier).To(HaveKeyWithValue("X-Deploy-Version", "v42"))
+		Expect(carrier).To(HaveKeyWithValue("X-Tenant-Id", "some-org"))
+		Expect(carrier).To(HaveKeyWithValue(IsolationSegmentHeader, "some-segment"))
+		Expect(carrier).To(HaveKey(RequestTimeoutHeader))
+	})
+
+	It("writes the context of the span in the context", func() {
+		tracer := mocktracer.New()
+		span := tracer.StartSpan("some-operation")
+		defer span.Finish()
+
+		carrier := mapCarrier{}
+		client.InjectMetadata(opentracing.ContextWithSpan(ctx, span), carrier)
+
+		Expect(carrier).To(HaveKeyWithValue("mockpfx-ids-traceid", Not(BeEmpty())))
+	})
+
+	It("writes the metadata that HTTP requests carry as headers", func() {
+		var received http.Header
+		fakeServer.AppendHandlers(ghttp.CombineHandlers(
+			func(w http.ResponseWriter, r *http.Request) {
+				received = r.Header
+			},
+			ghttp.RespondWith(http.StatusAccepted, "{}"),
+		))
+
+		_, err := client.RequestLRPAuctionsWithResult(lagertest.NewTestLogger("test"), ctx, []*LRPStartRequest{})
+		Expect(err).NotTo(HaveOccurred())
+
+		carrier := mapCarrier{}
+		client.InjectMetadata(ctx, carrier)
+		for key, value := range carrier {
+			Expect(received).To(HaveKey(http.CanonicalHeaderKey(key)))
+			if key != RequestTimeoutHeader {
+				Expect(received.Get(key)).To(Equal(value))
+			}
+		}
+	})
+})
@@ -0,0 +1,131 @@
+package auctioneer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// NDJSONContentType is the media type of auction batches streamed with
+// WithNDJSONStreaming, one JSON-encoded entry per line.
+const NDJSONContentType = "application/x-ndjson"
+
+var ndjsonContentType = []string{NDJSONContentType}
+
+// WithNDJSONStreaming streams an auction batch of more than threshold
+// entries as newline-delimited JSON, encoding each entry as it is written to
+// the connection, provided the auctioneer's Capabilities include
+// NDJSONContentType. The batch is then never held in memory as a whole, on
+// either side, and the auctioneer can process it incrementally. Batches are
+// sent as a JSON array when the auctioneer does not advertise NDJSON or its
+// capabilities cannot be determined, and batches submitted with
+// RequestLRPAuctionsRaw always are.
+//
+// Streamed batches have no known size up front, so WithMaxPayloadBytes
+// aborts one only once the NDJSON written exceeds its limit, failing the
+// request with a *PayloadTooLargeError, and WithServerPayloadLimit,
+// WithAutoCompression, WithContentDigest and WithBatchTrailers do not apply
+// to them. Retries and the plain HTTP fallback encode the batch again.
+func WithNDJSONStreaming(threshold int) ClientOption {
+	return func(c *auctioneerClient) {
+		c.ndjsonStreaming = true
+		c.ndjsonThreshold = threshold
+	}
+}
+
+// shouldStream reports whether the batch is to be streamed as NDJSON.
+func (c *auctioneerClient) shouldStream(logger lager.Logger, ctx context.Context, ar auctionRequest) bool {
+	if !c.ndjsonStreaming || ar.raw != nil || ar.count <= c.ndjsonThreshold {
+		return false
+	}
+
+	capabilities, err := c.batchCapabilities(logger, ctx)
+	if err != nil {
+		logger.Debug("sending-json-array", lager.Data{"error": err.Error()})
+		return false
+	}
+
+	return capabilities.SupportsContentType(NDJSONContentType)
+}
+
+// newStreamingAuctionsRequest returns a request whose body encodes the
+// batch as NDJSON while it is read.
+func (c *auctioneerClient) newStreamingAuctionsRequest(ctx context.Context, ar auctionRequest) (*http.Request, error) {
+	reqGen, err := c.requestGenerator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	body := c.streamNDJSON(ar)
+	req, err := reqGen.CreateRequest(ar.route, nil, body)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.ContentLength = -1
+	req.GetBody = func() (io.ReadCloser, error) {
+		return c.streamNDJSON(ar), nil
+	}
+
+	req.Header["Content-Type"] = ndjsonContentType
+	if ar.dryRun {
+		req.Header[DryRunHeader] = dryRunHeaderValue
+	}
+	return req, nil
+}
+
+// streamNDJSON returns a reader of ar's auctions encoded one per line. The
+// encoding stops, and its goroutine exits, once the reader is closed, or
+// with a *PayloadTooLargeError once it would exceed the client's maximum
+// payload size.
+func (c *auctioneerClient) streamNDJSON(ar auctionRequest) io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		enc := json.NewEncoder(&limitedStreamWriter{w: pw, operation: ar.operation, limit: c.maxPayloadBytes})
+		var err error
+		switch auctions := ar.auctions.(type) {
+		case []*LRPStartRequest:
+			for _, auction := range auctions {
+				if err = enc.Encode(auction); err != nil {
+					break
+				}
+			}
+		case []*TaskStartRequest:
+			for _, auction := range auctions {
+				if err = enc.Encode(auction); err != nil {
+					break
+				}
+			}
+		default:
+			err = fmt.Errorf("cannot stream auctions of type %T", auctions)
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return pr
+}
+
+// limitedStreamWriter counts the bytes of a streamed batch, refusing the
+// write that would take it past limit, when limit is positive.
+type limitedStreamWriter struct {
+	w         io.Writer
+	operation string
+	limit     int
+	written   int
+}
+
+func (w *limitedStreamWriter) Write(p []byte) (int, error) {
+	if w.limit > 0 && w.written+len(p) > w.limit {
+		return 0, &PayloadTooLargeError{Operation: w.operation, Size: w.written + len(p), Limit: w.limit}
+	}
+
+	n, err := w.w.Write(p)
+	w.written += n
+	return n, err
+}
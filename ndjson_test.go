@@ -0,0 +1,161 @@
+package auctioneer_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("WithNDJSONStreaming", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		client     ExtendedClient
+		lrpStarts  []*LRPStartRequest
+
+		contentType string
+		lines       []string
+		received    []*LRPStartRequest
+		accept      http.HandlerFunc
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		client = NewClient(fakeServer.URL(), WithNDJSONStreaming(1))
+		lrpStarts = []*LRPStartRequest{
+			{ProcessGuid: "guid-1", Domain: "some-domain", Indices: []int{0}},
+			{ProcessGuid: "guid-2", Domain: "some-domain", Indices: []int{0, 1}},
+		}
+
+		contentType = ""
+		lines = nil
+		received = nil
+		accept = func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+
+			contentType = r.Header.Get("Content-Type")
+			if contentType != NDJSONContentType {
+				Expect(json.NewDecoder(r.Body).Decode(&received)).To(Succeed())
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+
+			scanner := bufio.NewScanner(r.Body)
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+				lrpStart := &LRPStartRequest{}
+				Expect(json.Unmarshal(scanner.Bytes(), lrpStart)).To(Succeed())
+				received = append(received, lrpStart)
+			}
+			Expect(scanner.Err()).NotTo(HaveOccurred())
+			w.WriteHeader(http.StatusAccepted)
+		}
+		fakeServer.RouteToHandler("POST", "/v1/lrps", accept)
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	Context("when the auctioneer supports NDJSON", func() {
+		BeforeEach(func() {
+			fakeServer.RouteToHandler("OPTIONS", "/", ghttp.RespondWithJSONEncoded(http.StatusOK, Capabilities{
+				ContentTypes: []string{"application/json", NDJSONContentType},
+			}))
+		})
+
+		It("streams batches over the threshold one entry per line", func() {
+			Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+
+			Expect(contentType).To(Equal(NDJSONContentType))
+			Expect(lines).To(HaveLen(2))
+			Expect(received).To(Equal(lrpStarts))
+		})
+
+		It("sends batches at or under the threshold as a JSON array", func() {
+			Expect(client.RequestLRPAuctions(logger, lrpStarts[:1])).To(Succeed())
+
+			Expect(contentType).To(Equal("application/json"))
+			Expect(received).To(Equal(lrpStarts[:1]))
+		})
+
+		It("encodes the batch again for a retry", func() {
+			client = NewClient(fakeServer.URL(), WithNDJSONStreaming(1), WithRetries(1), WithRetryAfterSend(),
+				WithFailureClassifier(func(resp *http.Response, err error) Classification {
+					switch {
+					case err != nil:
+						return ClassificationPermanentError
+					case resp.StatusCode == http.StatusServiceUnavailable:
+						return ClassificationRetryableError
+					default:
+						return ClassificationSuccess
+					}
+				}),
+			)
+
+			attempts := 0
+			fakeServer.RouteToHandler("POST", "/v1/lrps", func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				if attempts == 1 {
+					ioutil.ReadAll(r.Body)
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				accept(w, r)
+			})
+
+			Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+			Expect(attempts).To(Equal(2))
+			Expect(received).To(Equal(lrpStarts))
+		})
+
+		It("aborts a batch once it exceeds WithMaxPayloadBytes", func() {
+			firstLine, err := json.Marshal(lrpStarts[0])
+			Expect(err).NotTo(HaveOccurred())
+			limit := len(firstLine) + 1
+			client = NewClient(fakeServer.URL(), WithNDJSONStreaming(1), WithMaxPayloadBytes(limit))
+
+			bodies := make(chan []byte, 1)
+			fakeServer.RouteToHandler("POST", "/v1/lrps", func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				bodies <- body
+				w.WriteHeader(http.StatusAccepted)
+			})
+
+			err = client.RequestLRPAuctions(logger, lrpStarts)
+			var tooLarge *PayloadTooLargeError
+			Expect(errors.As(err, &tooLarge)).To(BeTrue())
+			Expect(errors.Is(err, ErrPayloadTooLarge)).To(BeTrue())
+			Expect(tooLarge.Limit).To(Equal(limit))
+			Expect(tooLarge.Size).To(BeNumerically(">", limit))
+			var body []byte
+			Eventually(bodies).Should(Receive(&body))
+			Expect(len(body)).To(BeNumerically("<=", limit))
+		})
+	})
+
+	Context("when the auctioneer does not support NDJSON", func() {
+		BeforeEach(func() {
+			fakeServer.RouteToHandler("OPTIONS", "/", ghttp.RespondWithJSONEncoded(http.StatusOK, Capabilities{
+				ContentTypes: []string{"application/json"},
+			}))
+		})
+
+		It("sends batches as a JSON array", func() {
+			Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+
+			Expect(contentType).To(Equal("application/json"))
+			Expect(received).To(Equal(lrpStarts))
+		})
+	})
+})
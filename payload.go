@@ -0,0 +1,271 @@
+package auctioneer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// Buffers that grew past this size are dropped rather than pooled, so one
+// unusually large batch does not pin its memory.
+const maxPooledPayloadBytes = 4 * 1024 * 1024
+
+// BufferPool provides the buffers auction batches are marshaled into. Put
+// receives buffers that are no longer referenced. Implementations must be
+// safe for concurrent use.
+type BufferPool interface {
+	Get() *bytes.Buffer
+	Put(*bytes.Buffer)
+}
+
+// NewBufferPool returns a BufferPool backed by a sync.Pool. Clients use a
+// package-wide pool of this kind unless configured with WithBufferPool.
+func NewBufferPool() BufferPool {
+	return &syncBufferPool{
+		pool: sync.Pool{
+			New: func() interface{} {
+				return new(bytes.Buffer)
+			},
+		},
+	}
+}
+
+var defaultBufferPool = NewBufferPool()
+
+// WithBufferPool marshals auction batches into buffers drawn from pool, so
+// that many clients, or other components, can share one pool.
+func WithBufferPool(pool BufferPool) ClientOption {
+	return func(c *auctioneerClient) {
+		if pool != nil {
+			c.bufferPool = pool
+		}
+	}
+}
+
+// WithMaxPayloadBytes refuses to send an auction batch whose marshaled JSON
+// exceeds n bytes, failing with a *PayloadTooLargeError instead. The limit
+// applies before compression, since the auctioneer holds the uncompressed
+// batch in memory, and independently of the number of auctions: a few large
+// LRPs can exceed it in a small batch. A batch streamed with
+// WithNDJSONStreaming is aborted while it is written instead, once it
+// exceeds n bytes. A non-positive n means no limit.
+func WithMaxPayloadBytes(n int) ClientOption {
+	return func(c *auctioneerClient) {
+		c.maxPayloadBytes = n
+	}
+}
+
+// WithServerPayloadLimit refuses to send an auction batch whose marshaled
+// JSON exceeds the limit the auctioneer advertises in
+// Capabilities.MaxPayloadBytes, failing with a *PayloadTooLargeError that
+// matches ErrPayloadExceedsServerLimit instead of waiting for the
+// auctioneer to answer 413. The limit is checked as WithMaxPayloadBytes
+// checks its own. Batches are sent unchecked when the auctioneer advertises
+// no limit or its capabilities cannot be determined, and batches streamed
+// with WithNDJSONStreaming, whose size is not known up front, are never
+// checked.
+func WithServerPayloadLimit() ClientOption {
+	return func(c *auctioneerClient) {
+		c.serverPayloadLimit = true
+	}
+}
+
+// WithMarshalTimeout fails an auction submission with a *CanceledError for
+// context.DeadlineExceeded when marshaling its batch takes longer than d,
+// before anything is sent. Marshaling also respects the request context's
+// own deadline. A non-positive d means no limit beyond the context's.
+func WithMarshalTimeout(d time.Duration) ClientOption {
+	return func(c *auctioneerClient) {
+		c.marshalTimeout = d
+	}
+}
+
+type syncBufferPool struct {
+	pool sync.Pool
+}
+
+func (p *syncBufferPool) Get() *bytes.Buffer {
+	return p.pool.Get().(*bytes.Buffer)
+}
+
+func (p *syncBufferPool) Put(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledPayloadBytes {
+		return
+	}
+
+	buf.Reset()
+	p.pool.Put(buf)
+}
+
+// payloads are pooled separately from their buffers so that the encoder
+// bound to each payload is reused too.
+var payloadPool = sync.Pool{
+	New: func() interface{} {
+		p := &payload{}
+		p.encoder = json.NewEncoder(&p.writer)
+		return p
+	},
+}
+
+// payload is a marshaled auction batch in a pooled buffer. The buffer goes
+// back to its pool once the owner and every request body reading it have
+// been released; the transport may close a request body after the round
+// trip returns, so the owner alone cannot decide.
+type payload struct {
+	buf     *bytes.Buffer
+	pool    BufferPool
+	writer  payloadWriter
+	encoder *json.Encoder
+	refs    int32
+}
+
+func newPayload(pool BufferPool) *payload {
+	p := payloadPool.Get().(*payload)
+	p.buf = pool.Get()
+	p.pool = pool
+	p.writer.buf = p.buf
+	p.refs = 1
+	return p
+}
+
+func marshalPayload(pool BufferPool, v interface{}) (*payload, error) {
+	p := newPayload(pool)
+
+	err := p.encoder.Encode(v)
+	if err != nil {
+		p.release()
+		return nil, err
+	}
+
+	// Encode terminates the document with a newline that json.Marshal does
+	// not produce.
+	p.buf.Truncate(p.buf.Len() - 1)
+
+	return p, nil
+}
+
+// marshalAuctions marshals the batch into a pooled payload unless ctx, or
+// the marshal timeout, expires first. Encoding cannot be interrupted, so the
+// context is checked before and after it.
+func (c *auctioneerClient) marshalAuctions(ctx context.Context, ar auctionRequest) (*payload, error) {
+	if c.marshalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.marshalTimeout)
+		defer cancel()
+	}
+
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := marshalPayload(c.bufferPool, ar.auctions)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ctx.Err()
+	if err != nil {
+		payload.release()
+		return nil, err
+	}
+	return payload, nil
+}
+
+// readPayload copies a batch marshaled by the caller from r.
+func readPayload(pool BufferPool, r io.Reader) (*payload, error) {
+	p := newPayload(pool)
+
+	_, err := p.buf.ReadFrom(r)
+	if err != nil {
+		p.release()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *payload) Len() int {
+	return p.buf.Len()
+}
+
+// Bytes returns the marshaled batch, which is only valid until the payload
+// is released.
+func (p *payload) Bytes() []byte {
+	return p.buf.Bytes()
+}
+
+// body returns a reader over the payload that holds a reference to it until
+// closed.
+func (p *payload) body() io.ReadCloser {
+	atomic.AddInt32(&p.refs, 1)
+
+	b := &payloadBody{payload: p}
+	b.reader.Reset(p.buf.Bytes())
+	return b
+}
+
+func (p *payload) release() {
+	if atomic.AddInt32(&p.refs, -1) != 0 {
+		return
+	}
+
+	p.pool.Put(p.buf)
+	p.buf = nil
+	p.pool = nil
+	p.writer.buf = nil
+	payloadPool.Put(p)
+}
+
+// payloadWriter lets a payload's encoder write into whichever buffer the
+// payload currently holds.
+type payloadWriter struct {
+	buf *bytes.Buffer
+}
+
+func (w *payloadWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+type payloadBody struct {
+	reader  bytes.Reader
+	payload *payload
+	closed  int32
+}
+
+func (b *payloadBody) Read(p []byte) (int, error) {
+	return b.reader.Read(p)
+}
+
+func (b *payloadBody) Close() error {
+	if atomic.CompareAndSwapInt32(&b.closed, 0, 1) {
+		b.payload.release()
+	}
+	return nil
+}
+
+// checkServerPayloadLimit returns a *PayloadTooLargeError when a batch of
+// size bytes exceeds the auctioneer's advertised limit.
+func (c *auctioneerClient) checkServerPayloadLimit(logger lager.Logger, ctx context.Context, operation string, size int) error {
+	if !c.serverPayloadLimit {
+		return nil
+	}
+
+	capabilities, err := c.batchCapabilities(logger, ctx)
+	if err != nil {
+		logger.Debug("skipping-server-payload-limit", lager.Data{"error": err.Error()})
+		return nil
+	}
+
+	limit := capabilities.MaxPayloadBytes
+	if limit <= 0 || size <= limit {
+		return nil
+	}
+	return &PayloadTooLargeError{Operation: operation, Size: size, Limit: limit, Advertised: true}
+}
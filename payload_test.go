@@ -0,0 +1,264 @@
+package auctioneer_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+type countingBufferPool struct {
+	BufferPool
+	lock sync.Mutex
+	gets int
+	puts int
+}
+
+func (p *countingBufferPool) Get() *bytes.Buffer {
+	p.lock.Lock()
+	p.gets++
+	p.lock.Unlock()
+	return p.BufferPool.Get()
+}
+
+func (p *countingBufferPool) Put(buf *bytes.Buffer) {
+	p.lock.Lock()
+	p.puts++
+	p.lock.Unlock()
+	p.BufferPool.Put(buf)
+}
+
+func (p *countingBufferPool) counts() (int, int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.gets, p.puts
+}
+
+var _ = Describe("WithBufferPool", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		pool       *countingBufferPool
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+		fakeServer.RouteToHandler("POST", "/v1/tasks", ghttp.RespondWith(http.StatusAccepted, "{}"))
+		pool = &countingBufferPool{BufferPool: NewBufferPool()}
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("draws marshaling buffers for every client from the shared pool", func() {
+		lrpClient := NewClient(fakeServer.URL(), WithBufferPool(pool))
+		taskClient := NewClient(fakeServer.URL(), WithBufferPool(pool))
+
+		Expect(lrpClient.RequestLRPAuctions(logger, []*LRPStartRequest{{ProcessGuid: "some-guid"}})).To(Succeed())
+		Expect(taskClient.RequestTaskAuctions(logger, []*TaskStartRequest{{}})).To(Succeed())
+
+		Eventually(func() int {
+			_, puts := pool.counts()
+			return puts
+		}).Should(Equal(2))
+
+		gets, _ := pool.counts()
+		Expect(gets).To(Equal(2))
+	})
+
+	It("sends the marshaled batch", func() {
+		lrpStarts := []*LRPStartRequest{{ProcessGuid: "some-guid", Domain: "some-domain", Indices: []int{0}}}
+		fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.CombineHandlers(
+			ghttp.VerifyJSONRepresenting(lrpStarts),
+			ghttp.RespondWith(http.StatusAccepted, "{}"),
+		))
+
+		client := NewClient(fakeServer.URL(), WithBufferPool(pool))
+		Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+	})
+})
+
+var _ = Describe("WithMaxPayloadBytes", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		lrpStarts  []*LRPStartRequest
+		size       int
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+
+		lrpStarts = []*LRPStartRequest{{ProcessGuid: "some-guid", Domain: "some-domain", Indices: []int{0}}}
+		payload, err := json.Marshal(lrpStarts)
+		Expect(err).NotTo(HaveOccurred())
+		size = len(payload)
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("sends a batch at the limit", func() {
+		client := NewClient(fakeServer.URL(), WithMaxPayloadBytes(size))
+		Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+	})
+
+	It("refuses a batch over the limit without sending it", func() {
+		client := NewClient(fakeServer.URL(), WithMaxPayloadBytes(size-1))
+		err := client.RequestLRPAuctions(logger, lrpStarts)
+
+		Expect(errors.Is(err, ErrPayloadTooLarge)).To(BeTrue())
+		Expect(err).To(Equal(&PayloadTooLargeError{Operation: OperationLRP, Size: size, Limit: size - 1}))
+		Expect(err).To(MatchError(ContainSubstring("%d bytes", size)))
+		Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+	})
+
+	It("limits the uncompressed size", func() {
+		client := NewClient(fakeServer.URL(), WithMaxPayloadBytes(size-1), WithAutoCompression(1))
+		Expect(errors.Is(client.RequestLRPAuctions(logger, lrpStarts), ErrPayloadTooLarge)).To(BeTrue())
+	})
+})
+
+var _ = Describe("WithServerPayloadLimit", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		lrpStarts  []*LRPStartRequest
+		size       int
+	)
+
+	advertiseLimit := func(limit int) {
+		fakeServer.RouteToHandler("OPTIONS", "/", ghttp.RespondWithJSONEncoded(http.StatusOK, Capabilities{MaxPayloadBytes: limit}))
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+
+		lrpStarts = []*LRPStartRequest{{ProcessGuid: "some-guid", Domain: "some-domain", Indices: []int{0}}}
+		payload, err := json.Marshal(lrpStarts)
+		Expect(err).NotTo(HaveOccurred())
+		size = len(payload)
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("sends a batch at the advertised limit", func() {
+		advertiseLimit(size)
+		client := NewClient(fakeServer.URL(), WithServerPayloadLimit())
+		Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+	})
+
+	It("refuses a batch over the advertised limit without sending it", func() {
+		advertiseLimit(size - 1)
+		client := NewClient(fakeServer.URL(), WithServerPayloadLimit())
+		err := client.RequestLRPAuctions(logger, lrpStarts)
+
+		Expect(errors.Is(err, ErrPayloadExceedsServerLimit)).To(BeTrue())
+		Expect(errors.Is(err, ErrPayloadTooLarge)).To(BeTrue())
+		Expect(err).To(Equal(&PayloadTooLargeError{Operation: OperationLRP, Size: size, Limit: size - 1, Advertised: true}))
+		Expect(err).To(MatchError(ContainSubstring("advertised maximum")))
+		Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		Expect(fakeServer.ReceivedRequests()[0].Method).To(Equal("OPTIONS"))
+	})
+
+	It("sends the batch when the auctioneer advertises no limit", func() {
+		advertiseLimit(0)
+		client := NewClient(fakeServer.URL(), WithServerPayloadLimit())
+		Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+	})
+
+	It("sends the batch when the auctioneer predates capability detection", func() {
+		fakeServer.RouteToHandler("OPTIONS", "/", ghttp.RespondWith(http.StatusNotFound, ""))
+		client := NewClient(fakeServer.URL(), WithServerPayloadLimit())
+		Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+	})
+
+	It("does not match a WithMaxPayloadBytes refusal", func() {
+		client := NewClient(fakeServer.URL(), WithMaxPayloadBytes(size-1))
+		err := client.RequestLRPAuctions(logger, lrpStarts)
+
+		Expect(errors.Is(err, ErrPayloadTooLarge)).To(BeTrue())
+		Expect(errors.Is(err, ErrPayloadExceedsServerLimit)).To(BeFalse())
+	})
+
+	It("does not ask for the limit without the option", func() {
+		advertiseLimit(size - 1)
+		client := NewClient(fakeServer.URL())
+		Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+		Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("WithMarshalTimeout", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		pool       *countingBufferPool
+		lrpStarts  []*LRPStartRequest
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+		pool = &countingBufferPool{BufferPool: NewBufferPool()}
+		lrpStarts = []*LRPStartRequest{{ProcessGuid: "some-guid", Domain: "some-domain", Indices: []int{0}}}
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("sends a batch marshaled within the timeout", func() {
+		client := NewClient(fakeServer.URL(), WithMarshalTimeout(time.Minute))
+		Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+	})
+
+	It("fails without sending a batch that takes longer to marshal", func() {
+		client := NewClient(fakeServer.URL(), WithMarshalTimeout(time.Nanosecond), WithBufferPool(pool))
+		err := client.RequestLRPAuctions(logger, lrpStarts)
+
+		var canceledErr *CanceledError
+		Expect(errors.As(err, &canceledErr)).To(BeTrue())
+		Expect(canceledErr.Operation).To(Equal(OperationLRP))
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+
+		gets, puts := pool.counts()
+		Expect(puts).To(Equal(gets))
+	})
+
+	It("fails without marshaling once the request context is done", func() {
+		client := NewClient(fakeServer.URL(), WithBufferPool(pool))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := client.RequestLRPAuctionsWithResult(logger, ctx, lrpStarts)
+
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+
+		gets, _ := pool.counts()
+		Expect(gets).To(BeZero())
+	})
+})
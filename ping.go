@@ -0,0 +1,83 @@
+package auctioneer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagerctx"
+)
+
+// ErrAuctioneerUnreachable is matched, with errors.Is, by the errors Ping
+// returns when no response could be obtained from the auctioneer.
+var ErrAuctioneerUnreachable = errors.New("auctioneer is unreachable")
+
+// PingResult is the outcome of a Ping that reached the auctioneer.
+type PingResult struct {
+	// StatusCode is the status of the auctioneer's response, which may be
+	// an error status such as 404 or 405.
+	StatusCode int
+}
+
+// OK reports whether the auctioneer responded with a 2xx status.
+func (r PingResult) OK() bool {
+	return r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Ping checks that the auctioneer is reachable by sending a HEAD request to
+// its base URL, without submitting an auction. Any response, whatever its
+// status, means the auctioneer is reachable; its status is returned in the
+// result. A connection failure returns an error matching
+// ErrAuctioneerUnreachable that unwraps to the cause.
+func (c *auctioneerClient) Ping(logger lager.Logger, ctx context.Context) (PingResult, error) {
+	ctx = requestContext(ctx)
+	return c.ping(c.requestLogger(logger, ctx).Session("ping"), ctx)
+}
+
+// Warmup establishes a connection to the auctioneer, completing the TLS
+// handshake, and leaves it idle for the next request to reuse. It sends the
+// same request as Ping and fails only when the auctioneer is unreachable.
+// Connections are not kept when keep-alives are disabled.
+func (c *auctioneerClient) Warmup(ctx context.Context) error {
+	ctx = requestContext(ctx)
+	_, err := c.ping(lagerctx.FromContext(ctx).Session("warmup"), ctx)
+	return err
+}
+
+func (c *auctioneerClient) ping(logger lager.Logger, ctx context.Context) (PingResult, error) {
+	url, err := c.targetURL(ctx)
+	if err != nil {
+		return PingResult{}, err
+	}
+
+	req, err := http.NewRequest("HEAD", url, nil)
+	if err != nil {
+		return PingResult{}, err
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := c.doRequest(logger, req, "Ping")
+	if err != nil {
+		return PingResult{}, &unreachableError{err: err}
+	}
+	resp.Body.Close()
+
+	return PingResult{StatusCode: resp.StatusCode}, nil
+}
+
+type unreachableError struct {
+	err error
+}
+
+func (e *unreachableError) Error() string {
+	return ErrAuctioneerUnreachable.Error() + ": " + e.err.Error()
+}
+
+func (e *unreachableError) Unwrap() error {
+	return e.err
+}
+
+func (e *unreachableError) Is(target error) bool {
+	return target == ErrAuctioneerUnreachable
+}
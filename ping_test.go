@@ -0,0 +1,125 @@
+package auctioneer_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Ping", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		client     ExtendedClient
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		client = NewClient(fakeServer.URL())
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("sends a HEAD request to the base URL", func() {
+		fakeServer.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("HEAD", "/"),
+			ghttp.RespondWith(http.StatusOK, nil),
+		))
+
+		result, err := client.Ping(logger, context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.StatusCode).To(Equal(http.StatusOK))
+		Expect(result.OK()).To(BeTrue())
+	})
+
+	Context("when the auctioneer responds with an error status", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusMethodNotAllowed, nil))
+		})
+
+		It("reports the auctioneer as reachable", func() {
+			result, err := client.Ping(logger, context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+			Expect(result.OK()).To(BeFalse())
+		})
+	})
+
+	Context("when the auctioneer cannot be reached", func() {
+		BeforeEach(func() {
+			fakeServer.Close()
+		})
+
+		It("returns ErrAuctioneerUnreachable", func() {
+			_, err := client.Ping(logger, context.Background())
+			Expect(errors.Is(err, ErrAuctioneerUnreachable)).To(BeTrue())
+			Expect(IsRetryable(err)).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("Warmup", func() {
+	var (
+		fakeServer  *ghttp.Server
+		client      ExtendedClient
+		connections int32
+	)
+
+	BeforeEach(func() {
+		atomic.StoreInt32(&connections, 0)
+		fakeServer = ghttp.NewUnstartedServer()
+		fakeServer.HTTPTestServer.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				atomic.AddInt32(&connections, 1)
+			}
+		}
+		fakeServer.Start()
+		fakeServer.RouteToHandler("HEAD", "/", ghttp.RespondWith(http.StatusOK, nil))
+		fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+		client = NewClient(fakeServer.URL())
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("leaves a connection for the next request to reuse", func() {
+		Expect(client.Warmup(context.Background())).To(Succeed())
+		Expect(atomic.LoadInt32(&connections)).To(Equal(int32(1)))
+
+		Expect(client.RequestLRPAuctions(lagertest.NewTestLogger("test"), []*LRPStartRequest{})).To(Succeed())
+		Expect(atomic.LoadInt32(&connections)).To(Equal(int32(1)))
+	})
+
+	It("connects to the URL set by SetURL", func() {
+		otherServer := ghttp.NewServer()
+		defer otherServer.Close()
+		otherServer.AppendHandlers(ghttp.RespondWith(http.StatusOK, nil))
+
+		client.SetURL(otherServer.URL())
+		Expect(client.Warmup(context.Background())).To(Succeed())
+		Expect(otherServer.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("succeeds whatever the auctioneer's status", func() {
+		fakeServer.RouteToHandler("HEAD", "/", ghttp.RespondWith(http.StatusMethodNotAllowed, nil))
+		Expect(client.Warmup(context.Background())).To(Succeed())
+	})
+
+	It("fails when the auctioneer cannot be reached", func() {
+		fakeServer.Close()
+		Expect(errors.Is(client.Warmup(context.Background()), ErrAuctioneerUnreachable)).To(BeTrue())
+	})
+})
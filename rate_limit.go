@@ -0,0 +1,203 @@
+package auctioneer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WithRateLimit caps the auction requests the client sends, across all
+// operations, at qps per second, allowing bursts of up to burst requests.
+// Requests over the cap wait for capacity or for their context to be done.
+// While requests of several operations are waiting, capacity is shared
+// between the operations in proportion to their WithOperationWeight
+// weights, so a burst of one cannot starve the others.
+func WithRateLimit(qps float64, burst int) ClientOption {
+	return func(c *auctioneerClient) {
+		c.rateLimitQPS = qps
+		c.rateLimitBurst = burst
+	}
+}
+
+// WithOperationWeight sets the share of the WithRateLimit capacity that
+// requests for operation, OperationLRP or OperationTask, get while other
+// operations are also waiting. Operations default to a weight of 1.
+func WithOperationWeight(operation string, weight float64) ClientOption {
+	return func(c *auctioneerClient) {
+		if weight <= 0 {
+			return
+		}
+		if c.operationWeights == nil {
+			c.operationWeights = map[string]float64{}
+		}
+		c.operationWeights[operation] = weight
+	}
+}
+
+// rateLimiter is a token bucket whose tokens are handed to waiting
+// operations by weighted fair queuing: the operation with the least virtual
+// time, which advances by 1/weight for each token it is granted, goes next.
+type rateLimiter struct {
+	interval time.Duration
+	burst    float64
+	weights  map[string]float64
+
+	lock        sync.Mutex
+	tokens      float64
+	last        time.Time
+	virtualTime float64
+	finishTimes map[string]float64
+	waiters     map[string][]chan struct{}
+	timer       *time.Timer
+}
+
+func newRateLimiter(qps float64, burst int, weights map[string]float64) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimiter{
+		interval:    time.Duration(float64(time.Second) / qps),
+		burst:       float64(burst),
+		weights:     weights,
+		tokens:      float64(burst),
+		last:        time.Now(),
+		finishTimes: map[string]float64{},
+		waiters:     map[string][]chan struct{}{},
+	}
+}
+
+// wait blocks until a request for operation may be sent or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context, operation string) error {
+	l.lock.Lock()
+	l.refill()
+
+	if len(l.waiters[operation]) == 0 {
+		l.finishTimes[operation] = maxFloat(l.finishTimes[operation], l.virtualTime)
+	}
+
+	if l.tokens >= 1 && !l.hasWaiters() {
+		l.grant(operation)
+		l.lock.Unlock()
+		return nil
+	}
+
+	ready := make(chan struct{})
+	l.waiters[operation] = append(l.waiters[operation], ready)
+	l.schedule()
+	l.lock.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		if !l.cancel(operation, ready) {
+			// The token was granted while ctx was being canceled.
+			return nil
+		}
+		return fmt.Errorf("waiting for rate limit: %w", ctx.Err())
+	}
+}
+
+func (l *rateLimiter) refill() {
+	now := time.Now()
+	l.tokens += float64(now.Sub(l.last)) / float64(l.interval)
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+}
+
+func (l *rateLimiter) hasWaiters() bool {
+	for _, waiters := range l.waiters {
+		if len(waiters) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *rateLimiter) grant(operation string) {
+	l.tokens--
+	l.virtualTime = l.finishTimes[operation]
+	l.finishTimes[operation] += 1 / l.weight(operation)
+}
+
+func (l *rateLimiter) weight(operation string) float64 {
+	if weight, ok := l.weights[operation]; ok {
+		return weight
+	}
+	return 1
+}
+
+// dispatch hands the available tokens to the waiters, fairest operation
+// first, and schedules itself again while waiters remain.
+func (l *rateLimiter) dispatch() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.timer = nil
+	l.refill()
+
+	for l.tokens >= 1 {
+		operation, ok := l.nextOperation()
+		if !ok {
+			return
+		}
+
+		close(l.waiters[operation][0])
+		l.waiters[operation] = l.waiters[operation][1:]
+		l.grant(operation)
+	}
+
+	l.schedule()
+}
+
+func (l *rateLimiter) nextOperation() (string, bool) {
+	var next string
+	found := false
+	for operation, waiters := range l.waiters {
+		if len(waiters) == 0 {
+			continue
+		}
+		if !found || l.finishTimes[operation] < l.finishTimes[next] ||
+			(l.finishTimes[operation] == l.finishTimes[next] && operation < next) {
+			next = operation
+			found = true
+		}
+	}
+	return next, found
+}
+
+func (l *rateLimiter) schedule() {
+	if l.timer != nil || !l.hasWaiters() {
+		return
+	}
+
+	delay := time.Duration((1 - l.tokens) * float64(l.interval))
+	l.timer = time.AfterFunc(delay, l.dispatch)
+}
+
+// cancel removes ready from the operation's waiters. It returns false when
+// ready was already granted a token.
+func (l *rateLimiter) cancel(operation string, ready chan struct{}) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	waiters := l.waiters[operation]
+	for i, waiter := range waiters {
+		if waiter == ready {
+			l.waiters[operation] = append(waiters[:i:i], waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
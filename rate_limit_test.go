@@ -0,0 +1,103 @@
+package auctioneer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("WithRateLimit", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+
+		lock  sync.Mutex
+		paths []string
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+
+		paths = nil
+		fakeServer.RouteToHandler("POST", "/v1/lrps", func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			paths = append(paths, r.URL.Path)
+			lock.Unlock()
+			w.WriteHeader(http.StatusAccepted)
+		})
+		fakeServer.RouteToHandler("POST", "/v1/tasks", func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			paths = append(paths, r.URL.Path)
+			lock.Unlock()
+			w.WriteHeader(http.StatusAccepted)
+		})
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("spaces requests beyond the burst", func() {
+		client := NewClient(fakeServer.URL(), WithRateLimit(20, 1))
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		}
+		Expect(time.Since(start)).To(BeNumerically(">=", 90*time.Millisecond))
+	})
+
+	It("fails a waiting request when its context is done", func() {
+		client := NewClient(fakeServer.URL(), WithRateLimit(1, 1))
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := client.RequestTaskAuctionsWithResult(logger, ctx, []*TaskStartRequest{})
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("shares the capacity between operations by weight", func() {
+		client := NewClient(fakeServer.URL(), WithRateLimit(50, 1), WithOperationWeight(OperationLRP, 3))
+		Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).To(Succeed())
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(2)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).To(Succeed())
+			}()
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			}()
+		}
+		wg.Wait()
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		lrps := 0
+		for _, path := range paths[1:9] {
+			if path == "/v1/lrps" {
+				lrps++
+			}
+		}
+		Expect(lrps).To(BeNumerically(">=", 5))
+	})
+})
@@ -0,0 +1,43 @@
+package auctioneer
+
+import "encoding/json"
+
+// RejectionReason is the machine-readable reason the auctioneer gives for
+// rejecting an auction. Codes this client does not recognize decode as
+// RejectionReasonUnknown.
+type RejectionReason string
+
+const (
+	RejectionReasonUnknown               RejectionReason = "unknown"
+	RejectionReasonInvalidRequest        RejectionReason = "invalid_request"
+	RejectionReasonInsufficientResources RejectionReason = "insufficient_resources"
+	RejectionReasonPlacementConstraint   RejectionReason = "placement_constraint"
+	RejectionReasonUnknownCell           RejectionReason = "unknown_cell"
+	RejectionReasonUnknownDomain         RejectionReason = "unknown_domain"
+)
+
+// Temporary reports whether an auction rejected for r may succeed if
+// submitted again later, once capacity frees up.
+func (r RejectionReason) Temporary() bool {
+	return r == RejectionReasonInsufficientResources
+}
+
+func (r *RejectionReason) UnmarshalJSON(data []byte) error {
+	var code string
+	err := json.Unmarshal(data, &code)
+	if err != nil {
+		return err
+	}
+
+	switch reason := RejectionReason(code); reason {
+	case RejectionReasonInvalidRequest,
+		RejectionReasonInsufficientResources,
+		RejectionReasonPlacementConstraint,
+		RejectionReasonUnknownCell,
+		RejectionReasonUnknownDomain:
+		*r = reason
+	default:
+		*r = RejectionReasonUnknown
+	}
+	return nil
+}
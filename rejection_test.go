@@ -0,0 +1,44 @@
+package auctioneer_test
+
+import (
+	"encoding/json"
+
+	. "code.cloudfoundry.org/auctioneer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RejectionReason", func() {
+	decode := func(body string) (RejectionReason, error) {
+		var rejection DryRunRejection
+		err := json.Unmarshal([]byte(body), &rejection)
+		return rejection.Reason, err
+	}
+
+	It("decodes known reason codes", func() {
+		Expect(decode(`{"reason":"insufficient_resources"}`)).To(Equal(RejectionReasonInsufficientResources))
+		Expect(decode(`{"reason":"placement_constraint"}`)).To(Equal(RejectionReasonPlacementConstraint))
+		Expect(decode(`{"reason":"unknown_cell"}`)).To(Equal(RejectionReasonUnknownCell))
+		Expect(decode(`{"reason":"unknown_domain"}`)).To(Equal(RejectionReasonUnknownDomain))
+	})
+
+	It("decodes unrecognized codes as unknown", func() {
+		Expect(decode(`{"reason":"cosmic_rays"}`)).To(Equal(RejectionReasonUnknown))
+	})
+
+	It("leaves the reason empty when the auctioneer gives none", func() {
+		Expect(decode(`{}`)).To(BeEmpty())
+	})
+
+	It("rejects a reason that is not a string", func() {
+		_, err := decode(`{"reason":7}`)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("treats only insufficient resources as temporary", func() {
+		Expect(RejectionReasonInsufficientResources.Temporary()).To(BeTrue())
+		Expect(RejectionReasonUnknownDomain.Temporary()).To(BeFalse())
+		Expect(RejectionReasonUnknown.Temporary()).To(BeFalse())
+	})
+})
@@ -0,0 +1,199 @@
+package auctioneer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+const (
+	retryInitialBackoff = 100 * time.Millisecond
+	retryMaxBackoff     = 2 * time.Second
+)
+
+// WithRetries retries a request that fails with a retryable error (see
+// IsRetryable and WithFailureClassifier) up to maxRetries times, backing
+// off between attempts.
+//
+// Auction submissions are not idempotent: an auctioneer that received a
+// batch before the connection failed may already have scheduled it, and
+// submitting it again can start the same LRP instances or tasks twice. By
+// default an auction submission is therefore only retried when it failed
+// before any of it was written to the connection, as when the auctioneer
+// is unreachable. WithRetryAfterSend lifts that restriction. Batch status
+// polls are idempotent and are always eligible.
+//
+// Each failed attempt is logged at debug level as a "retry-decision" with
+// the attempt number, why it failed, and either the backoff before the next
+// attempt or why it is not retried.
+func WithRetries(maxRetries int) ClientOption {
+	return func(c *auctioneerClient) {
+		c.maxRetries = maxRetries
+	}
+}
+
+// WithRetryAfterSend makes auction submissions eligible for retry even after
+// they were partly or fully written to the connection. Use it only when
+// duplicate auctions are acceptable: the auctioneer may act on both the
+// original submission and the retry, double-scheduling LRP instances or
+// tasks. It has no effect without WithRetries.
+func WithRetryAfterSend() ClientOption {
+	return func(c *auctioneerClient) {
+		c.retryAfterSend = true
+	}
+}
+
+// doRequestWithRetries sends req, retrying it as WithRetries allows. With
+// retries enabled, each attempt is traced as a child of span, if not nil.
+func (c *auctioneerClient) doRequestWithRetries(logger lager.Logger, req *http.Request, span opentracing.Span, outcome *requestOutcome) (*http.Response, error) {
+	if c.maxRetries <= 0 {
+		return c.doRequestWithFallback(logger, req, outcome)
+	}
+
+	ctx := req.Context()
+	scheme := req.URL.Scheme
+	backoff := retryInitialBackoff
+
+	for attempt := 0; ; attempt++ {
+		attemptSpan := startAttemptSpan(span, req.Method, attempt)
+
+		var sent int32
+		trace := &httptrace.ClientTrace{
+			WroteHeaderField: func(string, []string) {
+				atomic.StoreInt32(&sent, 1)
+			},
+		}
+		if attemptSpan != nil {
+			trace.GotConn = func(info httptrace.GotConnInfo) {
+				attemptSpan.SetTag("conn.reused", info.Reused)
+			}
+		}
+		attemptReq := req.WithContext(httptrace.WithClientTrace(ctx, trace))
+		c.injectSpan(logger, attemptSpan, attemptReq)
+
+		resp, err := c.doRequestWithFallback(logger, attemptReq, outcome)
+		retryable := c.isRetryableAttempt(resp, err)
+		retry := attempt < c.maxRetries && retryable
+
+		attemptErr := err
+		if retry && resp != nil {
+			// a FailureClassifier asked for the response to be retried
+			attemptErr = newStatusError("", resp)
+		}
+
+		declined := ""
+		switch {
+		case !retryable:
+			declined = "not-retryable"
+		case !retry:
+			declined = "retries-exhausted"
+		case atomic.LoadInt32(&sent) == 1 && !c.retryAfterSend && !isIdempotent(req):
+			c.logError(logger, "not-retrying-after-send", attemptErr)
+			declined = "already-sent"
+			retry = false
+		}
+		if err != nil || retryable {
+			logRetryDecision(logger, attempt, resp, err, declined, backoff)
+		}
+
+		finishAttemptSpan(attemptSpan, resp, err, retry)
+		if !retry {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		c.logError(logger, "retrying-request", attemptErr, lager.Data{"attempt": attempt + 1})
+		c.metrics.IncrementCounter(RetryMetric, nil)
+		atomic.AddUint64(&c.stats.retries, 1)
+		if outcome != nil {
+			outcome.retries++
+		}
+
+		err = c.waitToRetry(ctx, backoff)
+		if err != nil {
+			return nil, err
+		}
+		backoff *= 2
+		if backoff > retryMaxBackoff {
+			backoff = retryMaxBackoff
+		}
+
+		// the insecure fallback may have downgraded the scheme
+		req.URL.Scheme = scheme
+		setRequestTimeoutHeader(req)
+		if req.GetBody != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// logRetryDecision logs, at debug level, whether the failed attempt-th
+// attempt of a request is retried, why it failed and, if it is retried, the
+// backoff before the next attempt. declined names why a failed attempt is
+// not retried, and is empty when it is.
+func logRetryDecision(logger lager.Logger, attempt int, resp *http.Response, err error, declined string, backoff time.Duration) {
+	data := lager.Data{
+		"attempt": attempt + 1,
+		"reason":  retryReason(resp, err),
+		"retry":   declined == "",
+	}
+	if resp != nil {
+		data["status"] = resp.StatusCode
+	}
+	if err != nil {
+		data["error"] = err.Error()
+	}
+	if declined == "" {
+		data["backoff"] = backoff.String()
+	} else {
+		data["declined"] = declined
+	}
+	logger.Debug("retry-decision", data)
+}
+
+// retryReason describes how an attempt failed: "status" for a response a
+// FailureClassifier asked to retry, such as a 503 or 429, and otherwise
+// "backend-timeout", "timeout" or "network-error".
+func retryReason(resp *http.Response, err error) string {
+	if err == nil && resp != nil {
+		return "status"
+	}
+
+	var backendTimeoutErr *BackendTimeoutError
+	if errors.As(err, &backendTimeoutErr) {
+		return "backend-timeout"
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+	return "network-error"
+}
+
+func isIdempotent(req *http.Request) bool {
+	return req.Method == http.MethodGet || req.Method == http.MethodHead
+}
+
+func (c *auctioneerClient) waitToRetry(ctx context.Context, backoff time.Duration) error {
+	timer := c.clock.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
@@ -0,0 +1,250 @@
+package auctioneer_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+func closeConnection(w http.ResponseWriter, r *http.Request) {
+	ioutil.ReadAll(r.Body)
+	conn, _, err := w.(http.Hijacker).Hijack()
+	Expect(err).NotTo(HaveOccurred())
+	conn.Close()
+}
+
+var _ = Describe("Retries", func() {
+	var (
+		logger      *lagertest.TestLogger
+		fakeServer  *ghttp.Server
+		client      ExtendedClient
+		failedDials int32
+	)
+
+	// failDials makes the first n dials fail before anything is sent.
+	failDials := func(n int32) ClientOption {
+		return WithTransportConfig(func(tr *http.Transport) {
+			dialer := &net.Dialer{}
+			tr.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				if atomic.AddInt32(&failedDials, 1) <= n {
+					return nil, &net.OpError{Op: "dial", Net: network, Err: errors.New("connection refused")}
+				}
+				return dialer.DialContext(ctx, network, addr)
+			}
+		})
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		failedDials = 0
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	Context("without WithRetries", func() {
+		BeforeEach(func() {
+			client = NewClient(fakeServer.URL(), failDials(1))
+		})
+
+		It("does not retry", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+			Expect(atomic.LoadInt32(&failedDials)).To(BeEquivalentTo(1))
+		})
+	})
+
+	Context("when the connection fails before the request is sent", func() {
+		BeforeEach(func() {
+			client = NewClient(fakeServer.URL(), WithRetries(2), failDials(2))
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+		})
+
+		It("retries the submission", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{{ProcessGuid: "some-guid"}})).To(Succeed())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+			Expect(atomic.LoadInt32(&failedDials)).To(BeEquivalentTo(3))
+		})
+
+		It("reports the retries in the result", func() {
+			result, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), []*LRPStartRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Retries).To(Equal(2))
+			Expect(result.UsedFallback).To(BeFalse())
+		})
+
+		It("backs off between attempts", func() {
+			fakeClock := fakeclock.NewFakeClock(time.Now())
+			client = NewClient(fakeServer.URL(), WithRetries(2), failDials(2), WithClock(fakeClock))
+
+			errs := make(chan error, 1)
+			go func() {
+				errs <- client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+			}()
+
+			fakeClock.WaitForWatcherAndIncrement(100 * time.Millisecond)
+			Consistently(errs).ShouldNot(Receive())
+
+			fakeClock.WaitForWatcherAndIncrement(200 * time.Millisecond)
+			Eventually(errs).Should(Receive(BeNil()))
+			Expect(atomic.LoadInt32(&failedDials)).To(BeEquivalentTo(3))
+		})
+
+		It("gives up after the configured number of retries", func() {
+			client = NewClient(fakeServer.URL(), WithRetries(1), failDials(2))
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+			Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+		})
+
+		It("stops when the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+			Expect(err).To(HaveOccurred())
+			Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+		})
+	})
+
+	Context("when the connection fails after the request is sent", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(
+				closeConnection,
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			)
+		})
+
+		It("does not retry the submission by default", func() {
+			client = NewClient(fakeServer.URL(), WithRetries(2))
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("retries the submission with WithRetryAfterSend", func() {
+			client = NewClient(fakeServer.URL(), WithRetries(2), WithRetryAfterSend())
+			lrpStarts := []*LRPStartRequest{{ProcessGuid: "some-guid"}}
+			fakeServer.SetHandler(1, ghttp.CombineHandlers(
+				ghttp.VerifyJSONRepresenting(lrpStarts),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+
+			Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(2))
+		})
+	})
+
+	Describe("logging retry decisions", func() {
+		retryDecisions := func() []lager.LogFormat {
+			var decisions []lager.LogFormat
+			for _, log := range logger.Logs() {
+				if strings.HasSuffix(log.Message, ".retry-decision") {
+					decisions = append(decisions, log)
+				}
+			}
+			return decisions
+		}
+
+		It("logs each retry with why the attempt failed and the backoff", func() {
+			client = NewClient(fakeServer.URL(), WithRetries(2), failDials(2))
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+
+			decisions := retryDecisions()
+			Expect(decisions).To(HaveLen(2))
+			Expect(decisions[0].Message).To(Equal("test.request-lrp-auctions.retry-decision"))
+			Expect(decisions[0].LogLevel).To(Equal(lager.DEBUG))
+			Expect(decisions[0].Data).To(HaveKeyWithValue("attempt", BeNumerically("==", 1)))
+			Expect(decisions[0].Data).To(HaveKeyWithValue("reason", "network-error"))
+			Expect(decisions[0].Data).To(HaveKeyWithValue("retry", true))
+			Expect(decisions[0].Data).To(HaveKeyWithValue("backoff", "100ms"))
+			Expect(decisions[0].Data).To(HaveKeyWithValue("error", ContainSubstring("connection refused")))
+			Expect(decisions[1].Data).To(HaveKeyWithValue("attempt", BeNumerically("==", 2)))
+			Expect(decisions[1].Data).To(HaveKeyWithValue("backoff", "200ms"))
+		})
+
+		It("logs the status of responses a classifier retries", func() {
+			classifier := func(resp *http.Response, err error) Classification {
+				switch {
+				case err != nil:
+					return ClassificationPermanentError
+				case resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusTooManyRequests:
+					return ClassificationRetryableError
+				case resp.StatusCode == http.StatusAccepted:
+					return ClassificationSuccess
+				default:
+					return ClassificationPermanentError
+				}
+			}
+			client = NewClient(fakeServer.URL(), WithRetries(2), WithRetryAfterSend(), WithFailureClassifier(classifier))
+			fakeServer.AppendHandlers(
+				ghttp.RespondWith(http.StatusServiceUnavailable, nil),
+				ghttp.RespondWith(http.StatusTooManyRequests, nil),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			)
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+
+			decisions := retryDecisions()
+			Expect(decisions).To(HaveLen(2))
+			Expect(decisions[0].Data).To(HaveKeyWithValue("reason", "status"))
+			Expect(decisions[0].Data).To(HaveKeyWithValue("status", BeNumerically("==", http.StatusServiceUnavailable)))
+			Expect(decisions[1].Data).To(HaveKeyWithValue("status", BeNumerically("==", http.StatusTooManyRequests)))
+		})
+
+		It("logs why a failed attempt is not retried", func() {
+			client = NewClient(fakeServer.URL(), WithRetries(2))
+			fakeServer.AppendHandlers(closeConnection)
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+
+			decisions := retryDecisions()
+			Expect(decisions).To(HaveLen(1))
+			Expect(decisions[0].Data).To(HaveKeyWithValue("retry", false))
+			Expect(decisions[0].Data).To(HaveKeyWithValue("declined", "already-sent"))
+			Expect(decisions[0].Data).NotTo(HaveKey("backoff"))
+		})
+
+		It("logs when the retries are exhausted", func() {
+			client = NewClient(fakeServer.URL(), WithRetries(1), failDials(2))
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+
+			decisions := retryDecisions()
+			Expect(decisions).To(HaveLen(2))
+			Expect(decisions[1].Data).To(HaveKeyWithValue("retry", false))
+			Expect(decisions[1].Data).To(HaveKeyWithValue("declined", "retries-exhausted"))
+		})
+	})
+
+	Context("when a batch status poll fails after it is sent", func() {
+		BeforeEach(func() {
+			client = NewClient(fakeServer.URL(), WithRetries(1))
+			fakeServer.AppendHandlers(
+				closeConnection,
+				ghttp.RespondWithJSONEncoded(http.StatusOK, BatchStatus{State: BatchStateComplete}),
+			)
+		})
+
+		It("retries the poll", func() {
+			status, err := client.WaitForBatch(logger, context.Background(), fakeServer.URL()+"/v1/batches/some-batch")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.State).To(Equal(BatchStateComplete))
+		})
+	})
+})
@@ -0,0 +1,213 @@
+package auctioneer
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// ErrCertificateRevoked is matched, with errors.Is, by the error a request
+// fails with when the auctioneer's certificate has been revoked.
+var ErrCertificateRevoked = errors.New("auctioneer certificate is revoked")
+
+// ErrOCSPStapleInvalid is matched, with errors.Is, by the error a request
+// made with WithOCSPStapling fails with when the auctioneer staples no OCSP
+// response, or one that is malformed, expired or not about its certificate.
+var ErrOCSPStapleInvalid = errors.New("auctioneer did not staple a valid OCSP response")
+
+// WithOCSPStapling fails the TLS handshake unless the auctioneer staples an
+// OCSP response, signed by the issuer of its certificate and not past its
+// next update, that reports the certificate as good. A revoked certificate
+// fails with ErrCertificateRevoked and a missing or unusable staple with
+// ErrOCSPStapleInvalid. Requiring TLS, as NewSecureClient's requireTLS does,
+// keeps a failed check from falling back to plain HTTP.
+func WithOCSPStapling() ClientOption {
+	return func(c *auctioneerClient) {
+		c.ocspStapling = true
+	}
+}
+
+// verifyOCSPStaple is a tls.Config VerifyConnection callback that checks the
+// OCSP response stapled to the handshake.
+func (c *auctioneerClient) verifyOCSPStaple(state tls.ConnectionState) error {
+	if len(state.OCSPResponse) == 0 {
+		return fmt.Errorf("%w: no OCSP response was stapled", ErrOCSPStapleInvalid)
+	}
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("auctioneer presented no certificate")
+	}
+
+	leaf := state.PeerCertificates[0]
+	issuer := certificateIssuer(state)
+	if issuer == nil {
+		return fmt.Errorf("%w: the issuer of the auctioneer certificate is unknown", ErrOCSPStapleInvalid)
+	}
+
+	resp, err := ocsp.ParseResponseForCert(state.OCSPResponse, leaf, issuer)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrOCSPStapleInvalid, err)
+	}
+	if !resp.NextUpdate.IsZero() && c.clock.Now().After(resp.NextUpdate) {
+		return fmt.Errorf("%w: the OCSP response expired at %s", ErrOCSPStapleInvalid, resp.NextUpdate)
+	}
+
+	switch resp.Status {
+	case ocsp.Good:
+		return nil
+	case ocsp.Revoked:
+		return fmt.Errorf("%w: revoked at %s", ErrCertificateRevoked, resp.RevokedAt)
+	default:
+		return fmt.Errorf("%w: the OCSP responder does not know the certificate", ErrOCSPStapleInvalid)
+	}
+}
+
+// certificateIssuer returns the certificate that issued the auctioneer's,
+// from the verified chain or, when the standard verification is replaced by
+// WithServerNames, from the certificates the auctioneer presented.
+func certificateIssuer(state tls.ConnectionState) *x509.Certificate {
+	if len(state.VerifiedChains) > 0 && len(state.VerifiedChains[0]) > 1 {
+		return state.VerifiedChains[0][1]
+	}
+	if len(state.PeerCertificates) > 1 {
+		return state.PeerCertificates[1]
+	}
+	return nil
+}
+
+// WithCRL rejects the auctioneer's certificate when crl, a certificate
+// revocation list in PEM or DER form from the certificate's issuer, lists
+// it, failing the handshake with ErrCertificateRevoked. The list is trusted
+// as given: its signature is not checked. Requiring TLS, as
+// NewSecureClient's requireTLS does, keeps a failed check from falling back
+// to plain HTTP.
+func WithCRL(crl []byte) ClientOption {
+	return func(c *auctioneerClient) {
+		c.crl = &crlChecker{data: crl}
+	}
+}
+
+// WithCRLFile checks the auctioneer's certificate against the revocation
+// list in path as WithCRL does, reading it again once reloadInterval has
+// passed since it was last read, so that updates to the list take effect
+// without restarting. A non-positive reloadInterval reads it only once. A
+// list that cannot be read or parsed fails the handshake the first time;
+// after that, the last list read stays in use until a reload succeeds.
+func WithCRLFile(path string, reloadInterval time.Duration) ClientOption {
+	return func(c *auctioneerClient) {
+		c.crl = &crlChecker{path: path, reloadInterval: reloadInterval}
+	}
+}
+
+// crlChecker holds the revocation list a client checks certificates
+// against, loading it from its file when due.
+type crlChecker struct {
+	data           []byte
+	path           string
+	reloadInterval time.Duration
+
+	lock     sync.Mutex
+	list     *revocationList
+	loadedAt time.Time
+}
+
+// revocationList is a parsed CRL, indexed by serial number.
+type revocationList struct {
+	rawIssuer []byte
+	revoked   map[string]time.Time
+}
+
+func (c *crlChecker) current(now time.Time) (*revocationList, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	due := c.list == nil || (c.path != "" && c.reloadInterval > 0 && now.Sub(c.loadedAt) >= c.reloadInterval)
+	if !due {
+		return c.list, nil
+	}
+
+	list, err := c.load()
+	if err != nil {
+		if c.list != nil {
+			// keep checking against the last list read
+			return c.list, nil
+		}
+		return nil, err
+	}
+
+	c.list = list
+	c.loadedAt = now
+	return list, nil
+}
+
+func (c *crlChecker) load() (*revocationList, error) {
+	data := c.data
+	if c.path != "" {
+		var err error
+		data, err = ioutil.ReadFile(c.path)
+		if err != nil {
+			return nil, fmt.Errorf("reading certificate revocation list: %w", err)
+		}
+	}
+
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+
+	crl, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing certificate revocation list: %w", err)
+	}
+
+	list := &revocationList{rawIssuer: crl.RawIssuer, revoked: map[string]time.Time{}}
+	for _, entry := range crl.RevokedCertificateEntries {
+		list.revoked[entry.SerialNumber.String()] = entry.RevocationTime
+	}
+	return list, nil
+}
+
+// verifyCRL is a tls.Config VerifyConnection callback that rejects a leaf
+// certificate on the client's revocation list. Unlike VerifyPeerCertificate,
+// VerifyConnection also runs for resumed TLS sessions, so a certificate
+// revoked after its session was cached is still rejected.
+func (c *auctioneerClient) verifyCRL(state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("auctioneer presented no certificate")
+	}
+	leaf := state.PeerCertificates[0]
+
+	list, err := c.crl.current(c.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(leaf.RawIssuer, list.rawIssuer) {
+		return nil
+	}
+	if revokedAt, ok := list.revoked[leaf.SerialNumber.String()]; ok {
+		return fmt.Errorf("%w: listed by its issuer's CRL as revoked at %s", ErrCertificateRevoked, revokedAt)
+	}
+	return nil
+}
+
+// chainConnectionVerification returns a VerifyConnection callback that runs
+// first, if not nil, and then second.
+func chainConnectionVerification(first, second func(tls.ConnectionState) error) func(tls.ConnectionState) error {
+	if first == nil {
+		return second
+	}
+	return func(state tls.ConnectionState) error {
+		err := first(state)
+		if err != nil {
+			return err
+		}
+		return second(state)
+	}
+}
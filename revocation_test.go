@@ -0,0 +1,242 @@
+package auctioneer_test
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"golang.org/x/crypto/ocsp"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("WithOCSPStapling", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		fixtures   tlsFixtures
+		certDir    string
+	)
+
+	// startServer serves the fixtures' server certificate with staple, if
+	// not nil.
+	startServer := func(staple []byte) {
+		tlsConfig := fixtures.ServerTLSConfig()
+		tlsConfig.Certificates[0].OCSPStaple = staple
+
+		fakeServer = ghttp.NewUnstartedServer()
+		fakeServer.HTTPTestServer.TLS = tlsConfig
+		fakeServer.HTTPTestServer.StartTLS()
+		fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+	}
+
+	// newStaple returns an OCSP response from the fixtures' CA about the
+	// server certificate.
+	newStaple := func(status int, nextUpdate time.Time) []byte {
+		serverCert, err := tls.LoadX509KeyPair(fixtures.ServerCertFile, fixtures.ServerKeyFile)
+		Expect(err).NotTo(HaveOccurred())
+		leaf, err := x509.ParseCertificate(serverCert.Certificate[0])
+		Expect(err).NotTo(HaveOccurred())
+
+		staple, err := ocsp.CreateResponse(fixtures.CACert, fixtures.CACert, ocsp.Response{
+			Status:       status,
+			SerialNumber: leaf.SerialNumber,
+			ThisUpdate:   time.Now().Add(-time.Minute),
+			NextUpdate:   nextUpdate,
+			RevokedAt:    time.Now().Add(-time.Minute),
+		}, fixtures.CAKey)
+		Expect(err).NotTo(HaveOccurred())
+		return staple
+	}
+
+	newClient := func(opts ...ClientOption) ExtendedClient {
+		client, err := NewSecureClient(fakeServer.URL(), fixtures.CAFile, fixtures.ClientCertFile, fixtures.ClientKeyFile, true, opts...)
+		Expect(err).NotTo(HaveOccurred())
+		return client
+	}
+
+	BeforeEach(func() {
+		var err error
+		certDir, err = ioutil.TempDir("", "auctioneer-certs")
+		Expect(err).NotTo(HaveOccurred())
+
+		logger = lagertest.NewTestLogger("test")
+		fixtures = newTLSFixtures(certDir, nil, net.ParseIP("127.0.0.1"))
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+		os.RemoveAll(certDir)
+	})
+
+	It("accepts a certificate stapled as good", func() {
+		startServer(newStaple(ocsp.Good, time.Now().Add(time.Hour)))
+
+		client := newClient(WithOCSPStapling())
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+	})
+
+	It("rejects a certificate stapled as revoked", func() {
+		startServer(newStaple(ocsp.Revoked, time.Now().Add(time.Hour)))
+
+		client := newClient(WithOCSPStapling())
+		err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+		Expect(errors.Is(err, ErrCertificateRevoked)).To(BeTrue())
+		Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+	})
+
+	It("rejects a missing staple", func() {
+		startServer(nil)
+
+		client := newClient(WithOCSPStapling())
+		err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+		Expect(errors.Is(err, ErrOCSPStapleInvalid)).To(BeTrue())
+	})
+
+	It("rejects an expired staple", func() {
+		startServer(newStaple(ocsp.Good, time.Now().Add(-time.Second)))
+
+		client := newClient(WithOCSPStapling())
+		err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+		Expect(errors.Is(err, ErrOCSPStapleInvalid)).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring("expired")))
+	})
+
+	It("does not check staples by default", func() {
+		startServer(nil)
+
+		client := newClient()
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+	})
+})
+
+var _ = Describe("WithCRL", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		fixtures   tlsFixtures
+		certDir    string
+		serverCert *x509.Certificate
+	)
+
+	// newCRL returns a revocation list from the fixtures' CA listing serials.
+	newCRL := func(serials ...*big.Int) []byte {
+		template := &x509.RevocationList{
+			Number:     big.NewInt(time.Now().UnixNano()),
+			ThisUpdate: time.Now().Add(-time.Minute),
+			NextUpdate: time.Now().Add(time.Hour),
+		}
+		for _, serial := range serials {
+			template.RevokedCertificateEntries = append(template.RevokedCertificateEntries, x509.RevocationListEntry{
+				SerialNumber:   serial,
+				RevocationTime: time.Now().Add(-time.Minute),
+			})
+		}
+		crl, err := x509.CreateRevocationList(rand.Reader, template, fixtures.CACert, fixtures.CAKey)
+		Expect(err).NotTo(HaveOccurred())
+		return crl
+	}
+
+	newClient := func(opts ...ClientOption) ExtendedClient {
+		client, err := NewSecureClient(fakeServer.URL(), fixtures.CAFile, fixtures.ClientCertFile, fixtures.ClientKeyFile, true, opts...)
+		Expect(err).NotTo(HaveOccurred())
+		return client
+	}
+
+	BeforeEach(func() {
+		var err error
+		certDir, err = ioutil.TempDir("", "auctioneer-certs")
+		Expect(err).NotTo(HaveOccurred())
+
+		logger = lagertest.NewTestLogger("test")
+		fixtures = newTLSFixtures(certDir, nil, net.ParseIP("127.0.0.1"))
+
+		tlsCert, err := tls.LoadX509KeyPair(fixtures.ServerCertFile, fixtures.ServerKeyFile)
+		Expect(err).NotTo(HaveOccurred())
+		serverCert, err = x509.ParseCertificate(tlsCert.Certificate[0])
+		Expect(err).NotTo(HaveOccurred())
+
+		fakeServer = ghttp.NewUnstartedServer()
+		fakeServer.HTTPTestServer.TLS = fixtures.ServerTLSConfig()
+		// every request makes a new handshake
+		fakeServer.HTTPTestServer.Config.SetKeepAlivesEnabled(false)
+		fakeServer.HTTPTestServer.StartTLS()
+		fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+		os.RemoveAll(certDir)
+	})
+
+	It("rejects a certificate on the list", func() {
+		client := newClient(WithCRL(newCRL(serverCert.SerialNumber)))
+
+		err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+		Expect(errors.Is(err, ErrCertificateRevoked)).To(BeTrue())
+		Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+	})
+
+	It("accepts a certificate not on the list", func() {
+		client := newClient(WithCRL(newCRL(big.NewInt(1000))))
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+	})
+
+	It("fails the handshake when the list cannot be parsed", func() {
+		client := newClient(WithCRL([]byte("not a crl")))
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(MatchError(ContainSubstring("parsing certificate revocation list")))
+	})
+
+	Describe("WithCRLFile", func() {
+		var (
+			crlFile   string
+			fakeClock *fakeclock.FakeClock
+		)
+
+		BeforeEach(func() {
+			crlFile = filepath.Join(certDir, "auctioneer.crl")
+			writePEM(crlFile, "X509 CRL", newCRL())
+			fakeClock = fakeclock.NewFakeClock(time.Now())
+		})
+
+		It("reads the list again once the reload interval has passed", func() {
+			client := newClient(WithCRLFile(crlFile, time.Minute), WithClock(fakeClock))
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+
+			writePEM(crlFile, "X509 CRL", newCRL(serverCert.SerialNumber))
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+
+			fakeClock.Increment(time.Minute)
+			err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+			Expect(errors.Is(err, ErrCertificateRevoked)).To(BeTrue())
+		})
+
+		It("keeps the last list read when a reload fails", func() {
+			writePEM(crlFile, "X509 CRL", newCRL(serverCert.SerialNumber))
+			client := newClient(WithCRLFile(crlFile, time.Minute), WithClock(fakeClock))
+			Expect(errors.Is(client.RequestLRPAuctions(logger, []*LRPStartRequest{}), ErrCertificateRevoked)).To(BeTrue())
+
+			Expect(os.Remove(crlFile)).To(Succeed())
+			fakeClock.Increment(time.Minute)
+			Expect(errors.Is(client.RequestLRPAuctions(logger, []*LRPStartRequest{}), ErrCertificateRevoked)).To(BeTrue())
+		})
+
+		It("fails the handshake when the list cannot be read", func() {
+			client := newClient(WithCRLFile(filepath.Join(certDir, "missing.crl"), time.Minute))
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(MatchError(ContainSubstring("reading certificate revocation list")))
+		})
+	})
+})
@@ -0,0 +1,23 @@
+package auctioneer
+
+import "net/http"
+
+// ServerVersionHeader is the response header in which the auctioneer reports
+// its version.
+const ServerVersionHeader = "X-Auctioneer-Version"
+
+// ServerVersion returns the version the auctioneer reported in its most
+// recent response, or "" if that response carried no ServerVersionHeader or
+// no response has been received yet. During a rolling upgrade it changes as
+// requests reach auctioneers of different versions.
+func (c *auctioneerClient) ServerVersion() string {
+	version, _ := c.serverVersion.Load().(string)
+	return version
+}
+
+func (c *auctioneerClient) recordServerVersion(resp *http.Response) {
+	version := resp.Header.Get(ServerVersionHeader)
+	if version != c.ServerVersion() {
+		c.serverVersion.Store(version)
+	}
+}
@@ -0,0 +1,51 @@
+package auctioneer_test
+
+import (
+	"net/http"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("ServerVersion", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		client     ExtendedClient
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		client = NewClient(fakeServer.URL())
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("is empty before any response", func() {
+		Expect(client.ServerVersion()).To(BeEmpty())
+	})
+
+	It("follows the version reported by each response", func() {
+		fakeServer.AppendHandlers(
+			ghttp.RespondWith(http.StatusAccepted, "{}", http.Header{ServerVersionHeader: []string{"1.2.0"}}),
+			ghttp.RespondWith(http.StatusInternalServerError, "", http.Header{ServerVersionHeader: []string{"1.3.0"}}),
+			ghttp.RespondWith(http.StatusAccepted, "{}"),
+		)
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(client.ServerVersion()).To(Equal("1.2.0"))
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+		Expect(client.ServerVersion()).To(Equal("1.3.0"))
+
+		Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).To(Succeed())
+		Expect(client.ServerVersion()).To(BeEmpty())
+	})
+})
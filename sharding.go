@@ -0,0 +1,218 @@
+package auctioneer
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// ShardFunc returns the index, in [0, backends), of the backend that owns
+// the LRP with processGuid. Out-of-range indexes are reduced modulo
+// backends.
+type ShardFunc func(processGuid string, backends int) int
+
+// HashShard assigns each process GUID to a backend by its FNV-1a hash.
+func HashShard(processGuid string, backends int) int {
+	h := fnv.New32a()
+	h.Write([]byte(processGuid))
+	return int(h.Sum32() % uint32(backends))
+}
+
+// WithSharding splits LRP auction batches across backends, the distinct
+// base URLs of auctioneers that each own a share of the process GUIDs. shard
+// assigns each LRP to a backend, HashShard when nil, and the shards are
+// submitted concurrently, sharing the client's transport and TLS
+// configuration. LRP dry runs are split the same way. Task auctions and
+// other requests still go to the client's URL.
+//
+// A sharded batch succeeds only if every shard does; otherwise it fails with
+// a *ShardedAuctionError carrying each failed shard's error. Shards that
+// succeeded are not rolled back.
+func WithSharding(backends []string, shard ShardFunc) ClientOption {
+	return func(c *auctioneerClient) {
+		if shard == nil {
+			shard = HashShard
+		}
+		c.shardBackends = backends
+		c.shardFunc = shard
+	}
+}
+
+// ShardedAuctionError is returned when some shards of a sharded LRP auction
+// batch fail.
+type ShardedAuctionError struct {
+	// Errors is the error of each failed shard, by backend.
+	Errors map[string]error
+	// Shards is the number of shards submitted.
+	Shards int
+}
+
+func (e *ShardedAuctionError) Error() string {
+	backends := make([]string, 0, len(e.Errors))
+	for backend := range e.Errors {
+		backends = append(backends, backend)
+	}
+	sort.Strings(backends)
+
+	failures := make([]string, len(backends))
+	for i, backend := range backends {
+		failures[i] = fmt.Sprintf("%s: %s", backend, e.Errors[backend])
+	}
+
+	return fmt.Sprintf("lrp auctions failed on %d of %d shards: %s", len(e.Errors), e.Shards, strings.Join(failures, "; "))
+}
+
+// Unwrap returns the shards' errors, so that errors.Is and errors.As match
+// any of them.
+func (e *ShardedAuctionError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// splitShards splits lrpStarts by backend, returning with each shard the
+// index in lrpStarts of each of its entries.
+func (c *auctioneerClient) splitShards(lrpStarts []*LRPStartRequest) ([][]*LRPStartRequest, [][]int) {
+	n := len(c.shardBackends)
+	shards := make([][]*LRPStartRequest, n)
+	indices := make([][]int, n)
+	for j, lrpStart := range lrpStarts {
+		i := c.shardFunc(lrpStart.ProcessGuid, n) % n
+		if i < 0 {
+			i += n
+		}
+		shards[i] = append(shards[i], lrpStart)
+		indices[i] = append(indices[i], j)
+	}
+	return shards, indices
+}
+
+func (c *auctioneerClient) requestShardedLRPAuctions(logger lager.Logger, ctx context.Context, lrpStarts []*LRPStartRequest) (AuctionResult, error) {
+	shards, indices := c.splitShards(lrpStarts)
+
+	var (
+		wg        sync.WaitGroup
+		lock      sync.Mutex
+		locations = map[string]string{}
+		errs      = map[string]error{}
+		submitted int
+		combined  AuctionResult
+	)
+
+	for i, shard := range shards {
+		if len(shard) == 0 {
+			continue
+		}
+		submitted++
+
+		backend := c.shardBackends[i]
+		wg.Add(1)
+		go func(shard []*LRPStartRequest, indices []int) {
+			defer wg.Done()
+
+			result, err := c.requestAuctions(logger.Session("shard", lager.Data{"backend": backend}), ContextWithTargetURL(ctx, backend), auctionRequest{
+				operation: OperationLRP,
+				route:     CreateLRPAuctionsRoute,
+				auctions:  shard,
+				count:     len(shard),
+			})
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				errs[backend] = err
+				return
+			}
+			if result.Location != "" {
+				locations[backend] = result.Location
+			}
+			combined.Retries += result.Retries
+			combined.UsedFallback = combined.UsedFallback || result.UsedFallback
+			combined.Accepted += result.Accepted
+			for _, rejection := range result.Rejected {
+				if rejection.Index >= 0 && rejection.Index < len(indices) {
+					rejection.Index = indices[rejection.Index]
+				}
+				combined.Rejected = append(combined.Rejected, rejection)
+			}
+		}(shard, indices[i])
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return AuctionResult{}, &ShardedAuctionError{Errors: errs, Shards: submitted}
+	}
+
+	sort.Slice(combined.Rejected, func(i, j int) bool {
+		return combined.Rejected[i].Index < combined.Rejected[j].Index
+	})
+	combined.ShardLocations = locations
+	return combined, nil
+}
+
+// dryRunShardedLRPAuctions dry-runs each shard of lrpStarts on its backend,
+// combining their validation outcomes as requestShardedLRPAuctions combines
+// results.
+func (c *auctioneerClient) dryRunShardedLRPAuctions(logger lager.Logger, ctx context.Context, lrpStarts []*LRPStartRequest) (DryRunResult, error) {
+	shards, indices := c.splitShards(lrpStarts)
+
+	var (
+		wg        sync.WaitGroup
+		lock      sync.Mutex
+		errs      = map[string]error{}
+		submitted int
+		combined  DryRunResult
+	)
+
+	for i, shard := range shards {
+		if len(shard) == 0 {
+			continue
+		}
+		submitted++
+
+		backend := c.shardBackends[i]
+		wg.Add(1)
+		go func(shard []*LRPStartRequest, indices []int) {
+			defer wg.Done()
+
+			result, err := c.dryRunAuctions(logger.Session("shard", lager.Data{"backend": backend}), ContextWithTargetURL(ctx, backend), auctionRequest{
+				operation: OperationLRP,
+				route:     CreateLRPAuctionsRoute,
+				auctions:  shard,
+				count:     len(shard),
+				dryRun:    true,
+			})
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				errs[backend] = err
+				return
+			}
+			combined.Valid += result.Valid
+			for _, rejection := range result.Rejected {
+				if rejection.Index >= 0 && rejection.Index < len(indices) {
+					rejection.Index = indices[rejection.Index]
+				}
+				combined.Rejected = append(combined.Rejected, rejection)
+			}
+		}(shard, indices[i])
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return DryRunResult{}, &ShardedAuctionError{Errors: errs, Shards: submitted}
+	}
+
+	sort.Slice(combined.Rejected, func(i, j int) bool {
+		return combined.Rejected[i].Index < combined.Rejected[j].Index
+	})
+	return combined, nil
+}
@@ -0,0 +1,150 @@
+package auctioneer_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("WithSharding", func() {
+	var (
+		logger      *lagertest.TestLogger
+		fakeServer  *ghttp.Server
+		shardA      *ghttp.Server
+		shardB      *ghttp.Server
+		client      ExtendedClient
+		lrpStarts   []*LRPStartRequest
+		byFirstByte ShardFunc
+	)
+
+	newLRPStart := func(processGuid string) *LRPStartRequest {
+		start := NewLRPStartRequest(processGuid, "some-domain", []int{0}, rep.NewResource(1, 1, 1), rep.PlacementConstraint{})
+		return &start
+	}
+
+	// recordGuids accepts LRP auctions, appending their process GUIDs to
+	// guids.
+	recordGuids := func(guids *[]string, location string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			var starts []LRPStartRequest
+			Expect(json.NewDecoder(r.Body).Decode(&starts)).To(Succeed())
+			for _, start := range starts {
+				*guids = append(*guids, start.ProcessGuid)
+			}
+			w.Header().Set("Location", location)
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		shardA = ghttp.NewServer()
+		shardB = ghttp.NewServer()
+
+		byFirstByte = func(processGuid string, backends int) int {
+			return int(processGuid[0] - 'a')
+		}
+		client = NewClient(fakeServer.URL(), WithSharding([]string{shardA.URL(), shardB.URL()}, byFirstByte))
+		lrpStarts = []*LRPStartRequest{newLRPStart("a-1"), newLRPStart("b-1"), newLRPStart("a-2")}
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+		shardA.Close()
+		shardB.Close()
+	})
+
+	Context("when every shard accepts its auctions", func() {
+		var guidsA, guidsB []string
+
+		BeforeEach(func() {
+			guidsA, guidsB = nil, nil
+			shardA.RouteToHandler("POST", "/v1/lrps", recordGuids(&guidsA, "/v1/batches/a"))
+			shardB.RouteToHandler("POST", "/v1/lrps", recordGuids(&guidsB, "/v1/batches/b"))
+		})
+
+		It("sends each backend the LRPs it owns", func() {
+			Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+
+			Expect(guidsA).To(ConsistOf("a-1", "a-2"))
+			Expect(guidsB).To(ConsistOf("b-1"))
+			Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+		})
+
+		It("returns the location of each shard's batch", func() {
+			result, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), lrpStarts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.ShardLocations).To(Equal(map[string]string{
+				shardA.URL(): shardA.URL() + "/v1/batches/a",
+				shardB.URL(): shardB.URL() + "/v1/batches/b",
+			}))
+		})
+
+		It("combines the shards' results, indexed in the whole batch", func() {
+			shardA.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted,
+				`{"accepted":1,"rejected":[{"index":1,"error":"invalid"}]}`))
+
+			result, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), lrpStarts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Accepted).To(Equal(2))
+			Expect(result.Rejected).To(Equal([]DryRunRejection{{Index: 2, Error: "invalid"}}))
+		})
+
+		It("reduces out-of-range shard indexes", func() {
+			client = NewClient(fakeServer.URL(), WithSharding([]string{shardA.URL(), shardB.URL()}, func(string, int) int {
+				return -1
+			}))
+
+			Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+			Expect(guidsB).To(ConsistOf("a-1", "b-1", "a-2"))
+		})
+
+		It("does not contact backends without LRPs", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{newLRPStart("a-1")})).To(Succeed())
+			Expect(shardB.ReceivedRequests()).To(BeEmpty())
+		})
+	})
+
+	Context("when a shard fails", func() {
+		BeforeEach(func() {
+			shardA.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+			shardB.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusServiceUnavailable, "{}"))
+		})
+
+		It("returns a ShardedAuctionError with the failed shard's error", func() {
+			err := client.RequestLRPAuctions(logger, lrpStarts)
+
+			var shardedErr *ShardedAuctionError
+			Expect(errors.As(err, &shardedErr)).To(BeTrue())
+			Expect(shardedErr.Shards).To(Equal(2))
+			Expect(shardedErr.Errors).To(HaveKey(shardB.URL()))
+			Expect(shardedErr.Errors).NotTo(HaveKey(shardA.URL()))
+
+			var statusErr *StatusError
+			Expect(errors.As(err, &statusErr)).To(BeTrue())
+			Expect(statusErr.StatusCode).To(Equal(http.StatusServiceUnavailable))
+		})
+	})
+
+	It("sends task auctions to the client's URL", func() {
+		fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+
+		Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).To(Succeed())
+		Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("assigns a process GUID to the same backend every time", func() {
+		Expect(HashShard("some-process-guid", 3)).To(Equal(HashShard("some-process-guid", 3)))
+		Expect(HashShard("some-process-guid", 3)).To(BeNumerically("<", 3))
+	})
+})
@@ -0,0 +1,44 @@
+package auctioneer
+
+import (
+	"errors"
+
+	"github.com/tedsuo/rata"
+)
+
+// ErrTransportNotShareable is returned by NewClientSharingTransport for a
+// parent that was not returned by one of this package's constructors, such
+// as a fake.
+var ErrTransportNotShareable = errors.New("client transport cannot be shared")
+
+// NewClientSharingTransport returns a client for parent's auctioneer that
+// sends its requests through parent's transport and connection pool but
+// keeps its own policies. opts configure it as they would a new client,
+// starting from the defaults rather than from parent's options, so that,
+// for example, task auctions can be retried more than latency-critical LRP
+// auctions without opening a second pool of connections to the auctioneer.
+//
+// Options that configure the transport, such as WithResponseHeaderTimeout,
+// WithTransportConfig, WithServerNames and the dial and TLS session
+// options, have no effect on the returned client: parent's apply. TLS
+// material reloaded by either client's ReloadTLS is used by both. SetURL
+// affects only the client it is called on.
+func NewClientSharingTransport(parent ExtendedClient, opts ...ClientOption) (ExtendedClient, error) {
+	p, ok := parent.(*auctioneerClient)
+	if !ok {
+		return nil, ErrTransportNotShareable
+	}
+	owner := p
+	if p.transportOwner != nil {
+		owner = p.transportOwner
+	}
+
+	client := newDefaultClient(p.currentURL())
+	client.requireTLS = owner.requireTLS
+	client.transportOwner = owner
+	client.applyOptions(opts)
+	client.reqGen = rata.NewRequestGenerator(client.url, client.routes)
+	client.warnOnInsecureFallback(client.url)
+
+	return client, nil
+}
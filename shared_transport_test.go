@@ -0,0 +1,109 @@
+package auctioneer_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptrace"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/auctioneer/auctioneerfakes"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("NewClientSharingTransport", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		parent     ExtendedClient
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		parent = NewClient(fakeServer.URL())
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("sends requests over the parent's connections", func() {
+		fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+		fakeServer.RouteToHandler("POST", "/v1/tasks", ghttp.RespondWith(http.StatusAccepted, "{}"))
+
+		taskClient, err := NewClientSharingTransport(parent)
+		Expect(err).NotTo(HaveOccurred())
+
+		var parentConn string
+		ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) {
+				parentConn = info.Conn.LocalAddr().String()
+			},
+		})
+		_, err = parent.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+		Expect(err).NotTo(HaveOccurred())
+
+		// the parent's connection returns to the pool asynchronously
+		Eventually(func() string {
+			var conn string
+			ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+				GotConn: func(info httptrace.GotConnInfo) {
+					conn = info.Conn.LocalAddr().String()
+				},
+			})
+			_, err := taskClient.RequestTaskAuctionsWithResult(logger, ctx, []*TaskStartRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			return conn
+		}).Should(Equal(parentConn))
+	})
+
+	It("applies its own options", func() {
+		fakeServer.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyContentType("application/vnd.cf.auction+json"),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyContentType("application/json"),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			),
+		)
+
+		taskClient, err := NewClientSharingTransport(parent, WithContentType("application/vnd.cf.auction+json"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(taskClient.RequestTaskAuctions(logger, []*TaskStartRequest{})).To(Succeed())
+		Expect(parent.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(taskClient.Stats().Requests).To(BeEquivalentTo(1))
+		Expect(parent.Stats().Requests).To(BeEquivalentTo(1))
+	})
+
+	It("reloads TLS through the parent", func() {
+		taskClient, err := NewClientSharingTransport(parent)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(taskClient.ReloadTLS()).To(Equal(ErrTLSNotConfigured))
+
+		parent, err = NewSecureClient(
+			fakeServer.URL(),
+			blueCerts.CAFile,
+			blueCerts.ClientCertFile,
+			blueCerts.ClientKeyFile,
+			false,
+		)
+		Expect(err).NotTo(HaveOccurred())
+		taskClient, err = NewClientSharingTransport(parent)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(taskClient.ReloadTLS()).To(Succeed())
+	})
+
+	Context("when the parent is not one of the package's clients", func() {
+		It("returns ErrTransportNotShareable", func() {
+			_, err := NewClientSharingTransport(&auctioneerfakes.FakeExtendedClient{})
+			Expect(err).To(Equal(ErrTransportNotShareable))
+		})
+	})
+})
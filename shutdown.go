@@ -0,0 +1,179 @@
+package auctioneer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ErrClientClosed is matched, with errors.Is, by the error of a request made
+// after Shutdown or Close was called, or canceled by them.
+var ErrClientClosed = errors.New("auctioneer client closed")
+
+// requestTracker counts a client's in-flight requests so that Shutdown can
+// wait for them, and cancels them for Close.
+type requestTracker struct {
+	lock     sync.Mutex
+	closed   bool
+	nextID   uint64
+	inFlight map[uint64]context.CancelFunc
+	// idle is closed, and replaced, whenever the last in-flight request
+	// finishes.
+	idle chan struct{}
+	// closing is closed by close.
+	closing chan struct{}
+}
+
+// begin registers req as in flight and returns it with a context that
+// cancelAll cancels, and the function that ends it, which may be called
+// more than once.
+func (t *requestTracker) begin(req *http.Request) (*http.Request, func(), error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.closed {
+		return nil, nil, ErrClientClosed
+	}
+	if t.inFlight == nil {
+		t.inFlight = map[uint64]context.CancelFunc{}
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	id := t.nextID
+	t.nextID++
+	t.inFlight[id] = cancel
+
+	var once sync.Once
+	return req.WithContext(ctx), func() {
+		once.Do(func() {
+			cancel()
+			t.end(id)
+		})
+	}, nil
+}
+
+func (t *requestTracker) end(id uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.inFlight, id)
+	if len(t.inFlight) == 0 && t.idle != nil {
+		close(t.idle)
+		t.idle = nil
+	}
+}
+
+// close stops new requests and returns a channel closed once none is in
+// flight.
+func (t *requestTracker) close() <-chan struct{} {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.closed && t.closing != nil {
+		close(t.closing)
+	}
+	t.closed = true
+	if len(t.inFlight) == 0 {
+		idle := make(chan struct{})
+		close(idle)
+		return idle
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	return t.idle
+}
+
+// done returns a channel closed once close is called.
+func (t *requestTracker) done() <-chan struct{} {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.closing == nil {
+		t.closing = make(chan struct{})
+		if t.closed {
+			close(t.closing)
+		}
+	}
+	return t.closing
+}
+
+func (t *requestTracker) isClosed() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.closed
+}
+
+func (t *requestTracker) cancelAll() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, cancel := range t.inFlight {
+		cancel()
+	}
+}
+
+// Shutdown stops the client gracefully, as http.Server.Shutdown stops a
+// server: requests made after it is called fail with ErrClientClosed, while
+// those in flight are given until ctx is done to finish, and health probes
+// and WithTLSFileWatch stop. A request is in flight from when it is sent until its response body
+// is closed, so a call such as WaitForBatch that makes several requests
+// fails at the next one it makes. Once none is in flight, or ctx is done and
+// the rest are canceled, the client's idle connections are closed. Shutdown
+// returns ctx's error if it had to cancel requests, and nil otherwise.
+//
+// A client created with NewClientSharingTransport leaves the shared
+// connections open; shut down the client it shares them with to close them.
+func (c *auctioneerClient) Shutdown(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	c.stopTLSWatch()
+
+	var err error
+	select {
+	case <-c.requests.close():
+	case <-ctx.Done():
+		c.requests.cancelAll()
+		err = ctx.Err()
+	}
+
+	c.closeIdleConnections()
+	return err
+}
+
+// Close stops the client at once: requests in flight are canceled, failing
+// with ErrClientClosed like any request made after it, and the client's
+// idle connections are closed. Use Shutdown to let requests in flight
+// finish first.
+func (c *auctioneerClient) Close() error {
+	c.requests.close()
+	c.requests.cancelAll()
+	c.stopTLSWatch()
+	c.closeIdleConnections()
+	return nil
+}
+
+func (c *auctioneerClient) closeIdleConnections() {
+	if c.transportOwner != nil {
+		return
+	}
+
+	httpClient, insecureHTTPClient := c.currentHTTPClients()
+	httpClient.CloseIdleConnections()
+	if insecureHTTPClient != nil {
+		insecureHTTPClient.CloseIdleConnections()
+	}
+}
+
+// closedError returns err as an error matching ErrClientClosed when the
+// request failed because the client was closed while it was in flight.
+func (c *auctioneerClient) closedError(ctx context.Context, err error) error {
+	if ctx.Err() != nil || !c.requests.isClosed() || !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrClientClosed, err)
+}
@@ -0,0 +1,131 @@
+package auctioneer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Shutdown and Close", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		client     ExtendedClient
+		unblock    chan struct{}
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		unblock = make(chan struct{})
+
+		fakeServer = ghttp.NewServer()
+		fakeServer.RouteToHandler("POST", "/v1/lrps", func(w http.ResponseWriter, r *http.Request) {
+			<-unblock
+			w.WriteHeader(http.StatusAccepted)
+		})
+		client = NewClient(fakeServer.URL())
+	})
+
+	AfterEach(func() {
+		select {
+		case <-unblock:
+		default:
+			close(unblock)
+		}
+		fakeServer.Close()
+	})
+
+	// submitInFlight starts a submission that the auctioneer holds until
+	// unblock is closed, and returns the channel its error is sent on.
+	submitInFlight := func() chan error {
+		errs := make(chan error, 1)
+		go func() {
+			errs <- client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+		}()
+		Eventually(fakeServer.ReceivedRequests).Should(HaveLen(1))
+		return errs
+	}
+
+	Describe("Shutdown", func() {
+		It("rejects requests made after it is called", func() {
+			Expect(client.Shutdown(context.Background())).To(Succeed())
+
+			err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+			Expect(errors.Is(err, ErrClientClosed)).To(BeTrue())
+			_, err = client.Ping(logger, context.Background())
+			Expect(errors.Is(err, ErrClientClosed)).To(BeTrue())
+			Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+		})
+
+		It("waits for requests in flight to finish", func() {
+			errs := submitInFlight()
+
+			shutdown := make(chan error, 1)
+			go func() {
+				shutdown <- client.Shutdown(context.Background())
+			}()
+			Consistently(shutdown, 100*time.Millisecond).ShouldNot(Receive())
+
+			err := client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+			Expect(errors.Is(err, ErrClientClosed)).To(BeTrue())
+
+			close(unblock)
+			Eventually(errs).Should(Receive(BeNil()))
+			Eventually(shutdown).Should(Receive(BeNil()))
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("cancels the requests still in flight when its context is done", func() {
+			errs := submitInFlight()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			Expect(client.Shutdown(ctx)).To(Equal(context.DeadlineExceeded))
+
+			var err error
+			Eventually(errs).Should(Receive(&err))
+			Expect(errors.Is(err, ErrClientClosed)).To(BeTrue())
+		})
+	})
+
+	Describe("Close", func() {
+		It("cancels requests in flight at once", func() {
+			errs := submitInFlight()
+
+			Expect(client.Close()).To(Succeed())
+
+			var err error
+			Eventually(errs).Should(Receive(&err))
+			Expect(errors.Is(err, ErrClientClosed)).To(BeTrue())
+
+			err = client.RequestLRPAuctions(logger, []*LRPStartRequest{})
+			Expect(errors.Is(err, ErrClientClosed)).To(BeTrue())
+		})
+	})
+
+	It("leaves the error of a request canceled by its caller alone", func() {
+		errs := make(chan error, 1)
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+			errs <- err
+		}()
+		Eventually(fakeServer.ReceivedRequests).Should(HaveLen(1))
+
+		cancel()
+		Expect(client.Close()).To(Succeed())
+
+		var err error
+		Eventually(errs).Should(Receive(&err))
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		Expect(errors.Is(err, ErrClientClosed)).To(BeFalse())
+	})
+})
@@ -0,0 +1,86 @@
+package auctioneer
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// ClientStats is a snapshot of a Client's cumulative request counters. A
+// request counts once however many attempts it took.
+type ClientStats struct {
+	Requests uint64
+
+	// Requests answered with a 2xx, 4xx, 5xx or any other status.
+	Successes     uint64
+	ClientErrors  uint64
+	ServerErrors  uint64
+	OtherStatuses uint64
+	// TransportErrors are requests that got no response.
+	TransportErrors uint64
+
+	Fallbacks uint64
+	Retries   uint64
+}
+
+// clientStats is allocated on its own so that its counters are 64-bit
+// aligned for atomic access on 32-bit platforms.
+type clientStats struct {
+	requests        uint64
+	successes       uint64
+	clientErrors    uint64
+	serverErrors    uint64
+	otherStatuses   uint64
+	transportErrors uint64
+	fallbacks       uint64
+	retries         uint64
+}
+
+func (s *clientStats) recordRequest(resp *http.Response, err error) {
+	atomic.AddUint64(&s.requests, 1)
+
+	switch {
+	case err != nil:
+		atomic.AddUint64(&s.transportErrors, 1)
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		atomic.AddUint64(&s.successes, 1)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		atomic.AddUint64(&s.clientErrors, 1)
+	case resp.StatusCode >= 500 && resp.StatusCode < 600:
+		atomic.AddUint64(&s.serverErrors, 1)
+	default:
+		atomic.AddUint64(&s.otherStatuses, 1)
+	}
+}
+
+// Stats returns a snapshot of the client's request counters.
+func (c *auctioneerClient) Stats() ClientStats {
+	return ClientStats{
+		Requests:        atomic.LoadUint64(&c.stats.requests),
+		Successes:       atomic.LoadUint64(&c.stats.successes),
+		ClientErrors:    atomic.LoadUint64(&c.stats.clientErrors),
+		ServerErrors:    atomic.LoadUint64(&c.stats.serverErrors),
+		OtherStatuses:   atomic.LoadUint64(&c.stats.otherStatuses),
+		TransportErrors: atomic.LoadUint64(&c.stats.transportErrors),
+		Fallbacks:       atomic.LoadUint64(&c.stats.fallbacks),
+		Retries:         atomic.LoadUint64(&c.stats.retries),
+	}
+}
+
+// StatsAndReset returns the client's request counters and zeroes them, for
+// reporting per interval rather than cumulatively. It may be called while
+// requests are in flight: each counter is swapped atomically, so every
+// request is counted in exactly one interval, though a request completing
+// during the call may appear in Requests for one interval and in its status
+// counter for the next.
+func (c *auctioneerClient) StatsAndReset() ClientStats {
+	return ClientStats{
+		Requests:        atomic.SwapUint64(&c.stats.requests, 0),
+		Successes:       atomic.SwapUint64(&c.stats.successes, 0),
+		ClientErrors:    atomic.SwapUint64(&c.stats.clientErrors, 0),
+		ServerErrors:    atomic.SwapUint64(&c.stats.serverErrors, 0),
+		OtherStatuses:   atomic.SwapUint64(&c.stats.otherStatuses, 0),
+		TransportErrors: atomic.SwapUint64(&c.stats.transportErrors, 0),
+		Fallbacks:       atomic.SwapUint64(&c.stats.fallbacks, 0),
+		Retries:         atomic.SwapUint64(&c.stats.retries, 0),
+	}
+}
@@ -0,0 +1,148 @@
+package auctioneer_test
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Stats", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		client     ExtendedClient
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		client = NewClient(fakeServer.URL())
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("starts at zero", func() {
+		Expect(client.Stats()).To(Equal(ClientStats{}))
+	})
+
+	It("counts requests by outcome", func() {
+		fakeServer.AppendHandlers(
+			ghttp.RespondWith(http.StatusAccepted, "{}"),
+			ghttp.RespondWith(http.StatusBadRequest, nil),
+			ghttp.RespondWith(http.StatusServiceUnavailable, nil),
+			ghttp.RespondWith(http.StatusNotModified, nil),
+		)
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).NotTo(Succeed())
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+
+		fakeServer.Close()
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+
+		Expect(client.Stats()).To(Equal(ClientStats{
+			Requests:        5,
+			Successes:       1,
+			ClientErrors:    1,
+			ServerErrors:    1,
+			OtherStatuses:   1,
+			TransportErrors: 1,
+		}))
+	})
+
+	It("counts fallbacks", func() {
+		fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+
+		client, err := NewSecureClient(
+			strings.Replace(fakeServer.URL(), "http:", "https:", 1),
+			blueCerts.CAFile,
+			blueCerts.ClientCertFile,
+			blueCerts.ClientKeyFile,
+			false,
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(client.Stats().Fallbacks).To(BeEquivalentTo(1))
+		Expect(client.Stats().Successes).To(BeEquivalentTo(1))
+	})
+
+	It("counts retries", func() {
+		fakeServer.AppendHandlers(
+			closeConnection,
+			ghttp.RespondWith(http.StatusAccepted, "{}"),
+		)
+		client = NewClient(fakeServer.URL(), WithRetries(1), WithRetryAfterSend())
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(client.Stats().Retries).To(BeEquivalentTo(1))
+		Expect(client.Stats().Requests).To(BeEquivalentTo(1))
+	})
+
+	Describe("StatsAndReset", func() {
+		It("returns the counters and zeroes them", func() {
+			fakeServer.AppendHandlers(
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			)
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			Expect(client.StatsAndReset()).To(Equal(ClientStats{Requests: 1, Successes: 1}))
+			Expect(client.Stats()).To(Equal(ClientStats{}))
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			Expect(client.StatsAndReset()).To(Equal(ClientStats{Requests: 1, Successes: 1}))
+		})
+
+		It("counts every request in exactly one interval while requests are in flight", func() {
+			fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+
+			const workers, requestsPerWorker = 4, 25
+			var wg sync.WaitGroup
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					for j := 0; j < requestsPerWorker; j++ {
+						Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+					}
+				}()
+			}
+
+			done := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+
+			var total ClientStats
+			add := func(stats ClientStats) {
+				total.Requests += stats.Requests
+				total.Successes += stats.Successes
+			}
+		poll:
+			for {
+				select {
+				case <-done:
+					break poll
+				default:
+					add(client.StatsAndReset())
+				}
+			}
+			add(client.StatsAndReset())
+
+			Expect(total).To(Equal(ClientStats{Requests: workers * requestsPerWorker, Successes: workers * requestsPerWorker}))
+		})
+	})
+})
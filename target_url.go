@@ -0,0 +1,72 @@
+package auctioneer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/tedsuo/rata"
+)
+
+// ErrInvalidTargetURL is matched, with errors.Is, by the error a request
+// fails with when its context carries a target URL that is not an absolute
+// http or https URL.
+var ErrInvalidTargetURL = errors.New("invalid target URL")
+
+type targetURLKey struct{}
+
+// ContextWithTargetURL returns a context that sends requests made with it to
+// auctioneerURL instead of the client's URL, sharing the client's transport
+// and TLS configuration. It lets one client route each batch to a different
+// auctioneer, such as the shard that owns it. auctioneerURL is validated by
+// each request.
+func ContextWithTargetURL(ctx context.Context, auctioneerURL string) context.Context {
+	return context.WithValue(ctx, targetURLKey{}, auctioneerURL)
+}
+
+func hasTargetURL(ctx context.Context) bool {
+	_, ok := ctx.Value(targetURLKey{}).(string)
+	return ok
+}
+
+// targetURL returns the URL a request made with ctx goes to.
+func (c *auctioneerClient) targetURL(ctx context.Context) (string, error) {
+	target, ok := ctx.Value(targetURLKey{}).(string)
+	if !ok {
+		return c.currentURL(), nil
+	}
+	target = normalizeURL(target)
+
+	err := validateTargetURL(target)
+	if err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// requestGenerator returns the generator for requests made with ctx.
+func (c *auctioneerClient) requestGenerator(ctx context.Context) (*rata.RequestGenerator, error) {
+	target, ok := ctx.Value(targetURLKey{}).(string)
+	if !ok {
+		return c.currentRequestGenerator(), nil
+	}
+	target = normalizeURL(target)
+
+	err := validateTargetURL(target)
+	if err != nil {
+		return nil, err
+	}
+	return rata.NewRequestGenerator(target, c.routes), nil
+}
+
+func validateTargetURL(target string) error {
+	u, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidTargetURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %q is not an absolute http or https URL", ErrInvalidTargetURL, target)
+	}
+	return nil
+}
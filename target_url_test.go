@@ -0,0 +1,75 @@
+package auctioneer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("ContextWithTargetURL", func() {
+	var (
+		logger      *lagertest.TestLogger
+		fakeServer  *ghttp.Server
+		shardServer *ghttp.Server
+		client      ExtendedClient
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		shardServer = ghttp.NewServer()
+		client = NewClient(fakeServer.URL())
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+		shardServer.Close()
+	})
+
+	It("sends the request to the target URL", func() {
+		shardServer.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("POST", "/v1/tasks"),
+			ghttp.RespondWith(http.StatusAccepted, "{}"),
+		))
+
+		ctx := ContextWithTargetURL(context.Background(), shardServer.URL())
+		_, err := client.RequestTaskAuctionsWithResult(logger, ctx, []*TaskStartRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(shardServer.ReceivedRequests()).To(HaveLen(1))
+		Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+	})
+
+	It("leaves other requests on the client's URL", func() {
+		fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("pings the target URL", func() {
+		shardServer.AppendHandlers(ghttp.RespondWith(http.StatusOK, nil))
+
+		result, err := client.Ping(logger, ContextWithTargetURL(context.Background(), shardServer.URL()))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.OK()).To(BeTrue())
+		Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+	})
+
+	for _, target := range []string{"", "auctioneer.service.cf.internal:9016", "ftp://auctioneer", "http://[::1"} {
+		target := target
+
+		It("rejects the invalid target URL "+target, func() {
+			ctx := ContextWithTargetURL(context.Background(), target)
+			_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+			Expect(errors.Is(err, ErrInvalidTargetURL)).To(BeTrue())
+			Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+		})
+	}
+})
@@ -0,0 +1,100 @@
+package auctioneer
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// WithServerNames accepts the auctioneer's certificate when it is valid for
+// any of names, rather than only for the host in the auctioneer URL. The
+// certificate chain is still verified against the client's CA.
+func WithServerNames(names ...string) ClientOption {
+	return func(c *auctioneerClient) {
+		c.serverNames = names
+	}
+}
+
+// verifyPeerCertificateForNames verifies the peer's chain against rootCAs
+// and accepts it when the leaf is valid for any of names. It stands in for
+// the standard verification, which must be disabled with
+// InsecureSkipVerify because it only checks a single name.
+func verifyPeerCertificateForNames(rootCAs *x509.CertPool, names []string) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("auctioneer presented no certificate")
+		}
+
+		certs := make([]*x509.Certificate, 0, len(rawCerts))
+		for _, rawCert := range rawCerts {
+			cert, err := x509.ParseCertificate(rawCert)
+			if err != nil {
+				return err
+			}
+			certs = append(certs, cert)
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		leaf := certs[0]
+		_, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         rootCAs,
+			Intermediates: intermediates,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			if leaf.VerifyHostname(name) == nil {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("auctioneer certificate is not valid for any of %v", names)
+	}
+}
+
+// ErrInsecureFallbackEnabled is logged by clients configured with
+// WithInsecureFallbackWarning.
+var ErrInsecureFallbackEnabled = errors.New("insecure HTTP fallback is enabled for a non-loopback auctioneer")
+
+// WithInsecureFallbackWarning logs an error to logger when a client built by
+// NewSecureClient without requireTLS, and so able to silently downgrade to
+// plain HTTP, targets an auctioneer that is not on a loopback address. The
+// check runs at construction and on SetURL.
+func WithInsecureFallbackWarning(logger lager.Logger) ClientOption {
+	return func(c *auctioneerClient) {
+		c.insecureFallbackLogger = logger
+	}
+}
+
+func (c *auctioneerClient) warnOnInsecureFallback(auctioneerURL string) {
+	_, insecureHTTPClient := c.currentHTTPClients()
+	if c.insecureFallbackLogger == nil || c.requireTLS || insecureHTTPClient == nil {
+		return
+	}
+
+	u, err := url.Parse(auctioneerURL)
+	if err != nil || isLoopbackHost(u.Hostname()) {
+		return
+	}
+
+	c.insecureFallbackLogger.Error("insecure-fallback-enabled", ErrInsecureFallbackEnabled, lager.Data{"url": auctioneerURL})
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,212 @@
+package auctioneer_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("TLS options", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		fixtures   tlsFixtures
+		certDir    string
+	)
+
+	BeforeEach(func() {
+		var err error
+		certDir, err = ioutil.TempDir("", "auctioneer-certs")
+		Expect(err).NotTo(HaveOccurred())
+
+		logger = lagertest.NewTestLogger("test")
+		fixtures = newTLSFixtures(certDir, []string{"auctioneer.service.cf.internal"})
+
+		fakeServer = ghttp.NewUnstartedServer()
+		fakeServer.HTTPTestServer.TLS = fixtures.ServerTLSConfig()
+		fakeServer.HTTPTestServer.StartTLS()
+		fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+		os.RemoveAll(certDir)
+	})
+
+	newClient := func(opts ...ClientOption) ExtendedClient {
+		client, err := NewSecureClient(fakeServer.URL(), fixtures.CAFile, fixtures.ClientCertFile, fixtures.ClientKeyFile, true, opts...)
+		Expect(err).NotTo(HaveOccurred())
+		return client
+	}
+
+	Describe("WithServerNames", func() {
+		It("rejects the certificate by default because it does not cover the URL host", func() {
+			client := newClient()
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(MatchError(ContainSubstring("certificate")))
+		})
+
+		It("accepts the certificate when it matches one of the names", func() {
+			client := newClient(WithServerNames("auctioneer.example.com", "auctioneer.service.cf.internal"))
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		})
+
+		It("rejects the certificate when it matches none of the names", func() {
+			client := newClient(WithServerNames("auctioneer.example.com"))
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(MatchError(ContainSubstring("not valid for any of")))
+		})
+
+		Context("when the certificate is signed by another CA", func() {
+			BeforeEach(func() {
+				otherDir, err := ioutil.TempDir(certDir, "other")
+				Expect(err).NotTo(HaveOccurred())
+				fixtures.CAFile = newTLSFixtures(otherDir, nil).CAFile
+			})
+
+			It("rejects the certificate", func() {
+				client := newClient(WithServerNames("auctioneer.service.cf.internal"))
+				Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(MatchError(ContainSubstring("unknown authority")))
+			})
+		})
+
+		It("keeps applying after the TLS configuration is reloaded", func() {
+			client := newClient(WithServerNames("auctioneer.service.cf.internal"))
+			Expect(client.ReloadTLS()).To(Succeed())
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		})
+	})
+})
+
+var _ = Describe("WithInsecureFallbackWarning", func() {
+	var (
+		logger   *lagertest.TestLogger
+		caFile   string
+		certFile string
+		keyFile  string
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		caFile = blueCerts.CAFile
+		certFile = blueCerts.ClientCertFile
+		keyFile = blueCerts.ClientKeyFile
+	})
+
+	It("warns when the fallback is enabled for a remote auctioneer", func() {
+		_, err := NewSecureClient("https://auctioneer.service.cf.internal:9016", caFile, certFile, keyFile, false, WithInsecureFallbackWarning(logger))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(logger).To(gbytes.Say("insecure-fallback-enabled"))
+	})
+
+	It("warns when SetURL points the client at a remote auctioneer", func() {
+		client, err := NewSecureClient("https://127.0.0.1:9016", caFile, certFile, keyFile, false, WithInsecureFallbackWarning(logger))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(logger.LogMessages()).To(BeEmpty())
+
+		client.SetURL("https://10.0.0.1:9016")
+		Expect(logger).To(gbytes.Say("insecure-fallback-enabled"))
+	})
+
+	It("does not warn for a loopback auctioneer", func() {
+		_, err := NewSecureClient("https://localhost:9016", caFile, certFile, keyFile, false, WithInsecureFallbackWarning(logger))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(logger.LogMessages()).To(BeEmpty())
+	})
+
+	It("does not warn when TLS is required", func() {
+		_, err := NewSecureClient("https://auctioneer.service.cf.internal:9016", caFile, certFile, keyFile, true, WithInsecureFallbackWarning(logger))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(logger.LogMessages()).To(BeEmpty())
+	})
+
+	It("does not warn for a plain HTTP client, which has no fallback", func() {
+		NewClient("http://auctioneer.service.cf.internal:9016", WithInsecureFallbackWarning(logger))
+		Expect(logger.LogMessages()).To(BeEmpty())
+	})
+})
+
+var _ = Describe("NewSecureClientWithTLSConfig", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		fixtures   tlsFixtures
+		certDir    string
+		tlsConfig  *tls.Config
+	)
+
+	BeforeEach(func() {
+		var err error
+		certDir, err = ioutil.TempDir("", "auctioneer-certs")
+		Expect(err).NotTo(HaveOccurred())
+
+		logger = lagertest.NewTestLogger("test")
+		fixtures = newTLSFixtures(certDir, nil, net.ParseIP("127.0.0.1"))
+
+		fakeServer = ghttp.NewUnstartedServer()
+		fakeServer.HTTPTestServer.TLS = fixtures.ServerTLSConfig()
+		fakeServer.HTTPTestServer.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+		fakeServer.HTTPTestServer.TLS.ClientCAs = x509.NewCertPool()
+		fakeServer.HTTPTestServer.TLS.ClientCAs.AddCert(fixtures.CACert)
+		fakeServer.HTTPTestServer.StartTLS()
+		fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.RespondWith(http.StatusAccepted, "{}"))
+
+		cert, err := tls.LoadX509KeyPair(fixtures.ClientCertFile, fixtures.ClientKeyFile)
+		Expect(err).NotTo(HaveOccurred())
+		rootCAs := x509.NewCertPool()
+		rootCAs.AddCert(fixtures.CACert)
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}, RootCAs: rootCAs}
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+		os.RemoveAll(certDir)
+	})
+
+	It("uses the given TLS config", func() {
+		client, err := NewSecureClientWithTLSConfig(fakeServer.URL(), tlsConfig, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+	})
+
+	It("does not modify the given TLS config", func() {
+		_, err := NewSecureClientWithTLSConfig(fakeServer.URL(), tlsConfig, true, WithServerNames("127.0.0.1"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tlsConfig.InsecureSkipVerify).To(BeFalse())
+		Expect(tlsConfig.ClientSessionCache).To(BeNil())
+	})
+
+	It("rejects a config without a client certificate when TLS is required", func() {
+		tlsConfig.Certificates = nil
+
+		_, err := NewSecureClientWithTLSConfig(fakeServer.URL(), tlsConfig, true)
+		Expect(err).To(Equal(ErrTLSConfigMissingCertificate))
+	})
+
+	It("accepts a config that provides the certificate on demand", func() {
+		cert := tlsConfig.Certificates[0]
+		tlsConfig.Certificates = nil
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return &cert, nil
+		}
+
+		client, err := NewSecureClientWithTLSConfig(fakeServer.URL(), tlsConfig, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+	})
+
+	It("cannot reload TLS files", func() {
+		client, err := NewSecureClientWithTLSConfig(fakeServer.URL(), tlsConfig, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.ReloadTLS()).To(Equal(ErrTLSNotConfigured))
+	})
+})
@@ -0,0 +1,42 @@
+package auctioneer
+
+import "crypto/tls"
+
+// DefaultTLSSessionCacheSize is the number of TLS sessions a client built by
+// NewSecureClient keeps for resumption unless WithTLSSessionCacheSize or
+// WithTLSSessionCache says otherwise.
+const DefaultTLSSessionCacheSize = 64
+
+// WithTLSSessionCacheSize keeps up to size TLS sessions for resumption, so
+// reconnecting to the auctioneer skips the full handshake. A size of zero or
+// less disables resumption.
+func WithTLSSessionCacheSize(size int) ClientOption {
+	return func(c *auctioneerClient) {
+		c.tlsSessionCacheSize = size
+	}
+}
+
+// WithTLSSessionCache resumes TLS sessions from cache, which may be shared
+// with other clients. A nil cache disables resumption. The client's own
+// cache is discarded by ReloadTLS so that sessions established with the
+// previous certificate are not resumed; a cache given here is kept.
+func WithTLSSessionCache(cache tls.ClientSessionCache) ClientOption {
+	return func(c *auctioneerClient) {
+		c.tlsSessionCache = cache
+		c.tlsSessionCacheSet = true
+	}
+}
+
+// newTLSSessionCache returns the session cache for a newly built TLS
+// transport.
+func (c *auctioneerClient) newTLSSessionCache() tls.ClientSessionCache {
+	if c.tlsSessionCacheSet {
+		return c.tlsSessionCache
+	}
+
+	if c.tlsSessionCacheSize <= 0 {
+		return nil
+	}
+
+	return tls.NewLRUClientSessionCache(c.tlsSessionCacheSize)
+}
@@ -0,0 +1,100 @@
+package auctioneer_test
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("TLS session resumption", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		fixtures   tlsFixtures
+		certDir    string
+
+		lock    sync.Mutex
+		resumed []bool
+	)
+
+	BeforeEach(func() {
+		var err error
+		certDir, err = ioutil.TempDir("", "auctioneer-certs")
+		Expect(err).NotTo(HaveOccurred())
+
+		logger = lagertest.NewTestLogger("test")
+		fixtures = newTLSFixtures(certDir, []string{"auctioneer.service.cf.internal"})
+
+		resumed = nil
+		fakeServer = ghttp.NewUnstartedServer()
+		fakeServer.HTTPTestServer.TLS = fixtures.ServerTLSConfig()
+		fakeServer.HTTPTestServer.StartTLS()
+		fakeServer.RouteToHandler("POST", "/v1/lrps", func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			resumed = append(resumed, r.TLS.DidResume)
+			lock.Unlock()
+			w.WriteHeader(http.StatusAccepted)
+		})
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+		os.RemoveAll(certDir)
+	})
+
+	newClient := func(opts ...ClientOption) ExtendedClient {
+		opts = append(opts,
+			WithServerNames("auctioneer.service.cf.internal"),
+			WithTransportConfig(func(tr *http.Transport) {
+				tr.DisableKeepAlives = true
+			}),
+		)
+		client, err := NewSecureClient(fakeServer.URL(), fixtures.CAFile, fixtures.ClientCertFile, fixtures.ClientKeyFile, true, opts...)
+		Expect(err).NotTo(HaveOccurred())
+		return client
+	}
+
+	sessionsResumed := func() []bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]bool(nil), resumed...)
+	}
+
+	It("resumes the session on reconnect by default", func() {
+		client := newClient()
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(sessionsResumed()).To(Equal([]bool{false, true}))
+	})
+
+	It("performs full handshakes when resumption is disabled", func() {
+		client := newClient(WithTLSSessionCacheSize(0))
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(sessionsResumed()).To(Equal([]bool{false, false}))
+	})
+
+	It("resumes sessions from a cache shared between clients", func() {
+		cache := tls.NewLRUClientSessionCache(4)
+		Expect(newClient(WithTLSSessionCache(cache)).RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(newClient(WithTLSSessionCache(cache)).RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(sessionsResumed()).To(Equal([]bool{false, true}))
+	})
+
+	It("does not resume sessions from before ReloadTLS", func() {
+		client := newClient()
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(client.ReloadTLS()).To(Succeed())
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+		Expect(sessionsResumed()).To(Equal([]bool{false, false}))
+	})
+})
@@ -0,0 +1,107 @@
+package auctioneer
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// Errors matched, with errors.Is, by the errors NewSecureClient,
+// NewSecureClientWithTLSConfig and ReloadTLS return for unusable TLS
+// material.
+var (
+	ErrTLSCAFileUnreadable         = errors.New("CA file is unreadable")
+	ErrTLSCertKeyMismatch          = errors.New("certificate and key do not match")
+	ErrTLSCertExpired              = errors.New("certificate has expired")
+	ErrTLSConfigMissingCertificate = errors.New("TLS config has no client certificate")
+)
+
+// ErrTLSRequiredButNotConfigured is matched, with errors.Is, by the error
+// NewSecureClient returns when TLS is required but no TLS files are given,
+// and by the errors ClientConfig.Validate and NewClientFromConfig return
+// when RequireTLS is set or the URL is https but no TLS files are
+// configured. Such a client could only fail on its first request.
+var ErrTLSRequiredButNotConfigured = errors.New("TLS is required but not configured")
+
+// validateTLSMaterial checks the files for the common mistakes that
+// cfhttp.NewTLSConfig reports with a generic error, judging expiry as of
+// now. An empty caFile, which cfhttp skips, is not checked.
+func validateTLSMaterial(caFile, certFile, keyFile string, now time.Time) error {
+	if caFile != "" {
+		caPEM, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrTLSCAFileUnreadable, err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(caPEM) {
+			return fmt.Errorf("%w: %s contains no PEM certificates", ErrTLSCAFileUnreadable, caFile)
+		}
+	}
+
+	certPEM, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return fmt.Errorf("reading certificate file: %w", err)
+	}
+	keyPEM, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return fmt.Errorf("reading key file: %w", err)
+	}
+
+	leaf, err := parseLeafCertificate(certPEM)
+	if err != nil {
+		return fmt.Errorf("parsing certificate file %s: %w", certFile, err)
+	}
+
+	_, err = tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		if !containsPrivateKey(keyPEM) {
+			return fmt.Errorf("parsing key file %s: %w", keyFile, err)
+		}
+		return fmt.Errorf("%w: %s and %s", ErrTLSCertKeyMismatch, certFile, keyFile)
+	}
+
+	if now.After(leaf.NotAfter) {
+		return fmt.Errorf("%w: %s expired at %s", ErrTLSCertExpired, certFile, leaf.NotAfter.UTC().Format(time.RFC3339))
+	}
+	if now.Before(leaf.NotBefore) {
+		return fmt.Errorf("certificate %s is not valid until %s", certFile, leaf.NotBefore.UTC().Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func parseLeafCertificate(certPEM []byte) (*x509.Certificate, error) {
+	for {
+		var block *pem.Block
+		block, certPEM = pem.Decode(certPEM)
+		if block == nil {
+			return nil, errors.New("no PEM certificate found")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+func containsPrivateKey(keyPEM []byte) bool {
+	for {
+		var block *pem.Block
+		block, keyPEM = pem.Decode(keyPEM)
+		if block == nil {
+			return false
+		}
+
+		if _, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+			return true
+		}
+		if _, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+			return true
+		}
+		if _, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+			return true
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package auctioneer_test
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/clock/fakeclock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TLS material validation", func() {
+	var (
+		certDir  string
+		fixtures tlsFixtures
+	)
+
+	BeforeEach(func() {
+		var err error
+		certDir, err = ioutil.TempDir("", "auctioneer-certs")
+		Expect(err).NotTo(HaveOccurred())
+		fixtures = newTLSFixtures(certDir, nil)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(certDir)
+	})
+
+	newSecureClient := func() error {
+		_, err := NewSecureClient("https://127.0.0.1", fixtures.CAFile, fixtures.ClientCertFile, fixtures.ClientKeyFile, true)
+		return err
+	}
+
+	It("accepts valid material", func() {
+		Expect(newSecureClient()).To(Succeed())
+	})
+
+	It("accepts a certificate and key without a CA", func() {
+		fixtures.CAFile = ""
+		Expect(newSecureClient()).To(Succeed())
+	})
+
+	It("judges expiry by the client's clock", func() {
+		later := fakeclock.NewFakeClock(time.Now().Add(2 * time.Hour))
+		_, err := NewSecureClient("https://127.0.0.1", fixtures.CAFile, fixtures.ClientCertFile, fixtures.ClientKeyFile, true, WithClock(later))
+		Expect(errors.Is(err, ErrTLSCertExpired)).To(BeTrue())
+	})
+
+	Context("when the CA file does not exist", func() {
+		BeforeEach(func() {
+			fixtures.CAFile = filepath.Join(certDir, "missing.crt")
+		})
+
+		It("reports the CA file as unreadable", func() {
+			err := newSecureClient()
+			Expect(errors.Is(err, ErrTLSCAFileUnreadable)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("missing.crt")))
+		})
+	})
+
+	Context("when the CA file contains no certificates", func() {
+		BeforeEach(func() {
+			fixtures.CAFile = fixtures.ClientKeyFile
+		})
+
+		It("reports the CA file as unreadable", func() {
+			err := newSecureClient()
+			Expect(errors.Is(err, ErrTLSCAFileUnreadable)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("contains no PEM certificates")))
+		})
+	})
+
+	Context("when the certificate and key do not match", func() {
+		BeforeEach(func() {
+			fixtures.ClientKeyFile = fixtures.ServerKeyFile
+		})
+
+		It("reports the mismatch", func() {
+			err := newSecureClient()
+			Expect(errors.Is(err, ErrTLSCertKeyMismatch)).To(BeTrue())
+		})
+	})
+
+	Context("when the key file is not a key", func() {
+		BeforeEach(func() {
+			fixtures.ClientKeyFile = fixtures.CAFile
+		})
+
+		It("reports a parse error rather than a mismatch", func() {
+			err := newSecureClient()
+			Expect(err).To(MatchError(ContainSubstring("parsing key file")))
+			Expect(errors.Is(err, ErrTLSCertKeyMismatch)).To(BeFalse())
+		})
+	})
+
+	Context("when the certificate has expired", func() {
+		BeforeEach(func() {
+			key := newKey()
+			template := &x509.Certificate{
+				SerialNumber: big.NewInt(4),
+				Subject:      pkix.Name{CommonName: "auctioneer test"},
+				NotBefore:    time.Now().Add(-2 * time.Hour),
+				NotAfter:     time.Now().Add(-time.Hour),
+				ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			}
+			der, err := x509.CreateCertificate(rand.Reader, template, fixtures.CACert, &key.PublicKey, fixtures.CAKey)
+			Expect(err).NotTo(HaveOccurred())
+			writePEM(fixtures.ClientCertFile, "CERTIFICATE", der)
+
+			keyDER, err := x509.MarshalECPrivateKey(key)
+			Expect(err).NotTo(HaveOccurred())
+			writePEM(fixtures.ClientKeyFile, "EC PRIVATE KEY", keyDER)
+		})
+
+		It("reports the expiry", func() {
+			err := newSecureClient()
+			Expect(errors.Is(err, ErrTLSCertExpired)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("expired at")))
+		})
+	})
+
+	Context("when reloading", func() {
+		It("validates the material again", func() {
+			client, err := NewSecureClient("https://127.0.0.1", fixtures.CAFile, fixtures.ClientCertFile, fixtures.ClientKeyFile, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			keyDER, err := ioutil.ReadFile(fixtures.ServerKeyFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.WriteFile(fixtures.ClientKeyFile, keyDER, 0600)).To(Succeed())
+
+			Expect(errors.Is(client.ReloadTLS(), ErrTLSCertKeyMismatch)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,111 @@
+package auctioneer
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager"
+	"github.com/fsnotify/fsnotify"
+)
+
+// tlsWatchSettleDelay is how long a client configured with WithTLSFileWatch
+// waits after the last change to its TLS directories before reloading, so
+// that a rotation writing the certificate, key and CA one after another is
+// reloaded once, when complete.
+const tlsWatchSettleDelay = 100 * time.Millisecond
+
+// WithTLSFileWatch makes a client built by NewSecureClient, or by
+// NewClientFromConfig with TLS files, watch the directories holding its CA,
+// certificate and key files and call ReloadTLS whenever anything in them
+// changes, logging each reload, and each failure to reload, to logger. Whole
+// directories are watched, rather than the files, so that rotations made by
+// swapping a symlink, as Kubernetes does for mounted secrets, are noticed.
+// A reload that fails, for example on a key not yet written, leaves the
+// previous TLS material in use until the next change. Watching stops on
+// Shutdown or Close. A client without TLS files ignores this option.
+func WithTLSFileWatch(logger lager.Logger) ClientOption {
+	return func(c *auctioneerClient) {
+		c.tlsWatchLogger = logger
+	}
+}
+
+// watchTLSFiles starts watching the directories of the client's TLS files
+// when it is configured with WithTLSFileWatch.
+func (c *auctioneerClient) watchTLSFiles() error {
+	if c.tlsWatchLogger == nil || c.certFile == "" {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("watching TLS files: %w", err)
+	}
+
+	watched := map[string]bool{}
+	for _, file := range []string{c.caFile, c.certFile, c.keyFile} {
+		dir := filepath.Dir(file)
+		if file == "" || watched[dir] {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return fmt.Errorf("watching TLS directory %s: %w", dir, err)
+		}
+		watched[dir] = true
+	}
+
+	c.tlsWatcher = watcher
+	go c.reloadOnTLSChanges(watcher, c.tlsWatchLogger.Session("tls-watch"))
+	return nil
+}
+
+// reloadOnTLSChanges reloads the client's TLS material once its directories
+// have settled after each change, until watcher is closed.
+func (c *auctioneerClient) reloadOnTLSChanges(watcher *fsnotify.Watcher, logger lager.Logger) {
+	var settle clock.Timer
+	var settled <-chan time.Time
+	defer func() {
+		if settle != nil {
+			settle.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			if settle != nil {
+				settle.Stop()
+			}
+			settle = c.clock.NewTimer(tlsWatchSettleDelay)
+			settled = settle.C()
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Error("watch-failed", err)
+
+		case <-settled:
+			settled = nil
+			if err := c.ReloadTLS(); err != nil {
+				logger.Error("reload-failed", err)
+				continue
+			}
+			logger.Info("reloaded")
+		}
+	}
+}
+
+func (c *auctioneerClient) stopTLSWatch() {
+	if c.tlsWatcher != nil {
+		c.tlsWatcher.Close()
+	}
+}
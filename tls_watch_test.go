@@ -0,0 +1,68 @@
+package auctioneer_test
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("WithTLSFileWatch", func() {
+	var (
+		logger   *lagertest.TestLogger
+		certDir  string
+		fixtures tlsFixtures
+		client   ExtendedClient
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+
+		var err error
+		certDir, err = ioutil.TempDir("", "tls-watch")
+		Expect(err).NotTo(HaveOccurred())
+		fixtures = newTLSFixtures(certDir, nil)
+
+		client, err = NewSecureClient("https://auctioneer.example.com", fixtures.CAFile, fixtures.ClientCertFile, fixtures.ClientKeyFile, true, WithTLSFileWatch(logger))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(client.Close()).To(Succeed())
+		os.RemoveAll(certDir)
+	})
+
+	It("reloads the TLS material when its files change", func() {
+		writeSignedCert(fixtures, 4, fixtures.ClientCertFile, fixtures.ClientKeyFile, nil, nil)
+
+		Eventually(logger).Should(gbytes.Say("test.tls-watch.reloaded"))
+	})
+
+	It("reloads once for a rotation that writes several files", func() {
+		newTLSFixtures(certDir, nil)
+
+		Eventually(logger).Should(gbytes.Say("test.tls-watch.reloaded"))
+		Consistently(logger, 300*time.Millisecond).ShouldNot(gbytes.Say("reloaded"))
+	})
+
+	It("logs a reload that fails and keeps watching", func() {
+		Expect(ioutil.WriteFile(fixtures.ClientKeyFile, []byte("not a key"), 0600)).To(Succeed())
+		Eventually(logger).Should(gbytes.Say("test.tls-watch.reload-failed"))
+
+		writeSignedCert(fixtures, 4, fixtures.ClientCertFile, fixtures.ClientKeyFile, nil, nil)
+		Eventually(logger).Should(gbytes.Say("test.tls-watch.reloaded"))
+	})
+
+	It("stops watching once the client is closed", func() {
+		Expect(client.Close()).To(Succeed())
+
+		writeSignedCert(fixtures, 4, fixtures.ClientCertFile, fixtures.ClientKeyFile, nil, nil)
+		Consistently(logger, 300*time.Millisecond).ShouldNot(gbytes.Say("tls-watch"))
+	})
+})
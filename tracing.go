@@ -0,0 +1,152 @@
+package auctioneer
+
+import (
+	"context"
+	"net/http"
+
+	"code.cloudfoundry.org/lager"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+)
+
+type forceTraceSamplingKey struct{}
+
+// WithTracer traces each request to the auctioneer as a client span of
+// tracer, a child of any span in the request context, and propagates the
+// span context to the auctioneer in the request headers. A nil tracer
+// disables tracing, leaving requests unchanged.
+func WithTracer(tracer opentracing.Tracer) ClientOption {
+	return func(c *auctioneerClient) {
+		c.tracer = tracer
+	}
+}
+
+// WithTracerFunc resolves the tracer for each request from its context, for
+// example to trace each tenant with its own tracer or sampling. Requests for
+// which tracerFunc returns nil use the tracer set with WithTracer, if any.
+func WithTracerFunc(tracerFunc func(ctx context.Context) opentracing.Tracer) ClientOption {
+	return func(c *auctioneerClient) {
+		c.tracerFunc = tracerFunc
+	}
+}
+
+// WithSpanNamer names the span of each request namer(op) instead of after
+// its HTTP method, such as "HTTP POST", so that traces can follow a naming
+// convention shared across services. op is the ExtendedClient method the
+// request is made for: "RequestLRPAuctions" and "RequestTaskAuctions" for
+// every way of submitting a batch, "DryRunLRPAuctions",
+// "DryRunTaskAuctions", "WaitForBatch", "Capabilities", or "Ping", which
+// also covers Warmup and the probes of WithHealthProbe. The spans of
+// individual attempts of a retried request keep their names.
+func WithSpanNamer(namer func(op string) string) ClientOption {
+	return func(c *auctioneerClient) {
+		c.spanNamer = namer
+	}
+}
+
+// ForceTraceSampling returns a context that forces the trace sampling
+// decision for requests made with it, so that high-value batches are traced
+// regardless of the tracer's sampling rate. It has no effect on a client
+// without a tracer.
+func ForceTraceSampling(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceTraceSamplingKey{}, true)
+}
+
+func (c *auctioneerClient) tracerFor(ctx context.Context) opentracing.Tracer {
+	if c.tracerFunc != nil {
+		if tracer := c.tracerFunc(ctx); tracer != nil {
+			return tracer
+		}
+	}
+	return c.tracer
+}
+
+// startSpan starts a client span for an HTTP request with the given method,
+// made for op (see WithSpanNamer), before the request itself exists, so
+// that failures preparing the request are traced too. It returns nil when
+// there is no tracer for ctx.
+func (c *auctioneerClient) startSpan(ctx context.Context, method, op string) opentracing.Span {
+	tracer := c.tracerFor(ctx)
+	if tracer == nil {
+		return nil
+	}
+
+	opts := []opentracing.StartSpanOption{ext.SpanKindRPCClient}
+	if parent := opentracing.SpanFromContext(ctx); parent != nil {
+		opts = append(opts, opentracing.ChildOf(parent.Context()))
+	}
+
+	name := "HTTP " + method
+	if c.spanNamer != nil {
+		name = c.spanNamer(op)
+	}
+
+	span := tracer.StartSpan(name, opts...)
+	ext.HTTPMethod.Set(span, method)
+
+	// The sampling priority must be set before injection so the decision
+	// propagates to the auctioneer.
+	if forced, _ := ctx.Value(forceTraceSamplingKey{}).(bool); forced {
+		ext.SamplingPriority.Set(span, 1)
+	}
+
+	return span
+}
+
+// injectSpan tags span with the request URL and propagates its context in
+// the request headers.
+func (c *auctioneerClient) injectSpan(logger lager.Logger, span opentracing.Span, req *http.Request) {
+	if span == nil {
+		return
+	}
+
+	ext.HTTPUrl.Set(span, req.URL.String())
+	c.injectSpanContext(logger, span, HTTPHeaderCarrier(req.Header))
+}
+
+func (c *auctioneerClient) injectSpanContext(logger lager.Logger, span opentracing.Span, carrier MetadataCarrier) {
+	err := span.Tracer().Inject(span.Context(), opentracing.HTTPHeaders, carrier)
+	if err != nil {
+		c.logError(logger, "failed-to-inject-span-context", err)
+	}
+}
+
+func finishRequestSpan(span opentracing.Span, resp *http.Response, err error) {
+	if span == nil {
+		return
+	}
+
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(otlog.Error(err))
+	} else {
+		ext.HTTPStatusCode.Set(span, uint16(resp.StatusCode))
+	}
+
+	span.Finish()
+}
+
+// startAttemptSpan starts a child of span for one attempt, counted from 0, of
+// a request that may be retried. It returns nil when span is nil.
+func startAttemptSpan(span opentracing.Span, method string, attempt int) opentracing.Span {
+	if span == nil {
+		return nil
+	}
+
+	attemptSpan := span.Tracer().StartSpan("HTTP "+method+" attempt", ext.SpanKindRPCClient, opentracing.ChildOf(span.Context()))
+	ext.HTTPMethod.Set(attemptSpan, method)
+	attemptSpan.SetTag("attempt", attempt+1)
+	return attemptSpan
+}
+
+// finishAttemptSpan finishes span with the outcome of the attempt and
+// whether it is being retried.
+func finishAttemptSpan(span opentracing.Span, resp *http.Response, err error, retried bool) {
+	if span == nil {
+		return
+	}
+
+	span.SetTag("retried", retried)
+	finishRequestSpan(span, resp, err)
+}
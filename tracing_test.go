@@ -0,0 +1,310 @@
+package auctioneer_test
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/mocktracer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Tracing", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		tracer     *mocktracer.MockTracer
+		client     ExtendedClient
+		sampled    string
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		tracer = mocktracer.New()
+		client = NewClient(fakeServer.URL(), WithTracer(tracer))
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	Context("when a request succeeds", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				func(w http.ResponseWriter, r *http.Request) {
+					Expect(r.Header.Get("Mockpfx-Ids-Traceid")).NotTo(BeEmpty())
+				},
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+		})
+
+		It("propagates and finishes a client span", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+
+			spans := tracer.FinishedSpans()
+			Expect(spans).To(HaveLen(1))
+			Expect(spans[0].OperationName).To(Equal("HTTP POST"))
+			Expect(spans[0].Tag(string(ext.SpanKind))).To(Equal(ext.SpanKindRPCClientEnum))
+			Expect(spans[0].Tag(string(ext.HTTPStatusCode))).To(BeEquivalentTo(http.StatusAccepted))
+		})
+
+		It("creates the span as a child of the span in the context", func() {
+			parent := tracer.StartSpan("parent")
+			ctx := opentracing.ContextWithSpan(context.Background(), parent)
+
+			_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+			Expect(err).NotTo(HaveOccurred())
+
+			spans := tracer.FinishedSpans()
+			Expect(spans).To(HaveLen(1))
+			Expect(spans[0].ParentID).To(Equal(parent.Context().(mocktracer.MockSpanContext).SpanID))
+		})
+
+		Context("when the parent span is not sampled", func() {
+			var ctx context.Context
+
+			BeforeEach(func() {
+				parent := tracer.StartSpan("parent")
+				ext.SamplingPriority.Set(parent, 0)
+				ctx = opentracing.ContextWithSpan(context.Background(), parent)
+
+				fakeServer.SetHandler(0, ghttp.CombineHandlers(
+					func(w http.ResponseWriter, r *http.Request) {
+						sampled = r.Header.Get("Mockpfx-Ids-Sampled")
+					},
+					ghttp.RespondWith(http.StatusAccepted, "{}"),
+				))
+			})
+
+			It("does not sample the request", func() {
+				_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sampled).To(Equal("false"))
+			})
+
+			It("forces the sampling decision when requested", func() {
+				_, err := client.RequestLRPAuctionsWithResult(logger, ForceTraceSampling(ctx), []*LRPStartRequest{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sampled).To(Equal("true"))
+			})
+		})
+	})
+
+	Context("when a request fails", func() {
+		BeforeEach(func() {
+			fakeServer.Close()
+		})
+
+		It("tags the span with the error", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).NotTo(Succeed())
+
+			spans := tracer.FinishedSpans()
+			Expect(spans).To(HaveLen(1))
+			Expect(spans[0].Tag(string(ext.Error))).To(Equal(true))
+		})
+	})
+
+	Context("when the request cannot be created", func() {
+		BeforeEach(func() {
+			client.SetURL("http://[::1")
+		})
+
+		It("finishes the span with the error", func() {
+			_, err := client.RequestTaskAuctionsWithResult(logger, context.Background(), []*TaskStartRequest{})
+			Expect(err).To(HaveOccurred())
+
+			spans := tracer.FinishedSpans()
+			Expect(spans).To(HaveLen(1))
+			Expect(spans[0].OperationName).To(Equal("HTTP POST"))
+			Expect(spans[0].Tag(string(ext.Error))).To(Equal(true))
+		})
+	})
+
+	Context("when forcing sampling without a tracer", func() {
+		BeforeEach(func() {
+			client = NewClient(fakeServer.URL())
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+		})
+
+		It("is a no-op", func() {
+			_, err := client.RequestLRPAuctionsWithResult(logger, ForceTraceSampling(context.Background()), []*LRPStartRequest{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when the tracer is nil", func() {
+		BeforeEach(func() {
+			client = NewClient(fakeServer.URL(), WithTracer(nil))
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				func(w http.ResponseWriter, r *http.Request) {
+					Expect(r.Header.Get("Mockpfx-Ids-Traceid")).To(BeEmpty())
+				},
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+		})
+
+		It("sends the request untraced", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+
+	Context("WithTracerFunc", func() {
+		var tenantTracer *mocktracer.MockTracer
+
+		BeforeEach(func() {
+			tenantTracer = mocktracer.New()
+			client = NewClient(fakeServer.URL(), WithTracer(tracer), WithTracerFunc(func(ctx context.Context) opentracing.Tracer {
+				if ctx.Value(tenantKey{}) == "traced-tenant" {
+					return tenantTracer
+				}
+				return nil
+			}))
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				func(w http.ResponseWriter, r *http.Request) {
+					Expect(r.Header.Get("Mockpfx-Ids-Traceid")).NotTo(BeEmpty())
+				},
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+			))
+		})
+
+		It("traces the request with the tracer resolved from the context", func() {
+			ctx := context.WithValue(context.Background(), tenantKey{}, "traced-tenant")
+
+			_, err := client.RequestLRPAuctionsWithResult(logger, ctx, []*LRPStartRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tenantTracer.FinishedSpans()).To(HaveLen(1))
+			Expect(tracer.FinishedSpans()).To(BeEmpty())
+		})
+
+		It("falls back to the construction-time tracer", func() {
+			_, err := client.RequestLRPAuctionsWithResult(logger, context.Background(), []*LRPStartRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tenantTracer.FinishedSpans()).To(BeEmpty())
+			Expect(tracer.FinishedSpans()).To(HaveLen(1))
+		})
+	})
+
+	Context("when a request is retried", func() {
+		var spanIDs []string
+
+		BeforeEach(func() {
+			spanIDs = nil
+			client = NewClient(fakeServer.URL(), WithTracer(tracer), WithRetries(2), WithRetryAfterSend())
+			recordSpan := func(w http.ResponseWriter, r *http.Request) {
+				spanIDs = append(spanIDs, r.Header.Get("Mockpfx-Ids-Spanid"))
+			}
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(recordSpan, closeConnection),
+				ghttp.CombineHandlers(recordSpan, ghttp.RespondWith(http.StatusAccepted, "{}")),
+			)
+		})
+
+		It("traces each attempt as a child span", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+
+			spans := tracer.FinishedSpans()
+			Expect(spans).To(HaveLen(3))
+			first, second, request := spans[0], spans[1], spans[2]
+			Expect(request.OperationName).To(Equal("HTTP POST"))
+
+			Expect(first.OperationName).To(Equal("HTTP POST attempt"))
+			Expect(first.ParentID).To(Equal(request.SpanContext.SpanID))
+			Expect(first.Tag("attempt")).To(Equal(1))
+			Expect(first.Tag("retried")).To(Equal(true))
+			Expect(first.Tag(string(ext.Error))).To(Equal(true))
+			Expect(first.Tag("conn.reused")).To(Equal(false))
+
+			Expect(second.ParentID).To(Equal(request.SpanContext.SpanID))
+			Expect(second.Tag("attempt")).To(Equal(2))
+			Expect(second.Tag("retried")).To(Equal(false))
+			Expect(second.Tag(string(ext.HTTPStatusCode))).To(BeEquivalentTo(http.StatusAccepted))
+			Expect(second.Tag("conn.reused")).To(Equal(false))
+		})
+
+		It("tags each attempt with whether its connection was reused", func() {
+			fakeServer.SetHandler(0, ghttp.RespondWith(http.StatusServiceUnavailable, nil))
+			client = NewClient(fakeServer.URL(), WithTracer(tracer), WithRetries(2), WithRetryAfterSend(),
+				WithFailureClassifier(func(resp *http.Response, err error) Classification {
+					if err == nil && resp.StatusCode == http.StatusServiceUnavailable {
+						return ClassificationRetryableError
+					}
+					return ClassificationSuccess
+				}))
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+
+			spans := tracer.FinishedSpans()
+			Expect(spans).To(HaveLen(3))
+			Expect(spans[0].Tag("conn.reused")).To(Equal(false))
+			Expect(spans[1].Tag("conn.reused")).To(Equal(true))
+		})
+
+		It("propagates each attempt's span to the auctioneer", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+
+			spans := tracer.FinishedSpans()
+			Expect(spanIDs).To(Equal([]string{
+				strconv.Itoa(spans[0].SpanContext.SpanID),
+				strconv.Itoa(spans[1].SpanContext.SpanID),
+			}))
+		})
+	})
+
+	Describe("WithSpanNamer", func() {
+		BeforeEach(func() {
+			client = NewClient(fakeServer.URL(), WithTracer(tracer), WithSpanNamer(func(op string) string {
+				return "auctioneer." + op
+			}))
+		})
+
+		It("names each span after the operation it was made for", func() {
+			fakeServer.AppendHandlers(
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+				ghttp.RespondWith(http.StatusAccepted, "{}"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, DryRunResult{}),
+				ghttp.RespondWith(http.StatusOK, nil),
+			)
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			Expect(client.RequestTaskAuctions(logger, []*TaskStartRequest{})).To(Succeed())
+			_, err := client.DryRunLRPAuctions(logger, context.Background(), []*LRPStartRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = client.Ping(logger, context.Background())
+			Expect(err).NotTo(HaveOccurred())
+
+			var names []string
+			for _, span := range tracer.FinishedSpans() {
+				names = append(names, span.OperationName)
+			}
+			Expect(names).To(Equal([]string{
+				"auctioneer.RequestLRPAuctions",
+				"auctioneer.RequestTaskAuctions",
+				"auctioneer.DryRunLRPAuctions",
+				"auctioneer.Ping",
+			}))
+		})
+
+		It("keeps the names of attempt spans", func() {
+			client = NewClient(fakeServer.URL(), WithTracer(tracer), WithRetries(1), WithSpanNamer(func(op string) string {
+				return "auctioneer." + op
+			}))
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+
+			spans := tracer.FinishedSpans()
+			Expect(spans).To(HaveLen(2))
+			Expect(spans[0].OperationName).To(Equal("HTTP POST attempt"))
+			Expect(spans[1].OperationName).To(Equal("auctioneer.RequestLRPAuctions"))
+		})
+	})
+})
@@ -0,0 +1,43 @@
+package auctioneer
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// Trailers sent with auction batches by clients configured with
+// WithBatchTrailers.
+const (
+	BatchChecksumTrailer = "X-Auctioneer-Batch-Checksum"
+	BatchIDTrailer       = "X-Auctioneer-Batch-Id"
+)
+
+// WithBatchTrailers sends each auction batch with HTTP trailers carrying the
+// SHA-256 checksum of the body, as "sha256=<hex>", and a random batch ID,
+// so that an auctioneer can verify the batch before acting on it. Retries
+// of a batch carry the same ID. Trailers require a chunked body, so the
+// request is sent without a Content-Length.
+func WithBatchTrailers() ClientOption {
+	return func(c *auctioneerClient) {
+		c.batchTrailers = true
+	}
+}
+
+func setBatchTrailers(req *http.Request, body []byte) error {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(body)
+
+	req.ContentLength = 0
+	req.Trailer = http.Header{
+		BatchChecksumTrailer: []string{"sha256=" + hex.EncodeToString(sum[:])},
+		BatchIDTrailer:       []string{hex.EncodeToString(id)},
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package auctioneer_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Batch trailers", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		trailers   chan http.Header
+		bodies     chan []byte
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		trailers = make(chan http.Header, 2)
+		bodies = make(chan []byte, 2)
+
+		fakeServer.RouteToHandler("POST", "/v1/lrps", ghttp.CombineHandlers(
+			func(w http.ResponseWriter, r *http.Request) {
+				body, err := ioutil.ReadAll(r.Body)
+				Expect(err).NotTo(HaveOccurred())
+				bodies <- body
+				trailers <- r.Trailer
+			},
+			ghttp.RespondWith(http.StatusAccepted, "{}"),
+		))
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("does not send trailers by default", func() {
+		client := NewClient(fakeServer.URL())
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{{ProcessGuid: "some-guid"}})).To(Succeed())
+
+		Expect(<-trailers).To(BeEmpty())
+	})
+
+	Context("WithBatchTrailers", func() {
+		var client ExtendedClient
+
+		BeforeEach(func() {
+			client = NewClient(fakeServer.URL(), WithBatchTrailers())
+		})
+
+		It("sends the checksum of the body and a batch ID", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{{ProcessGuid: "some-guid"}})).To(Succeed())
+
+			body := <-bodies
+			sum := sha256.Sum256(body)
+			trailer := <-trailers
+			Expect(trailer.Get(BatchChecksumTrailer)).To(Equal("sha256=" + hex.EncodeToString(sum[:])))
+			Expect(trailer.Get(BatchIDTrailer)).To(HaveLen(32))
+		})
+
+		It("sends a distinct ID with each batch", func() {
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+			Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+
+			first := <-trailers
+			second := <-trailers
+			Expect(first.Get(BatchIDTrailer)).NotTo(Equal(second.Get(BatchIDTrailer)))
+		})
+	})
+})
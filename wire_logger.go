@@ -0,0 +1,85 @@
+package auctioneer
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Directions passed to the callback of WithWireLogger.
+const (
+	WireRequest  = "request"
+	WireResponse = "response"
+)
+
+// WireLogMaxBytes is the most of each body passed to the callback of
+// WithWireLogger; longer bodies are truncated.
+const WireLogMaxBytes = 64 * 1024
+
+// WithWireLogger calls log with the body of each request as sent, with dir
+// WireRequest, and of each response as received, with dir WireResponse, for
+// debugging what the auctioneer actually sees. Bodies are truncated to
+// WireLogMaxBytes. log owns b and may retain it.
+func WithWireLogger(log func(dir string, b []byte)) ClientOption {
+	return func(c *auctioneerClient) {
+		c.wireLogger = log
+	}
+}
+
+func (c *auctioneerClient) logRequestWire(req *http.Request) {
+	if c.wireLogger == nil || req.GetBody == nil {
+		return
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	b, err := ioutil.ReadAll(io.LimitReader(body, WireLogMaxBytes))
+	if err != nil {
+		return
+	}
+	c.wireLogger(WireRequest, b)
+}
+
+// logResponseWire reads the start of the response body for the wire logger
+// and puts it back in front of the rest, so the caller reads the body
+// unchanged.
+func (c *auctioneerClient) logResponseWire(resp *http.Response) {
+	if c.wireLogger == nil {
+		return
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, WireLogMaxBytes))
+	c.wireLogger(WireResponse, b)
+
+	resp.Body = &replayedBody{
+		Reader: io.MultiReader(bytes.NewReader(b), errReader{err: err}, resp.Body),
+		body:   resp.Body,
+	}
+}
+
+type replayedBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+func (b *replayedBody) Close() error {
+	return b.body.Close()
+}
+
+// errReader returns err, or io.EOF when err is nil, so that a read error hit
+// while logging is surfaced to the caller in sequence.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	return 0, io.EOF
+}
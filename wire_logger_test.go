@@ -0,0 +1,82 @@
+package auctioneer_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	. "code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+type wireEntry struct {
+	dir  string
+	body string
+}
+
+var _ = Describe("WithWireLogger", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeServer *ghttp.Server
+		client     ExtendedClient
+		lock       sync.Mutex
+		entries    []wireEntry
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeServer = ghttp.NewServer()
+		entries = nil
+		client = NewClient(fakeServer.URL(), WithWireLogger(func(dir string, b []byte) {
+			lock.Lock()
+			defer lock.Unlock()
+			entries = append(entries, wireEntry{dir: dir, body: string(b)})
+		}))
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("logs the request and response bodies", func() {
+		lrpStarts := []*LRPStartRequest{{ProcessGuid: "some-guid"}}
+		fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, "{}"))
+
+		Expect(client.RequestLRPAuctions(logger, lrpStarts)).To(Succeed())
+
+		expectedBody, err := json.Marshal(lrpStarts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(Equal([]wireEntry{
+			{dir: WireRequest, body: string(expectedBody)},
+			{dir: WireResponse, body: "{}"},
+		}))
+	})
+
+	It("leaves the response body readable", func() {
+		fakeServer.AppendHandlers(ghttp.RespondWithJSONEncoded(http.StatusOK, BatchStatus{State: BatchStateComplete}))
+
+		status, err := client.WaitForBatch(logger, context.Background(), fakeServer.URL()+"/v1/batches/some-batch")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.State).To(Equal(BatchStateComplete))
+
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].dir).To(Equal(WireResponse))
+		Expect(entries[0].body).To(ContainSubstring(`"complete"`))
+	})
+
+	It("truncates large bodies", func() {
+		large := strings.Repeat("a", WireLogMaxBytes+1)
+		fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusAccepted, large))
+
+		Expect(client.RequestLRPAuctions(logger, []*LRPStartRequest{})).To(Succeed())
+
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[1].body).To(HaveLen(WireLogMaxBytes))
+	})
+})